@@ -0,0 +1,285 @@
+package connector
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"maunium.net/go/mautrix"
+	"maunium.net/go/mautrix/bridgev2/commands"
+	"maunium.net/go/mautrix/bridgev2/matrix"
+	"maunium.net/go/mautrix/pushrules"
+
+	"go.mau.fi/mautrix-zulip/pkg/zid"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/alertwords"
+)
+
+// alertWordActions are the actions of keyword push rules created for alert
+// words, which match the notification of a mention like on Zulip.
+var alertWordActions = pushrules.PushActionArray{
+	{Action: pushrules.ActionNotify},
+	{Action: pushrules.ActionSetTweak, Tweak: pushrules.TweakSound, Value: "default"},
+	{Action: pushrules.ActionSetTweak, Tweak: pushrules.TweakHighlight, Value: true},
+}
+
+type reqPutKeywordRule struct {
+	Actions pushrules.PushActionArray `json:"actions"`
+	Pattern string                    `json:"pattern"`
+}
+
+// alertWordRulePrefix starts the IDs of the keyword push rules made for alert
+// words. It's followed by the login ID, so that the rules of different logins
+// that share a double puppet are kept apart.
+const alertWordRulePrefix = "fi.mau.zulip.alert_word."
+
+// alertWordGlobChars are the characters that have a special meaning in push
+// rule patterns. Alert words with them aren't synced, as they can't be escaped.
+const alertWordGlobChars = "*?"
+
+// syncAlertWords syncs the user's Zulip alert words with the keyword (content)
+// push rules of their double puppet in both directions.
+//
+// Push rule changes aren't sent to the bridge, so the sync happens whenever
+// the alert words change on Zulip, when connecting, and with the
+// sync-alert-words command. Words are compared against the last synced set
+// to tell whether they were added on one side or removed on the other.
+//
+// Keywords added by Matrix clients are synced too, so with several logins a
+// new keyword is added as an alert word on each of them. Which words came from
+// which side is only tracked through the words of the last sync.
+func (zc *ZulipClient) syncAlertWords(ctx context.Context, zulipWords []string) {
+	err := zc.doSyncAlertWords(ctx, zulipWords)
+	if err != nil {
+		zerolog.Ctx(ctx).Err(err).Msg("Failed to sync alert words with push rules")
+	}
+}
+
+// alertWordRuleID makes the ID of the keyword push rule for an alert word.
+// The word is hashed, as rule IDs are used in URLs and can't have slashes.
+func (zc *ZulipClient) alertWordRuleID(word string) string {
+	hash := sha256.Sum256([]byte(strings.ToLower(word)))
+	return zc.alertWordRuleIDPrefix() + hex.EncodeToString(hash[:8])
+}
+
+func (zc *ZulipClient) alertWordRuleIDPrefix() string {
+	return alertWordRulePrefix + string(zc.UserLogin.ID) + "."
+}
+
+func (zc *ZulipClient) doSyncAlertWords(ctx context.Context, zulipWords []string) error {
+	cli := zc.getDoublePuppetClient(ctx)
+	if cli == nil {
+		return nil
+	}
+	zc.alertWordsLock.Lock()
+	defer zc.alertWordsLock.Unlock()
+	log := zerolog.Ctx(ctx)
+	ruleset, err := cli.GetPushRules(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get push rules: %w", err)
+	}
+	rules, matrixWords := zc.collectKeywordRules(ruleset.Content)
+	meta := zc.UserLogin.Metadata.(*zid.UserLoginMetadata)
+	changes := mergeAlertWords(zulipWords, matrixWords, meta.SyncedAlertWords)
+
+	for _, word := range changes.addToMatrix {
+		log.Debug().Str("word", word).Msg("Adding keyword push rule for alert word")
+		ruleID := zc.alertWordRuleID(word)
+		var existing *pushrules.PushRule
+		if existingRules := rules[strings.ToLower(word)]; len(existingRules) > 0 {
+			existing = existingRules[0]
+			ruleID = existing.RuleID
+		}
+		_, err = cli.MakeRequest(ctx, http.MethodPut, cli.BuildClientURL("v3", "pushrules", "global", pushrules.ContentRule, ruleID), &reqPutKeywordRule{
+			Actions: alertWordActions,
+			Pattern: word,
+		}, nil)
+		if err != nil {
+			return fmt.Errorf("failed to add push rule for %q: %w", word, err)
+		} else if existing != nil && !existing.Enabled {
+			// Updating a rule doesn't change whether it's enabled
+			_, err = cli.MakeRequest(ctx, http.MethodPut, cli.BuildClientURL("v3", "pushrules", "global", pushrules.ContentRule, ruleID, "enabled"), &reqPutPushRuleEnabled{
+				Enabled: true,
+			}, nil)
+			if err != nil {
+				return fmt.Errorf("failed to enable push rule for %q: %w", word, err)
+			}
+		}
+	}
+	for _, word := range changes.removeFromMatrix {
+		// All rules for the word are removed, as any one left would add it back on the next sync
+		for _, rule := range rules[strings.ToLower(word)] {
+			log.Debug().Str("rule_id", rule.RuleID).Msg("Removing keyword push rule of removed alert word")
+			err = cli.DeletePushRule(ctx, "global", pushrules.ContentRule, rule.RuleID)
+			if err != nil {
+				return fmt.Errorf("failed to remove push rule %q: %w", rule.RuleID, err)
+			}
+		}
+	}
+	if len(changes.addToZulip) > 0 || len(changes.removeFromZulip) > 0 {
+		log.Debug().
+			Strs("added", changes.addToZulip).
+			Strs("removed", changes.removeFromZulip).
+			Msg("Updating alert words from keyword push rules")
+	}
+	svc := alertwords.NewService(zc.Client)
+	if len(changes.removeFromZulip) > 0 {
+		if _, err = svc.RemoveAlertWords(ctx, changes.removeFromZulip); err != nil {
+			return fmt.Errorf("failed to remove alert words: %w", err)
+		}
+	}
+	if len(changes.addToZulip) > 0 {
+		if _, err = svc.AddAlertWords(ctx, changes.addToZulip); err != nil {
+			return fmt.Errorf("failed to add alert words: %w", err)
+		}
+	}
+	if !slices.Equal(changes.synced, meta.SyncedAlertWords) {
+		meta.SyncedAlertWords = changes.synced
+		if err = zc.UserLogin.Save(ctx); err != nil {
+			return fmt.Errorf("failed to save synced alert words: %w", err)
+		}
+	}
+	return nil
+}
+
+// collectKeywordRules finds the keyword push rules that are synced with alert
+// words, grouped by their lowercased pattern, and the words of the enabled ones.
+//
+// Besides the rules made by the bridge for this login, this includes keywords
+// added by Matrix clients, which use the keyword as the rule ID. Rules made
+// for other logins, default rules, rules that don't notify and patterns with
+// glob characters are skipped.
+func (zc *ZulipClient) collectKeywordRules(contentRules pushrules.PushRuleArray) (rules map[string][]*pushrules.PushRule, words []string) {
+	rules = make(map[string][]*pushrules.PushRule)
+	ownPrefix := zc.alertWordRuleIDPrefix()
+	for _, rule := range contentRules {
+		isOwn := strings.HasPrefix(rule.RuleID, ownPrefix)
+		if rule.Default || rule.Pattern == "" || strings.ContainsAny(rule.Pattern, alertWordGlobChars) ||
+			(!isOwn && strings.HasPrefix(rule.RuleID, alertWordRulePrefix)) ||
+			!slices.ContainsFunc(rule.Actions, func(action *pushrules.PushAction) bool {
+				return action.Action == pushrules.ActionNotify
+			}) {
+			continue
+		}
+		// Alert words and keyword rules are both case-insensitive
+		key := strings.ToLower(rule.Pattern)
+		if isOwn {
+			// Prefer updating the bridge's own rule when re-adding a word
+			rules[key] = append([]*pushrules.PushRule{rule}, rules[key]...)
+		} else {
+			rules[key] = append(rules[key], rule)
+		}
+		if rule.Enabled {
+			words = append(words, rule.Pattern)
+		}
+	}
+	return
+}
+
+type reqPutPushRuleEnabled struct {
+	Enabled bool `json:"enabled"`
+}
+
+// alertWordChanges are the changes that make the alert words on Zulip and the
+// keyword push rules on Matrix match.
+type alertWordChanges struct {
+	addToMatrix      []string
+	removeFromMatrix []string
+	addToZulip       []string
+	removeFromZulip  []string
+	// synced is the sorted set of words on both sides after the changes.
+	synced []string
+}
+
+// mergeAlertWords does a three-way merge of the alert words on Zulip and the
+// keywords on Matrix, using the words of the last sync to tell whether a word
+// that is only on one side was added there or removed from the other side.
+// Words are compared case-insensitively, and Zulip words that can't be keyword
+// patterns are left alone.
+func mergeAlertWords(zulipWords, matrixWords, syncedWords []string) (changes alertWordChanges) {
+	toSet := func(words []string) map[string]string {
+		set := make(map[string]string, len(words))
+		for _, word := range words {
+			if !strings.ContainsAny(word, alertWordGlobChars) {
+				set[strings.ToLower(word)] = word
+			}
+		}
+		return set
+	}
+	zulipSet, matrixSet, syncedSet := toSet(zulipWords), toSet(matrixWords), toSet(syncedWords)
+	for key, word := range zulipSet {
+		_, onMatrix := matrixSet[key]
+		_, wasSynced := syncedSet[key]
+		if onMatrix {
+			changes.synced = append(changes.synced, word)
+		} else if wasSynced {
+			changes.removeFromZulip = append(changes.removeFromZulip, word)
+		} else {
+			changes.addToMatrix = append(changes.addToMatrix, word)
+			changes.synced = append(changes.synced, word)
+		}
+	}
+	for key, word := range matrixSet {
+		if _, onZulip := zulipSet[key]; onZulip {
+			continue
+		} else if _, wasSynced := syncedSet[key]; wasSynced {
+			changes.removeFromMatrix = append(changes.removeFromMatrix, word)
+		} else {
+			changes.addToZulip = append(changes.addToZulip, word)
+			changes.synced = append(changes.synced, word)
+		}
+	}
+	for _, words := range []*[]string{
+		&changes.addToMatrix, &changes.removeFromMatrix, &changes.addToZulip, &changes.removeFromZulip, &changes.synced,
+	} {
+		slices.Sort(*words)
+	}
+	return
+}
+
+// getDoublePuppetClient returns the Matrix client of the user's double puppet,
+// or nil if double puppeting isn't enabled for the user.
+func (zc *ZulipClient) getDoublePuppetClient(ctx context.Context) *mautrix.Client {
+	intent, ok := zc.UserLogin.User.DoublePuppet(ctx).(*matrix.ASIntent)
+	if !ok || intent == nil {
+		return nil
+	}
+	return intent.Matrix.Client
+}
+
+var cmdSyncAlertWords = &commands.FullHandler{
+	Func: fnSyncAlertWords,
+	Name: "sync-alert-words",
+	Help: commands.HelpMeta{
+		Section: HelpSectionZulip,
+		Description: "Sync your Zulip alert words with the notification keywords on your Matrix account. " +
+			"Changes on Zulip are synced automatically, but keyword changes on Matrix are only synced when reconnecting or with this command.",
+	},
+	RequiresLogin: true,
+}
+
+func fnSyncAlertWords(ce *commands.Event) {
+	zc := getCommandClient(ce)
+	if zc == nil {
+		return
+	}
+	if zc.getDoublePuppetClient(ce.Ctx) == nil {
+		ce.Reply("Alert words can only be synced when double puppeting is enabled")
+		return
+	}
+	resp, err := alertwords.NewService(zc.Client).GetAlertWords(ce.Ctx)
+	if err != nil {
+		ce.Reply("Failed to get alert words: %v", err)
+		return
+	}
+	err = zc.doSyncAlertWords(ce.Ctx, resp.AlertWords)
+	if err != nil {
+		ce.Reply("Failed to sync alert words: %v", err)
+		return
+	}
+	ce.React("✅")
+}
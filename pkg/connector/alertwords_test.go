@@ -0,0 +1,120 @@
+package connector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/database"
+	"maunium.net/go/mautrix/pushrules"
+
+	"go.mau.fi/mautrix-zulip/pkg/zid"
+)
+
+func TestMergeAlertWords(t *testing.T) {
+	tests := []struct {
+		name     string
+		zulip    []string
+		matrix   []string
+		synced   []string
+		expected alertWordChanges
+	}{
+		{
+			name:     "first sync",
+			zulip:    []string{"deploy", "Outage"},
+			expected: alertWordChanges{addToMatrix: []string{"Outage", "deploy"}, synced: []string{"Outage", "deploy"}},
+		},
+		{
+			name:     "in sync",
+			zulip:    []string{"deploy"},
+			matrix:   []string{"DEPLOY"},
+			synced:   []string{"deploy"},
+			expected: alertWordChanges{synced: []string{"deploy"}},
+		},
+		{
+			name:     "added on zulip",
+			zulip:    []string{"deploy", "outage"},
+			matrix:   []string{"deploy"},
+			synced:   []string{"deploy"},
+			expected: alertWordChanges{addToMatrix: []string{"outage"}, synced: []string{"deploy", "outage"}},
+		},
+		{
+			name:     "removed on zulip",
+			matrix:   []string{"deploy"},
+			synced:   []string{"deploy"},
+			expected: alertWordChanges{removeFromMatrix: []string{"deploy"}},
+		},
+		{
+			name:     "added on matrix",
+			zulip:    []string{"deploy"},
+			matrix:   []string{"deploy", "outage"},
+			synced:   []string{"deploy"},
+			expected: alertWordChanges{addToZulip: []string{"outage"}, synced: []string{"deploy", "outage"}},
+		},
+		{
+			name:     "removed on matrix",
+			zulip:    []string{"deploy", "outage"},
+			matrix:   []string{"deploy"},
+			synced:   []string{"deploy", "outage"},
+			expected: alertWordChanges{removeFromZulip: []string{"outage"}, synced: []string{"deploy"}},
+		},
+		{
+			name:     "removed on both",
+			synced:   []string{"deploy"},
+			expected: alertWordChanges{},
+		},
+		{
+			name:     "glob characters",
+			zulip:    []string{"deploy*", "what?"},
+			matrix:   []string{"out*age"},
+			synced:   []string{"what?"},
+			expected: alertWordChanges{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, mergeAlertWords(test.zulip, test.matrix, test.synced))
+		})
+	}
+}
+
+func TestAlertWordRuleID(t *testing.T) {
+	newClient := func(userID int) *ZulipClient {
+		return &ZulipClient{UserLogin: &bridgev2.UserLogin{UserLogin: &database.UserLogin{
+			ID: zid.MakeUserLoginID("0123abcd", userID),
+		}}}
+	}
+	zc := newClient(10)
+	ruleID := zc.alertWordRuleID("CI/CD")
+	assert.Equal(t, ruleID, zc.alertWordRuleID("ci/cd"))
+	assert.NotContains(t, ruleID, "/")
+	assert.Regexp(t, `^fi\.mau\.zulip\.alert_word\.0123abcd\.10\.[0-9a-f]{16}$`, ruleID)
+	assert.NotEqual(t, ruleID, newClient(11).alertWordRuleID("CI/CD"))
+}
+
+func TestCollectKeywordRules(t *testing.T) {
+	zc := &ZulipClient{UserLogin: &bridgev2.UserLogin{UserLogin: &database.UserLogin{
+		ID: zid.MakeUserLoginID("0123abcd", 10),
+	}}}
+	notify := pushrules.PushActionArray{{Action: pushrules.ActionNotify}}
+	ownDeploy := &pushrules.PushRule{RuleID: zc.alertWordRuleID("deploy"), Pattern: "deploy", Enabled: true, Actions: notify}
+	elementDeploy := &pushrules.PushRule{RuleID: "Deploy", Pattern: "Deploy", Enabled: true, Actions: notify}
+	rules, words := zc.collectKeywordRules(pushrules.PushRuleArray{
+		elementDeploy,
+		ownDeploy,
+		{RuleID: "outage", Pattern: "outage", Enabled: true, Actions: notify},
+		{RuleID: "paused", Pattern: "paused", Enabled: false, Actions: notify},
+		{RuleID: "muted", Pattern: "muted", Enabled: true, Actions: pushrules.PushActionArray{}},
+		{RuleID: "glob", Pattern: "out*age", Enabled: true, Actions: notify},
+		{RuleID: ".m.rule.contains_user_name", Pattern: "alice", Enabled: true, Default: true, Actions: notify},
+		{RuleID: alertWordRulePrefix + "0123abcd.11.0011223344556677", Pattern: "other", Enabled: true, Actions: notify},
+	})
+	assert.Equal(t, []string{"Deploy", "deploy", "outage"}, words)
+	assert.Equal(t, []*pushrules.PushRule{ownDeploy, elementDeploy}, rules["deploy"])
+	assert.Len(t, rules["outage"], 1)
+	assert.Len(t, rules["paused"], 1)
+	assert.NotContains(t, rules, "muted")
+	assert.NotContains(t, rules, "out*age")
+	assert.NotContains(t, rules, "alice")
+	assert.NotContains(t, rules, "other")
+}
@@ -2,6 +2,7 @@ package connector
 
 import (
 	"context"
+	"fmt"
 
 	"maunium.net/go/mautrix/bridgev2"
 	"maunium.net/go/mautrix/event"
@@ -19,19 +20,84 @@ func (zc *ZulipConnector) GetCapabilities() *bridgev2.NetworkGeneralCapabilities
 		AggressiveUpdateInfo:    false,
 		ImplicitReadReceipts:    false,
 		OutgoingMessageTimeouts: nil,
-		Provisioning:            bridgev2.ProvisioningCapabilities{},
+		Provisioning: bridgev2.ProvisioningCapabilities{
+			ResolveIdentifier: bridgev2.ResolveIdentifierCapabilities{
+				CreateDM:       true,
+				LookupEmail:    true,
+				LookupUsername: true,
+				ContactList:    true,
+				Search:         true,
+			},
+			GroupCreation: map[string]bridgev2.GroupTypeCapabilities{
+				groupDMType: {
+					TypeDescription: "a group DM",
+					Participants: bridgev2.GroupFieldCapability{
+						Allowed:   true,
+						Required:  true,
+						MinLength: 2,
+					},
+				},
+				channelType:        channelCreationCapabilities("a public channel"),
+				privateChannelType: channelCreationCapabilities("a private channel"),
+			},
+		},
+	}
+}
+
+func channelCreationCapabilities(description string) bridgev2.GroupTypeCapabilities {
+	return bridgev2.GroupTypeCapabilities{
+		TypeDescription: description,
+		Name: bridgev2.GroupFieldCapability{
+			Allowed:   true,
+			Required:  true,
+			MaxLength: maxChannelNameLength,
+		},
+		Topic: bridgev2.GroupFieldCapability{
+			Allowed:   true,
+			MaxLength: maxChannelDescriptionLength,
+		},
+		Participants: bridgev2.GroupFieldCapability{
+			Allowed: true,
+		},
+	}
+}
+
+func makeFileFeatures(maxSize int64) *event.FileFeatures {
+	return &event.FileFeatures{
+		MimeTypes: map[string]event.CapabilitySupportLevel{
+			"*/*": event.CapLevelFullySupported,
+		},
+		Caption: event.CapLevelFullySupported,
+		MaxSize: maxSize,
 	}
 }
 
 func (zc *ZulipClient) GetCapabilities(ctx context.Context, portal *bridgev2.Portal) *event.RoomFeatures {
+	maxSize := int64(zc.UserLogin.Metadata.(*zid.UserLoginMetadata).MaxFileUploadSizeMiB) * 1024 * 1024
+	fileFeatures := makeFileFeatures(maxSize)
 	caps := &event.RoomFeatures{
-		ID:     "fi.mau.zulip.capabilities.2025_10_02",
+		ID: fmt.Sprintf("fi.mau.zulip.capabilities.2026_10_19+maxsize_%d", maxSize),
+		File: event.FileFeatureMap{
+			event.MsgImage: fileFeatures,
+			event.MsgVideo: fileFeatures,
+			event.MsgAudio: fileFeatures,
+			event.MsgFile:  fileFeatures,
+		},
 		Thread: event.CapLevelFullySupported,
+		Edit:   event.CapLevelFullySupported,
+		// Replies are sent as quotes
+		Reply: event.CapLevelPartialSupport,
+		// Only the star reaction is bridged, which stars the message
+		Reaction:         event.CapLevelPartialSupport,
+		AllowedReactions: []string{starEmoji},
 	}
-	_, userIDs, _ := zid.ParsePortalID(portal.ID)
+	_, _, userIDs, _ := zid.ParsePortalID(portal.ID)
 	if userIDs != nil {
 		caps.ID += "+dm"
 		caps.Thread = event.CapLevelUnsupported
+	} else if _, _, _, isTopic := zid.ParseTopicPortalID(portal.ID); isTopic {
+		caps.ID += "+topic"
+		caps.Thread = event.CapLevelUnsupported
 	}
 	return caps
 }
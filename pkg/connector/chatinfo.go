@@ -18,42 +18,81 @@ import (
 
 	"go.mau.fi/mautrix-zulip/pkg/zid"
 	"go.mau.fi/mautrix-zulip/pkg/zulip/channels"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/realtime/events"
 	"go.mau.fi/mautrix-zulip/pkg/zulip/users"
 )
 
 func (zc *ZulipClient) IsThisUser(ctx context.Context, userID networkid.UserID) bool {
-	return zid.ParseUserID(userID) == zc.ownUserID
+	return userID == zid.MakeUserID(zc.realm, zc.ownUserID)
 }
 
 func (zc *ZulipClient) GetChatInfo(ctx context.Context, portal *bridgev2.Portal) (*bridgev2.ChatInfo, error) {
-	streamID, userIDs, err := zid.ParsePortalID(portal.ID)
+	if isRealm, isDMs := zid.IsSpacePortalID(portal.ID); isRealm {
+		return zc.getRealmSpaceInfo(ctx)
+	} else if isDMs {
+		return zc.wrapDMSpaceInfo(), nil
+	}
+	_, streamID, userIDs, err := zid.ParsePortalID(portal.ID)
 	if err != nil {
 		return nil, err
 	} else if userIDs != nil {
 		return zc.wrapDMInfo(userIDs)
-	} else {
-		srv := channels.NewService(zc.Client)
-		chat, err := srv.GetChannelByID(ctx, streamID)
-		if err != nil {
-			return nil, err
-		}
-		members, err := srv.GetChannelSubscribers(ctx, streamID)
-		if err != nil {
-			return nil, err
-		}
-		return zc.wrapChannelInfo(chat.Stream, members.Subscribers)
 	}
+	if _, topic, ok := getPortalTopic(portal); ok {
+		return zc.getTopicChatInfo(ctx, streamID, topic)
+	}
+	name, description, subscribers, err := zc.getChannelInfo(ctx, streamID)
+	if err != nil {
+		return nil, err
+	}
+	info, err := zc.wrapChannelInfo(name, description, subscribers)
+	if info != nil {
+		info.UserLocal = zc.getChannelUserLocalInfo(streamID)
+	}
+	return info, err
+}
+
+// getTopicChatInfo returns the info of a topic room. The topic is the display
+// name, which can't be known from the portal ID alone.
+func (zc *ZulipClient) getTopicChatInfo(ctx context.Context, streamID int, topic string) (*bridgev2.ChatInfo, error) {
+	_, _, subscribers, err := zc.getChannelInfo(ctx, streamID)
+	if err != nil {
+		return nil, err
+	}
+	info, err := zc.wrapTopicInfo(streamID, topic, subscribers)
+	if info != nil {
+		info.UserLocal = zc.getTopicUserLocalInfo(streamID, topic)
+	}
+	return info, err
+}
+
+// getChannelInfo returns the name, description and subscribers of a channel,
+// preferring the cached subscription over fetching it from the server.
+func (zc *ZulipClient) getChannelInfo(ctx context.Context, streamID int) (name, description string, subscribers []int, err error) {
+	if sub, ok := zc.Realm.GetSubscription(streamID); ok {
+		return sub.Name, sub.Description, sub.Subscribers, nil
+	}
+	srv := channels.NewService(zc.Client)
+	chat, err := srv.GetChannelByID(ctx, streamID)
+	if err != nil {
+		return
+	}
+	members, err := srv.GetChannelSubscribers(ctx, streamID)
+	if err != nil {
+		return
+	}
+	return chat.Stream.Name, chat.Stream.Description, members.Subscribers, nil
 }
 
 func (zc *ZulipClient) wrapDMInfo(members []int) (*bridgev2.ChatInfo, error) {
 	var otherUserID networkid.UserID
 	portalType := database.RoomTypeGroupDM
 	if len(members) == 1 {
-		otherUserID = zid.MakeUserID(members[0])
+		otherUserID = zid.MakeUserID(zc.realm, members[0])
 		portalType = database.RoomTypeDM
 	}
 	memberMap := zc.makeMemberMap(members)
-	memberMap[zid.MakeUserID(zc.ownUserID)] = bridgev2.ChatMember{
+	memberMap[zid.MakeUserID(zc.realm, zc.ownUserID)] = bridgev2.ChatMember{
 		EventSender: zc.makeEventSender(zc.ownUserID),
 		Membership:  event.MembershipJoin,
 	}
@@ -64,14 +103,15 @@ func (zc *ZulipClient) wrapDMInfo(members []int) (*bridgev2.ChatInfo, error) {
 			MemberMap:        memberMap,
 			OtherUserID:      otherUserID,
 		},
-		Type: &portalType,
+		Type:     &portalType,
+		ParentID: ptr.Ptr(zc.dmParentID()),
 	}, nil
 }
 
 func (zc *ZulipClient) makeMemberMap(members []int) map[networkid.UserID]bridgev2.ChatMember {
 	memberMap := make(map[networkid.UserID]bridgev2.ChatMember, len(members))
 	for _, m := range members {
-		memberMap[zid.MakeUserID(m)] = bridgev2.ChatMember{
+		memberMap[zid.MakeUserID(zc.realm, m)] = bridgev2.ChatMember{
 			EventSender: zc.makeEventSender(m),
 			Membership:  event.MembershipJoin,
 		}
@@ -79,22 +119,67 @@ func (zc *ZulipClient) makeMemberMap(members []int) map[networkid.UserID]bridgev
 	return memberMap
 }
 
-func (zc *ZulipClient) wrapChannelInfo(channel channels.ChannelInfo, members []int) (*bridgev2.ChatInfo, error) {
+// wrapChannelInfo makes the info of a channel room, which is a space of topic
+// rooms when topics are bridged as separate rooms.
+func (zc *ZulipClient) wrapChannelInfo(name, description string, members []int) (*bridgev2.ChatInfo, error) {
+	roomType := database.RoomTypeDefault
+	if zc.Main.Config.RoomPerTopic {
+		roomType = database.RoomTypeSpace
+	}
 	return &bridgev2.ChatInfo{
-		Name:  &channel.Name,
-		Topic: &channel.Description,
+		Name:  &name,
+		Topic: &description,
 		Members: &bridgev2.ChatMemberList{
 			IsFull:           members != nil,
 			TotalMemberCount: len(members),
 			MemberMap:        zc.makeMemberMap(members),
 			PowerLevels:      nil, // TODO
 		},
-		Type: ptr.Ptr(database.RoomTypeDefault),
+		Type:     &roomType,
+		ParentID: ptr.Ptr(zc.realmSpaceID()),
+	}, nil
+}
+
+// emptyTopicName is the name Zulip shows for the topic with an empty name.
+const emptyTopicName = "general chat"
+
+// wrapTopicInfo makes the info of a topic room, which is in the space of its
+// channel. Topics don't have their own member lists, so all subscribers of
+// the channel are members.
+func (zc *ZulipClient) wrapTopicInfo(streamID int, topic string, members []int) (*bridgev2.ChatInfo, error) {
+	name := topic
+	if name == "" {
+		name = emptyTopicName
+	}
+	return &bridgev2.ChatInfo{
+		Name: &name,
+		Members: &bridgev2.ChatMemberList{
+			IsFull:           members != nil,
+			TotalMemberCount: len(members),
+			MemberMap:        zc.makeMemberMap(members),
+		},
+		Type:     ptr.Ptr(database.RoomTypeDefault),
+		ParentID: ptr.Ptr(zid.MakeChannelPortalID(zc.realm, streamID)),
+		ExtraUpdates: func(ctx context.Context, portal *bridgev2.Portal) bool {
+			meta := portal.Metadata.(*zid.PortalMetadata)
+			if meta.TopicName == topic {
+				return false
+			}
+			meta.TopicName = topic
+			return true
+		},
 	}, nil
 }
 
 func (zc *ZulipClient) GetUserInfo(ctx context.Context, ghost *bridgev2.Ghost) (*bridgev2.UserInfo, error) {
-	user, err := users.NewService(zc.Client).GetUser(ctx, zid.ParseUserID(ghost.ID))
+	realm, userID := zid.ParseUserID(ghost.ID)
+	if realm != zc.realm {
+		return nil, fmt.Errorf("ghost %s is not in the realm of this login", ghost.ID)
+	}
+	if person, ok := zc.Realm.GetUser(userID); ok {
+		return wrapPersonInfo(person)
+	}
+	user, err := users.NewService(zc.Client).GetUser(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -106,16 +191,24 @@ var AvatarClient = &http.Client{
 }
 
 func wrapUserInfo(user users.UserData) (*bridgev2.UserInfo, error) {
+	return makeUserInfo(user.FullName, user.DeliveryEmail, user.AvatarURL, user.AvatarVersion, user.IsBot), nil
+}
+
+func wrapPersonInfo(person events.Person) (*bridgev2.UserInfo, error) {
+	return makeUserInfo(person.FullName, person.DeliveryEmail, person.AvatarURL, person.AvatarVersion, person.IsBot), nil
+}
+
+func makeUserInfo(fullName, email, avatarURL string, avatarVersion int, isBot bool) *bridgev2.UserInfo {
 	var identifiers []string
-	if user.DeliveryEmail != "" {
-		identifiers = []string{"mailto:" + user.DeliveryEmail}
+	if email != "" {
+		identifiers = []string{"mailto:" + email}
 	}
 	return &bridgev2.UserInfo{
 		Identifiers: identifiers,
-		Name:        &user.FullName,
-		Avatar:      wrapAvatar(user.AvatarVersion, user.AvatarURL, user.DeliveryEmail),
-		IsBot:       &user.IsBot,
-	}, nil
+		Name:        &fullName,
+		Avatar:      wrapAvatar(avatarVersion, avatarURL, email),
+		IsBot:       &isBot,
+	}
 }
 
 func wrapAvatar(version int, url, email string) *bridgev2.Avatar {
@@ -129,18 +222,24 @@ func wrapAvatar(version int, url, email string) *bridgev2.Avatar {
 	return &bridgev2.Avatar{
 		ID: networkid.AvatarID(strconv.Itoa(version)),
 		Get: func(ctx context.Context) ([]byte, error) {
-			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-			if err != nil {
-				return nil, err
-			}
-			req.Header.Set("User-Agent", mautrix.DefaultUserAgent)
-			resp, err := AvatarClient.Do(req)
-			if err != nil {
-				return nil, err
-			} else if resp.StatusCode >= 300 {
-				return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
-			}
-			return io.ReadAll(resp.Body)
+			return downloadAvatar(ctx, url)
 		},
 	}
 }
+
+func downloadAvatar(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", mautrix.DefaultUserAgent)
+	resp, err := AvatarClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,39 @@
+package connector
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/database"
+	"maunium.net/go/mautrix/bridgev2/networkid"
+
+	"go.mau.fi/mautrix-zulip/pkg/zid"
+)
+
+func TestGetChatInfoSpaces(t *testing.T) {
+	zc := newTestClient(t, Config{DMSpace: true}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v1/server_settings", r.URL.Path)
+		_, _ = w.Write([]byte(`{"result": "success", "msg": "", "realm_name": "Example", "realm_description": "An example realm"}`))
+	}))
+	ctx := context.Background()
+
+	info, err := zc.GetChatInfo(ctx, &bridgev2.Portal{Portal: &database.Portal{
+		PortalKey: zc.realmSpaceKey(),
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "Example", *info.Name)
+	assert.Equal(t, database.RoomTypeSpace, *info.Type)
+	assert.Nil(t, info.ParentID)
+
+	info, err = zc.GetChatInfo(ctx, &bridgev2.Portal{Portal: &database.Portal{
+		PortalKey: networkid.PortalKey{ID: zid.MakeDMSpacePortalID(zc.realm, testOwnUserID)},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, dmSpaceName, *info.Name)
+	assert.Equal(t, database.RoomTypeSpace, *info.Type)
+	assert.Equal(t, zc.realmSpaceID(), *info.ParentID)
+}
@@ -4,13 +4,16 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	slogzerolog "github.com/samber/slog-zerolog/v2"
+	"golang.org/x/sync/semaphore"
 	"maunium.net/go/mautrix"
 	"maunium.net/go/mautrix/bridgev2"
 
+	"go.mau.fi/mautrix-zulip/pkg/msgconv"
 	"go.mau.fi/mautrix-zulip/pkg/zid"
 	"go.mau.fi/mautrix-zulip/pkg/zulip"
 )
@@ -19,30 +22,51 @@ type ZulipClient struct {
 	Main      *ZulipConnector
 	UserLogin *bridgev2.UserLogin
 	Client    *zulip.Client
+	Realm     *RealmState
 
-	stopPoll    atomic.Pointer[context.CancelFunc]
-	pollStopped atomic.Pointer[chan struct{}]
-	ownUserID   int
+	stopPoll       atomic.Pointer[context.CancelFunc]
+	pollStopped    atomic.Pointer[chan struct{}]
+	realm          zid.Realm
+	ownUserID      int
+	mediaDownloads *semaphore.Weighted
+	alertWordsLock sync.Mutex
+
+	topicParticipants     map[userTopicKey]cachedTopicParticipants
+	topicParticipantsLock sync.Mutex
 }
 
+// maxConcurrentMediaDownloads is how many files are downloaded from Zulip at
+// the same time per login when converting messages.
+const maxConcurrentMediaDownloads = 4
+
+var _ msgconv.MediaDownloadLimiter = (*ZulipClient)(nil)
+
 func (zc *ZulipConnector) LoadUserLogin(ctx context.Context, login *bridgev2.UserLogin) error {
 	meta := login.Metadata.(*zid.UserLoginMetadata)
-	httpClient := &http.Client{Timeout: 180 * time.Second}
+	// Requests have individual timeouts set by the zulip client, a global one would break large uploads.
+	httpClient := &http.Client{}
 	zulipLog := login.Log.With().Str("component", "zulip").Logger()
 	cli, err := zulip.NewClient(
 		zulip.Credentials(meta.URL, meta.Email, meta.Token),
 		zulip.WithCustomUserAgent(mautrix.DefaultUserAgent),
 		zulip.WithLogger(slog.New(slogzerolog.Option{Logger: &zulipLog}.NewZerologHandler())),
 		zulip.WithHTTPClient(httpClient),
+		zulip.WithRateLimiting(zulip.DefaultRateLimitConfig),
+		zulip.WithServerFeatures(meta.ServerFeatures),
 	)
 	if err != nil {
 		return err
 	}
+	_, ownUserID := zid.ParseUserLoginID(login.ID)
 	login.Client = &ZulipClient{
 		Main:      zc,
 		Client:    cli,
 		UserLogin: login,
-		ownUserID: zid.ParseUserLoginID(login.ID),
+		Realm:     newRealmState(),
+		realm:     zid.MakeRealm(meta.URL),
+		ownUserID: ownUserID,
+
+		mediaDownloads: semaphore.NewWeighted(maxConcurrentMediaDownloads),
 	}
 	return nil
 }
@@ -65,6 +89,10 @@ func (zc *ZulipClient) Disconnect() {
 	}
 }
 
+func (zc *ZulipClient) MediaDownloadSemaphore() *semaphore.Weighted {
+	return zc.mediaDownloads
+}
+
 func (zc *ZulipClient) IsLoggedIn() bool {
 	return zc.Client != nil
 }
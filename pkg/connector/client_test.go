@@ -0,0 +1,40 @@
+package connector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/bridgeconfig"
+	"maunium.net/go/mautrix/bridgev2/database"
+
+	"go.mau.fi/mautrix-zulip/pkg/zid"
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+)
+
+const testOwnUserID = 10
+
+// newTestClient makes a client for a login whose Zulip server is the given handler.
+func newTestClient(t *testing.T, config Config, handler http.Handler) *ZulipClient {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	cli, err := zulip.NewClient(zulip.Credentials(srv.URL, "user@example.com", "token"))
+	require.NoError(t, err)
+	realm := zid.MakeRealm(srv.URL)
+	return &ZulipClient{
+		Main:   &ZulipConnector{Config: config},
+		Client: cli,
+		UserLogin: &bridgev2.UserLogin{
+			UserLogin: &database.UserLogin{
+				ID:       zid.MakeUserLoginID(realm, testOwnUserID),
+				Metadata: &zid.UserLoginMetadata{URL: srv.URL},
+			},
+			Bridge: &bridgev2.Bridge{Config: &bridgeconfig.BridgeConfig{}},
+		},
+		Realm:     newRealmState(),
+		realm:     realm,
+		ownUserID: testOwnUserID,
+	}
+}
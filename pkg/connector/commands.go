@@ -0,0 +1,167 @@
+package connector
+
+import (
+	"strings"
+
+	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/commands"
+	"maunium.net/go/mautrix/bridgev2/provisionutil"
+	"maunium.net/go/mautrix/event"
+
+	"go.mau.fi/mautrix-zulip/pkg/zid"
+)
+
+var HelpSectionZulip = commands.HelpSection{Name: "Zulip", Order: 25}
+
+func (zc *ZulipConnector) registerCommands() {
+	proc, ok := zc.Bridge.Commands.(*commands.Processor)
+	if !ok {
+		return
+	}
+	proc.AddHandlers(
+		cmdCreateChannel,
+		cmdCreateTopic,
+		cmdDefaultTopic,
+		cmdMuteTopic,
+		cmdUnmuteTopic,
+		cmdFollowTopic,
+		cmdUnfollowTopic,
+		cmdMuteChannel,
+		cmdUnmuteChannel,
+		cmdStarred,
+		cmdSearchMessages,
+		cmdSchedule,
+		cmdListScheduled,
+		cmdCancelScheduled,
+		cmdSyncAlertWords,
+	)
+}
+
+// getCommandClient returns the login to run a command with: the preferred
+// login of the portal when the command is sent in a portal, and the default
+// login of the user otherwise. It replies with an error if there is none.
+func getCommandClient(ce *commands.Event) *ZulipClient {
+	login := ce.User.GetDefaultLogin()
+	if ce.Portal != nil {
+		var err error
+		login, _, err = ce.Portal.FindPreferredLogin(ce.Ctx, ce.User, false)
+		if err != nil {
+			ce.Log.Err(err).Msg("Failed to find login for portal")
+			ce.Reply("Failed to find your login for this room: %v", err)
+			return nil
+		}
+	}
+	if login == nil {
+		ce.Reply("You're not logged in")
+		return nil
+	}
+	zc, ok := login.Client.(*ZulipClient)
+	if !ok || !zc.IsLoggedIn() {
+		ce.Reply("Your login isn't connected")
+		return nil
+	}
+	return zc
+}
+
+// getCommandChannel returns the channel of the portal the command was sent in.
+func getCommandChannel(ce *commands.Event) (streamID int, ok bool) {
+	_, streamID, _, err := zid.ParsePortalID(ce.Portal.ID)
+	if err != nil || streamID == 0 {
+		ce.Reply("This command can only be used in channel rooms")
+		return 0, false
+	}
+	return streamID, true
+}
+
+var cmdCreateChannel = &commands.FullHandler{
+	Func: fnCreateChannel,
+	Name: "create-channel",
+	Help: commands.HelpMeta{
+		Section:     HelpSectionZulip,
+		Description: "Create a new Zulip channel. Subscribers can be given as a comma-separated list of emails or user IDs.",
+		Args:        "[--private] [--subscribers=_users_] <_name_> [| _description_]",
+	},
+	RequiresLogin: true,
+}
+
+func fnCreateChannel(ce *commands.Event) {
+	zc := getCommandClient(ce)
+	if zc == nil {
+		return
+	}
+	params := &bridgev2.GroupCreateParams{Type: channelType}
+	var subscribers []string
+	args := ce.Args
+	for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+		flag, value, _ := strings.Cut(args[0], "=")
+		switch flag {
+		case "--private":
+			params.Type = privateChannelType
+		case "--subscribers":
+			subscribers = strings.Split(value, ",")
+		default:
+			ce.Reply("Unknown flag `%s`", flag)
+			return
+		}
+		args = args[1:]
+	}
+	name, description, _ := strings.Cut(strings.Join(args, " "), "|")
+	params.Name = &event.RoomNameEventContent{Name: strings.TrimSpace(name)}
+	params.Topic = &event.TopicEventContent{Topic: strings.TrimSpace(description)}
+	if params.Name.Name == "" {
+		ce.Reply("Usage: `$cmdprefix create-channel [--private] [--subscribers=<users>] <name> [| <description>]`")
+		return
+	}
+	for _, identifier := range subscribers {
+		person, err := zc.resolveUser(ce.Ctx, strings.TrimSpace(identifier))
+		if err != nil {
+			ce.Reply("Failed to find subscriber `%s`: %v", identifier, err)
+			return
+		}
+		if person.UserID != zc.ownUserID {
+			params.Participants = append(params.Participants, zid.MakeUserID(zc.realm, person.UserID))
+		}
+	}
+	resp, err := provisionutil.CreateGroup(ce.Ctx, zc.UserLogin, params)
+	if err != nil {
+		ce.Reply("Failed to create channel: %v", err)
+		return
+	}
+	ce.Reply("Created channel %s: [%s](%s)", params.Name.Name, resp.MXID, resp.MXID.URI().MatrixToURL())
+}
+
+var cmdCreateTopic = &commands.FullHandler{
+	Func: fnCreateTopic,
+	Name: "create-topic",
+	Help: commands.HelpMeta{
+		Section:     HelpSectionZulip,
+		Description: "Create a new topic in the current channel",
+		Args:        "<_name_>",
+	},
+	RequiresLogin:  true,
+	RequiresPortal: true,
+}
+
+func fnCreateTopic(ce *commands.Event) {
+	zc := getCommandClient(ce)
+	if zc == nil {
+		return
+	}
+	streamID, ok := getCommandChannel(ce)
+	if !ok {
+		return
+	}
+	topic := strings.TrimSpace(ce.RawArgs)
+	if topic == "" {
+		ce.Reply("Usage: `$cmdprefix create-topic <name>`")
+		return
+	}
+	portal, err := zc.createTopic(ce.Ctx, streamID, topic)
+	if err != nil {
+		ce.Reply("Failed to create topic: %v", err)
+	} else if portal != nil {
+		ce.Reply("Created topic room [%s](%s)", portal.MXID, portal.MXID.URI().MatrixToURL())
+	} else {
+		ce.Reply("Created topic %s, reply in its thread to send the first message", topic)
+	}
+}
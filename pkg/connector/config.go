@@ -11,6 +11,11 @@ import (
 var ExampleConfig string
 
 type Config struct {
+	// RoomPerTopic bridges each topic as a separate room instead of a thread
+	// in the channel room. The channel is bridged as a space of its topics.
+	RoomPerTopic bool `yaml:"room_per_topic"`
+	// DMSpace groups DMs in a separate space inside the realm space.
+	DMSpace bool `yaml:"dm_space"`
 }
 
 func (zc *ZulipConnector) GetConfig() (example string, data any, upgrader up.Upgrader) {
@@ -36,5 +41,6 @@ func (c *Config) PostProcess() (err error) {
 }
 
 func upgradeConfig(helper up.Helper) {
-
+	helper.Copy(up.Bool, "room_per_topic")
+	helper.Copy(up.Bool, "dm_space")
 }
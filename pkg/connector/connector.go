@@ -15,9 +15,14 @@ var _ bridgev2.NetworkConnector = (*ZulipConnector)(nil)
 
 func (zc *ZulipConnector) Init(bridge *bridgev2.Bridge) {
 	zc.Bridge = bridge
+	zc.registerCommands()
 }
 
 func (zc *ZulipConnector) Start(ctx context.Context) error {
+	if err := zc.migrateRealmIDs(ctx); err != nil {
+		return err
+	}
+	go zc.replaceLegacyGhosts(zc.Bridge.BackgroundCtx)
 	return nil
 }
 
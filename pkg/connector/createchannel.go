@@ -0,0 +1,208 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"go.mau.fi/util/ptr"
+	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/networkid"
+	"maunium.net/go/mautrix/event"
+
+	"go.mau.fi/mautrix-zulip/pkg/zid"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/channels"
+)
+
+// Group types for creating channels from Matrix.
+const (
+	channelType        = "channel"
+	privateChannelType = "private_channel"
+)
+
+// Length limits of names and descriptions enforced by Zulip servers.
+const (
+	maxChannelNameLength        = 60
+	maxChannelDescriptionLength = 1024
+	maxTopicLength              = 60
+)
+
+// topicLinePrefix starts the first line of a message that creates a new topic.
+const topicLinePrefix = "topic:"
+
+// createChannel creates a channel with the own user and the participants as
+// subscribers, and returns the portal of the new channel.
+func (zc *ZulipClient) createChannel(ctx context.Context, params *bridgev2.GroupCreateParams) (*bridgev2.CreateChatResponse, error) {
+	name := strings.TrimSpace(ptr.Val(params.Name).Name)
+	description := ptr.Val(params.Topic).Topic
+	if name == "" {
+		return nil, fmt.Errorf("channel name is required")
+	} else if utf8.RuneCountInString(name) > maxChannelNameLength {
+		return nil, fmt.Errorf("channel name is longer than %d characters", maxChannelNameLength)
+	}
+	subscribers := []int{zc.ownUserID}
+	for _, participant := range params.Participants {
+		userID, err := zc.parseGhostID(participant)
+		if err != nil {
+			return nil, err
+		}
+		subscribers = append(subscribers, userID)
+	}
+	srv := channels.NewService(zc.Client)
+	// Subscribing to an existing channel doesn't fail, so check that the name is free first
+	if _, err := srv.GetChannelID(ctx, name); err == nil {
+		return nil, fmt.Errorf("a channel named %q already exists", name)
+	}
+	_, err := srv.SubscribeToChannel(
+		ctx,
+		[]channels.SubscribeTo{{Name: name, Description: description}},
+		channels.SubscribePrincipals(subscribers),
+		channels.InviteOnly(params.Type == privateChannelType),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create channel: %w", err)
+	}
+	resp, err := srv.GetChannelID(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ID of new channel: %w", err)
+	}
+	info, err := zc.wrapChannelInfo(name, description, subscribers)
+	if err != nil {
+		return nil, err
+	}
+	return &bridgev2.CreateChatResponse{
+		PortalKey:  zc.makeChannelPortalKey(resp.StreamID),
+		PortalInfo: info,
+	}, nil
+}
+
+// createTopic makes a new topic in a channel visible on Matrix. Zulip topics
+// don't exist until the first message is sent to them, so this only creates
+// the topic room or topic root message, which the first message can then be
+// sent to. The topic portal is returned when topics are bridged as rooms.
+func (zc *ZulipClient) createTopic(ctx context.Context, streamID int, topic string) (*bridgev2.Portal, error) {
+	if err := validateTopicName(topic); err != nil {
+		return nil, err
+	}
+	if !zc.Main.Config.RoomPerTopic {
+		res := zc.UserLogin.QueueRemoteEvent(zc.makeTopicUpsert(topic, streamID))
+		if !res.Success {
+			return nil, fmt.Errorf("failed to queue topic root message")
+		}
+		return nil, nil
+	}
+	portal, err := zc.Main.Bridge.GetPortalByKey(ctx, zc.makeTopicPortalKey(streamID, topic))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get topic portal: %w", err)
+	} else if portal.MXID != "" {
+		return portal, nil
+	}
+	_, _, subscribers, err := zc.getChannelInfo(ctx, streamID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel info: %w", err)
+	}
+	info, err := zc.wrapTopicInfo(streamID, topic, subscribers)
+	if err != nil {
+		return nil, err
+	}
+	err = portal.CreateMatrixRoom(ctx, zc.UserLogin, info)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create topic room: %w", err)
+	}
+	return portal, nil
+}
+
+func validateTopicName(topic string) error {
+	if topic == "" {
+		return fmt.Errorf("topic name is required")
+	} else if utf8.RuneCountInString(topic) > maxTopicLength {
+		return fmt.Errorf("topic name is longer than %d characters", maxTopicLength)
+	}
+	return nil
+}
+
+// getNewThreadTopic finds the topic of a message in a channel room that isn't
+// in an existing topic thread. A first line of `topic: <name>` starts a new
+// topic, which is remembered for later messages if the message is in a Matrix
+// thread. Other top-level messages are sent to the default topic of the portal.
+//
+// The root of the Matrix thread is put in the thread of the topic, so that it
+// stays the thread root when messages in the topic come from Zulip.
+func (zc *ZulipClient) getNewThreadTopic(ctx context.Context, msg *bridgev2.MatrixMessage) (networkid.MessageID, string, error) {
+	var threadMeta *zid.MessageMetadata
+	if msg.ThreadRoot != nil {
+		threadMeta = msg.ThreadRoot.Metadata.(*zid.MessageMetadata)
+	}
+	topic, rest, ok := cutTopicLine(msg.Content.Body)
+	if ok {
+		if strings.TrimSpace(rest) == "" {
+			return "", "", fmt.Errorf("message has no content after the topic line")
+		}
+		msg.Content.Body = rest
+		if msg.Content.Format == event.FormatHTML {
+			if formattedRest, ok := cutTopicLineHTML(msg.Content.FormattedBody); ok {
+				msg.Content.FormattedBody = formattedRest
+			} else {
+				msg.Content.Format = ""
+				msg.Content.FormattedBody = ""
+			}
+		}
+	} else if threadMeta != nil && threadMeta.ThreadTopic != "" {
+		topic = threadMeta.ThreadTopic
+	} else if msg.ThreadRoot != nil {
+		return "", "", fmt.Errorf("start the first message of a new thread with `%s <name>` to create a topic", topicLinePrefix)
+	} else if topic = getDefaultTopic(msg.Portal); topic == "" {
+		return "", "", nil
+	}
+	if err := validateTopicName(topic); err != nil {
+		return "", "", err
+	}
+	topicID := zid.MakeTopicMessageID(zc.realm, topic)
+	if threadMeta != nil && (threadMeta.ThreadTopic != topic || msg.ThreadRoot.ThreadRoot != topicID) {
+		threadMeta.ThreadTopic = topic
+		msg.ThreadRoot.ThreadRoot = topicID
+		if err := zc.Main.Bridge.DB.Message.Update(ctx, msg.ThreadRoot); err != nil {
+			return "", "", fmt.Errorf("failed to save thread topic: %w", err)
+		}
+	}
+	return topicID, topic, nil
+}
+
+// cutTopicLine splits a message whose first line is `topic: <name>` into the
+// topic name and the rest of the message.
+func cutTopicLine(body string) (topic, rest string, ok bool) {
+	firstLine, rest, _ := strings.Cut(body, "\n")
+	firstLine = strings.TrimSpace(firstLine)
+	if len(firstLine) < len(topicLinePrefix) || !strings.EqualFold(firstLine[:len(topicLinePrefix)], topicLinePrefix) {
+		return "", body, false
+	}
+	topic = strings.TrimSpace(firstLine[len(topicLinePrefix):])
+	if topic == "" {
+		return "", body, false
+	}
+	return topic, strings.TrimLeft(rest, "\r\n"), true
+}
+
+var htmlLineBreakRegex = regexp.MustCompile(`(?i)<br\s*/?>|</p>|\n`)
+
+// cutTopicLineHTML removes the `topic: <name>` line from the start of a
+// formatted body. ok is false if the first line has other formatting, as the
+// topic line can't be cut out without breaking the HTML.
+func cutTopicLineHTML(formatted string) (rest string, ok bool) {
+	formatted = strings.TrimLeft(formatted, " \r\n")
+	line, inParagraph := strings.CutPrefix(formatted, "<p>")
+	lineEnd := htmlLineBreakRegex.FindStringIndex(line)
+	if lineEnd == nil || strings.ContainsRune(line[:lineEnd[0]], '<') {
+		return "", false
+	} else if _, _, ok = cutTopicLine(html.UnescapeString(line[:lineEnd[0]])); !ok {
+		return "", false
+	}
+	rest = strings.TrimLeft(line[lineEnd[1]:], " \r\n")
+	if inParagraph && !strings.EqualFold(line[lineEnd[0]:lineEnd[1]], "</p>") {
+		rest = "<p>" + rest
+	}
+	return rest, true
+}
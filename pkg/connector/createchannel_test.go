@@ -0,0 +1,47 @@
+package connector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCutTopicLine(t *testing.T) {
+	topic, rest, ok := cutTopicLine("Topic: Deploy v2 \n\nhello\nworld")
+	assert.True(t, ok)
+	assert.Equal(t, "Deploy v2", topic)
+	assert.Equal(t, "hello\nworld", rest)
+
+	_, rest, ok = cutTopicLine("topic:\nhello")
+	assert.False(t, ok)
+	assert.Equal(t, "topic:\nhello", rest)
+
+	_, _, ok = cutTopicLine("the topic: is this")
+	assert.False(t, ok)
+}
+
+func TestCutTopicLineHTML(t *testing.T) {
+	tests := []struct {
+		name      string
+		formatted string
+		rest      string
+		ok        bool
+	}{
+		{"line break", "topic: Deploy<br>hello <strong>world</strong>", "hello <strong>world</strong>", true},
+		{"self-closing line break", "topic: Deploy<br />\nhello", "hello", true},
+		{"newline", "topic: Deploy\n<em>hello</em>", "<em>hello</em>", true},
+		{"own paragraph", "<p>topic: Deploy</p>\n<p>hello</p>", "<p>hello</p>", true},
+		{"line in paragraph", "<p>topic: Deploy<br>hello</p>", "<p>hello</p>", true},
+		{"escaped topic", "topic: R&amp;D<br>hello", "hello", true},
+		{"formatted topic line", "topic: <code>Deploy</code><br>hello", "", false},
+		{"no topic line", "hello<br>topic: Deploy", "", false},
+		{"single line", "topic: Deploy", "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rest, ok := cutTopicLineHTML(test.formatted)
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.rest, rest)
+		})
+	}
+}
@@ -8,9 +8,13 @@ import (
 
 func (zc *ZulipConnector) GetDBMetaTypes() database.MetaTypes {
 	return database.MetaTypes{
-		Portal:   nil,
-		Ghost:    nil,
-		Message:  nil,
+		Portal: func() any {
+			return &zid.PortalMetadata{}
+		},
+		Ghost: nil,
+		Message: func() any {
+			return &zid.MessageMetadata{}
+		},
 		Reaction: nil,
 		UserLogin: func() any {
 			return &zid.UserLoginMetadata{}
@@ -0,0 +1,161 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/commands"
+	"maunium.net/go/mautrix/event"
+
+	"go.mau.fi/mautrix-zulip/pkg/zid"
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+)
+
+// getDefaultTopic returns the topic that messages sent outside threads in a
+// channel room go to. An empty topic is the "general chat" topic.
+func getDefaultTopic(portal *bridgev2.Portal) string {
+	meta, _ := portal.Metadata.(*zid.PortalMetadata)
+	if meta == nil {
+		return ""
+	} else if meta.StickyTopic && meta.LastTopic != "" {
+		return meta.LastTopic
+	}
+	return meta.DefaultTopic
+}
+
+// rememberLastTopic saves the topic a message was sent to from Matrix, which
+// is the default topic in sticky mode.
+func rememberLastTopic(ctx context.Context, portal *bridgev2.Portal, topic string) {
+	meta, ok := portal.Metadata.(*zid.PortalMetadata)
+	if !ok {
+		meta = &zid.PortalMetadata{}
+		portal.Metadata = meta
+	}
+	if topic == "" || meta.LastTopic == topic {
+		return
+	}
+	meta.LastTopic = topic
+	if err := portal.Save(ctx); err != nil {
+		zerolog.Ctx(ctx).Err(err).Msg("Failed to save last used topic")
+	}
+}
+
+// emptyTopicError explains how to pick a topic when the server doesn't accept
+// a message without one.
+func (zc *ZulipClient) emptyTopicError(reason string) error {
+	return bridgev2.WrapErrorInStatus(fmt.Errorf(
+		"%s. Reply in a topic thread, start the message with `%s <name>` or set a default topic with `%s default-topic <name>`",
+		reason, topicLinePrefix, zc.Main.Bridge.Config.CommandPrefix,
+	)).WithIsCertain(true).WithErrorAsMessage().WithSendNotice(true).WithErrorReason(event.MessageStatusUnsupported)
+}
+
+// checkEmptyTopic returns an error if the server is too old to accept
+// messages without a topic.
+func (zc *ZulipClient) checkEmptyTopic() error {
+	if zc.Client.SupportsFeature(zulip.FeatureLevelEmptyTopicName) {
+		return nil
+	}
+	return zc.emptyTopicError("This Zulip server doesn't support messages without a topic")
+}
+
+// emptyTopicErrorMessages are the errors that Zulip servers return when a
+// message can't be sent without a topic. The server only has a generic error
+// code for them and translates the messages, so errors in other languages are
+// returned as-is.
+var emptyTopicErrorMessages = []string{
+	// The organization requires topics (Zulip 10+)
+	"Topics are required in this organization",
+	// The channel doesn't allow the general chat topic (Zulip 11+)
+	"Sending messages to the general chat is not allowed in this channel",
+	// Servers before empty topics were supported
+	"Topic can't be empty",
+}
+
+// wrapEmptyTopicError explains errors from the server when sending a message
+// without a topic, e.g. if the organization requires topics. Other errors are
+// returned unchanged.
+func (zc *ZulipClient) wrapEmptyTopicError(err error) error {
+	var respErr zulip.ErrorResp
+	if !errors.As(err, &respErr) || respErr.Inner.Code() != zulip.ErrBadRequest {
+		return err
+	}
+	msg := respErr.Inner.Msg()
+	isEmptyTopicError := slices.ContainsFunc(emptyTopicErrorMessages, func(prefix string) bool {
+		return strings.HasPrefix(msg, prefix)
+	})
+	if !isEmptyTopicError {
+		return err
+	}
+	return zc.emptyTopicError(fmt.Sprintf("Zulip rejected the message without a topic: %s", strings.TrimRight(msg, ".!")))
+}
+
+var cmdDefaultTopic = &commands.FullHandler{
+	Func: fnDefaultTopic,
+	Name: "default-topic",
+	Help: commands.HelpMeta{
+		Section:     HelpSectionZulip,
+		Description: "View or change the topic that messages sent outside threads in this channel go to. `--last-used` uses the topic you last sent a message to and `--none` uses the general chat topic.",
+		Args:        "[_name_ | --last-used | --none]",
+	},
+	RequiresLogin:      true,
+	RequiresPortal:     true,
+	RequiresEventLevel: event.StateTopic,
+}
+
+func fnDefaultTopic(ce *commands.Event) {
+	if _, _, _, isTopicPortal := zid.ParseTopicPortalID(ce.Portal.ID); isTopicPortal {
+		ce.Reply("All messages in topic rooms go to the topic of the room")
+		return
+	} else if _, ok := getCommandChannel(ce); !ok {
+		return
+	}
+	meta, ok := ce.Portal.Metadata.(*zid.PortalMetadata)
+	if !ok {
+		meta = &zid.PortalMetadata{}
+		ce.Portal.Metadata = meta
+	}
+	arg := strings.TrimSpace(ce.RawArgs)
+	switch arg {
+	case "":
+		if meta.StickyTopic {
+			ce.Reply("Messages outside threads go to the topic you last sent a message to (currently `%s`)", formatTopicName(meta.LastTopic))
+		} else {
+			ce.Reply("Messages outside threads go to `%s`", formatTopicName(meta.DefaultTopic))
+		}
+		return
+	case "--last-used":
+		meta.StickyTopic = true
+	case "--none":
+		meta.StickyTopic = false
+		meta.DefaultTopic = ""
+	default:
+		if err := validateTopicName(arg); err != nil {
+			ce.Reply("Invalid topic: %v", err)
+			return
+		}
+		meta.StickyTopic = false
+		meta.DefaultTopic = arg
+	}
+	if err := ce.Portal.Save(ce.Ctx); err != nil {
+		ce.Log.Err(err).Msg("Failed to save portal")
+		ce.Reply("Failed to save default topic: %v", err)
+		return
+	}
+	if meta.StickyTopic {
+		ce.Reply("Messages outside threads will now go to the topic you last sent a message to")
+	} else {
+		ce.Reply("Messages outside threads will now go to `%s`", formatTopicName(meta.DefaultTopic))
+	}
+}
+
+func formatTopicName(topic string) string {
+	if topic == "" {
+		return emptyTopicName
+	}
+	return topic
+}
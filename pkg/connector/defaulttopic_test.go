@@ -0,0 +1,53 @@
+package connector
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/bridgeconfig"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+)
+
+func makeErrorResp(t *testing.T, code, msg string) error {
+	var resp zulip.APIResponseBase
+	data, err := json.Marshal(map[string]string{"result": zulip.ResultError, "code": code, "msg": msg})
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &resp))
+	return fmt.Errorf("failed to send message: %w", zulip.ErrorResp{Inner: &resp})
+}
+
+func TestWrapEmptyTopicError(t *testing.T) {
+	zc := &ZulipClient{Main: &ZulipConnector{Bridge: &bridgev2.Bridge{
+		Config: &bridgeconfig.BridgeConfig{CommandPrefix: "!zulip"},
+	}}}
+	tests := []struct {
+		name    string
+		err     error
+		wrapped bool
+	}{
+		{"mandatory topics", makeErrorResp(t, zulip.ErrBadRequest, "Topics are required in this organization."), true},
+		{"general chat disabled", makeErrorResp(t, zulip.ErrBadRequest, "Sending messages to the general chat is not allowed in this channel."), true},
+		{"old server", makeErrorResp(t, zulip.ErrBadRequest, "Topic can't be empty!"), true},
+		{"other bad request", makeErrorResp(t, zulip.ErrBadRequest, "Not authorized to send to channel 'secret'"), false},
+		{"other code", makeErrorResp(t, zulip.ErrRateLimitHit, "Topics are required in this organization."), false},
+		{"not an API error", errors.New("connection reset"), false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			wrapped := zc.wrapEmptyTopicError(test.err)
+			if test.wrapped {
+				assert.NotErrorIs(t, wrapped, test.err)
+				assert.Contains(t, wrapped.Error(), "Zulip rejected the message without a topic")
+				assert.Contains(t, wrapped.Error(), "`!zulip default-topic <name>`")
+			} else {
+				assert.Equal(t, test.err, wrapped)
+			}
+		})
+	}
+}
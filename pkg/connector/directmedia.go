@@ -0,0 +1,51 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"maunium.net/go/mautrix"
+	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/networkid"
+	"maunium.net/go/mautrix/mediaproxy"
+
+	"go.mau.fi/mautrix-zulip/pkg/msgconv"
+	"go.mau.fi/mautrix-zulip/pkg/zid"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/messages"
+)
+
+var _ bridgev2.DirectMediableNetwork = (*ZulipConnector)(nil)
+
+// temporaryURLLifetime is how long the temporary upload URLs are treated as
+// valid. Zulip accepts them for 60 seconds, a bit of margin is left for redirects.
+const temporaryURLLifetime = 50 * time.Second
+
+func (zc *ZulipConnector) SetUseDirectMedia() {
+	msgconv.UseDirectMedia = true
+}
+
+// Download resolves a direct media URI into a temporary URL of the Zulip
+// upload, which can be fetched without credentials.
+func (zc *ZulipConnector) Download(ctx context.Context, mediaID networkid.MediaID, params map[string]string) (mediaproxy.GetMediaResponse, error) {
+	loginID, uploadPath, err := zid.ParseUploadMediaID(mediaID)
+	if err != nil {
+		return nil, mautrix.MNotFound.WithMessage("Invalid media ID")
+	}
+	login, err := zc.Bridge.GetExistingUserLoginByID(ctx, loginID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user login: %w", err)
+	} else if login == nil || !login.Client.IsLoggedIn() {
+		return nil, mautrix.MNotFound.WithMessage("The login that bridged this file is no longer available")
+	}
+	client := login.Client.(*ZulipClient)
+	resp, err := messages.NewService(client.Client).GetTemporaryFileURL(ctx, uploadPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get temporary file URL: %w", err)
+	}
+	meta := login.Metadata.(*zid.UserLoginMetadata)
+	return &mediaproxy.GetMediaResponseURL{
+		URL:       meta.URL + resp.URL,
+		ExpiresAt: time.Now().Add(temporaryURLLifetime),
+	}, nil
+}
@@ -2,19 +2,28 @@ package connector
 
 import (
 	"context"
+	"crypto/sha256"
+	"errors"
 	"fmt"
+	"os"
 
+	"github.com/rs/zerolog"
 	"maunium.net/go/mautrix/bridgev2"
 	"maunium.net/go/mautrix/bridgev2/database"
 	"maunium.net/go/mautrix/bridgev2/networkid"
+	"maunium.net/go/mautrix/event"
 
+	"go.mau.fi/mautrix-zulip/pkg/msgconv"
 	"go.mau.fi/mautrix-zulip/pkg/zid"
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
 	"go.mau.fi/mautrix-zulip/pkg/zulip/messages"
 	"go.mau.fi/mautrix-zulip/pkg/zulip/messages/recipient"
 )
 
+var _ bridgev2.EditHandlingNetworkAPI = (*ZulipClient)(nil)
+
 func (zc *ZulipClient) HandleMatrixMessage(ctx context.Context, msg *bridgev2.MatrixMessage) (message *bridgev2.MatrixMessageResponse, err error) {
-	channelID, userIDs, err := zid.ParsePortalID(msg.Portal.ID)
+	_, channelID, userIDs, err := zid.ParsePortalID(msg.Portal.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -22,30 +31,152 @@ func (zc *ZulipClient) HandleMatrixMessage(ctx context.Context, msg *bridgev2.Ma
 	var resp *messages.SendMessageResponse
 	var threadRootID networkid.MessageID
 	var topicID string
-	if msg.ThreadRoot != nil {
+	_, topic, isTopicPortal := getPortalTopic(msg.Portal)
+	if isTopicPortal {
+		topicID = topic
+	} else if msg.ThreadRoot != nil {
 		threadRootID = msg.ThreadRoot.ID
 		if msg.ThreadRoot.ThreadRoot != "" {
 			threadRootID = msg.ThreadRoot.ThreadRoot
 		}
-		topicID, _ = zid.ParseMessageID(threadRootID)
-		if topicID == "" {
-			return nil, fmt.Errorf("invalid thread root")
+		_, topicID, _ = zid.ParseMessageID(threadRootID)
+	}
+	msg.Content.RemoveReplyFallback()
+	if channelID != 0 && topicID == "" && !isTopicPortal {
+		threadRootID, topicID, err = zc.getNewThreadTopic(ctx, msg)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if channelID != 0 && topicID == "" {
+		if err = zc.checkEmptyTopic(); err != nil {
+			return nil, err
+		}
+	}
+	content := msg.Content.Body
+	switch msg.Content.MsgType {
+	case event.MsgEmote:
+		content = "/me " + content
+	case event.MsgImage, event.MsgVideo, event.MsgAudio, event.MsgFile:
+		content, err = zc.uploadMatrixMedia(ctx, msg.Content)
+		if err != nil {
+			return nil, err
 		}
 	}
+	if msg.ReplyTo != nil {
+		content = zc.addQuoteReply(ctx, msg.ReplyTo, content)
+	}
 	if channelID != 0 {
-		resp, err = srv.SendMessageToChannelTopic(ctx, recipient.ToChannel(channelID), topicID, msg.Content.Body)
+		resp, err = srv.SendMessageToChannelTopic(ctx, recipient.ToChannel(channelID), topicID, content)
+		if err != nil && topicID == "" {
+			err = zc.wrapEmptyTopicError(err)
+		} else if err == nil && !isTopicPortal {
+			rememberLastTopic(ctx, msg.Portal, topicID)
+		}
 	} else {
-		resp, err = srv.SendMessageToUsers(ctx, recipient.ToUsers(userIDs), msg.Content.Body)
+		resp, err = srv.SendMessageToUsers(ctx, recipient.ToUsers(userIDs), content)
 	}
 	if err != nil {
 		return nil, err
 	}
 	return &bridgev2.MatrixMessageResponse{
 		DB: &database.Message{
-			ID:         zid.MakeMessageID(resp.ID),
-			SenderID:   zid.MakeUserID(zc.ownUserID),
+			ID:         zid.MakeMessageID(zc.realm, resp.ID),
+			SenderID:   zid.MakeUserID(zc.realm, zc.ownUserID),
 			ThreadRoot: threadRootID,
 		},
 		StreamOrder: int64(resp.ID),
 	}, nil
 }
+
+func (zc *ZulipClient) HandleMatrixEdit(ctx context.Context, msg *bridgev2.MatrixEdit) error {
+	_, _, messageID := zid.ParseMessageID(msg.EditTarget.ID)
+	if messageID == 0 {
+		return fmt.Errorf("topic root messages can't be edited")
+	}
+	content := msg.Content.Body
+	switch msg.Content.MsgType {
+	case event.MsgText, event.MsgNotice:
+	case event.MsgEmote:
+		content = "/me " + content
+	default:
+		return bridgev2.ErrUnsupportedMessageType
+	}
+	// Edits replace the whole content, so the quote of a reply must be added again
+	if replyTo := zc.getEditReplyTarget(ctx, msg); replyTo != nil {
+		content = zc.addQuoteReply(ctx, replyTo, content)
+	}
+	_, err := messages.NewService(zc.Client).EditMessage(ctx, messageID, messages.NewContent(content))
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256([]byte(content))
+	msg.EditTarget.Metadata = &zid.MessageMetadata{ContentHash: hash[:]}
+	return nil
+}
+
+// getEditReplyTarget returns the message that the edited message was a reply to
+// when it was sent from Matrix, or nil if it wasn't a reply.
+func (zc *ZulipClient) getEditReplyTarget(ctx context.Context, msg *bridgev2.MatrixEdit) *database.Message {
+	if msg.EditTarget.ReplyTo.MessageID == "" {
+		return nil
+	}
+	replyTo, err := zc.Main.Bridge.DB.Message.GetFirstOrSpecificPartByID(ctx, msg.Portal.Receiver, msg.EditTarget.ReplyTo)
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).
+			Str("reply_to_id", string(msg.EditTarget.ReplyTo.MessageID)).
+			Msg("Failed to get reply target of edited message")
+		return nil
+	}
+	return replyTo
+}
+
+// addQuoteReply turns a Matrix reply into a Zulip quote-and-reply. If the
+// replied-to message can't be fetched, the reply is sent as a normal message.
+func (zc *ZulipClient) addQuoteReply(ctx context.Context, replyTo *database.Message, content string) string {
+	_, _, messageID := zid.ParseMessageID(replyTo.ID)
+	if messageID == 0 {
+		// Topic root messages can't be quoted
+		return content
+	}
+	resp, err := messages.NewService(zc.Client).FetchSingleMessage(ctx, messageID, messages.ApplyMarkdownSingleMessage(false))
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Int("message_id", messageID).Msg("Failed to fetch replied-to message")
+		return content
+	}
+	rawContent := resp.RawContent
+	if rawContent == "" {
+		rawContent = resp.Message.Content
+	}
+	meta := zc.UserLogin.Metadata.(*zid.UserLoginMetadata)
+	return msgconv.QuoteReply(meta.URL, &resp.Message, rawContent, content)
+}
+
+func (zc *ZulipClient) uploadMatrixMedia(ctx context.Context, content *event.MessageEventContent) (string, error) {
+	meta := zc.UserLogin.Metadata.(*zid.UserLoginMetadata)
+	if content.Info != nil && meta.MaxFileUploadSizeMiB > 0 && content.Info.Size > meta.MaxFileUploadSizeMiB*1024*1024 {
+		return "", bridgev2.ErrMediaTooLarge
+	}
+	uri := content.URL
+	if content.File != nil {
+		uri = content.File.URL
+	}
+	fileName := content.GetFileName()
+	var resp *messages.UploadFileResponse
+	err := zc.Main.Bridge.Bot.DownloadMediaToFile(ctx, uri, content.File, false, func(file *os.File) (err error) {
+		resp, err = messages.NewService(zc.Client).UploadFileFromReader(
+			ctx, fileName, file, messages.MaxFileSizeMiB(meta.MaxFileUploadSizeMiB),
+		)
+		return
+	})
+	if errors.Is(err, zulip.ErrFileTooLarge) {
+		return "", bridgev2.ErrMediaTooLarge
+	} else if err != nil {
+		return "", bridgev2.WrapErrorInStatus(err).WithErrorAsMessage().WithIsCertain(true).WithSendNotice(true)
+	}
+	link := fmt.Sprintf("[%s](%s)", fileName, resp.URL)
+	if caption := content.GetCaption(); caption != "" {
+		return caption + "\n" + link, nil
+	}
+	return link, nil
+}
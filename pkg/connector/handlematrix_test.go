@@ -0,0 +1,80 @@
+//go:build cgo
+
+package connector
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/database"
+	"maunium.net/go/mautrix/bridgev2/networkid"
+	"maunium.net/go/mautrix/event"
+
+	"go.mau.fi/mautrix-zulip/pkg/zid"
+)
+
+func TestHandleMatrixEditKeepsQuoteReply(t *testing.T) {
+	var editedContent string
+	zc := newTestClient(t, Config{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v1/messages/123":
+			_, _ = w.Write([]byte(`{"result": "success", "msg": "", "raw_content": "original", "message": {
+				"id": 123, "sender_id": 20, "sender_full_name": "Bob", "type": "stream",
+				"stream_id": 5, "display_recipient": "general", "subject": "Deploy"
+			}}`))
+		case "PATCH /api/v1/messages/124":
+			require.NoError(t, r.ParseForm())
+			editedContent = r.Form.Get("content")
+			_, _ = w.Write([]byte(`{"result": "success", "msg": ""}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	ctx := context.Background()
+	db := newTestDB(t)
+	zc.Main.Bridge = &bridgev2.Bridge{DB: db}
+	portal := &bridgev2.Portal{Portal: &database.Portal{PortalKey: networkid.PortalKey{ID: zid.MakeChannelPortalID(zc.realm, 5)}}}
+	_, err := db.Exec(ctx, `
+		INSERT INTO portal (
+			bridge_id, id, receiver, name, topic, avatar_id, avatar_hash, avatar_mxc,
+			name_set, avatar_set, topic_set, in_space, room_type, metadata
+		) VALUES ('zulip', $1, '', '', '', '', '', '', false, false, false, false, '', '{}')
+	`, portal.ID)
+	require.NoError(t, err)
+	_, err = db.Exec(ctx, `
+		INSERT INTO ghost (
+			bridge_id, id, name, avatar_id, avatar_hash, avatar_mxc, name_set, avatar_set, contact_info_set, is_bot, identifiers, metadata
+		) VALUES ('zulip', $1, '', '', '', '', false, false, false, false, '[]', '{}')
+	`, zid.MakeUserID(zc.realm, 20))
+	require.NoError(t, err)
+	require.NoError(t, db.Message.Insert(ctx, &database.Message{
+		ID:       zid.MakeMessageID(zc.realm, 123),
+		MXID:     "$original",
+		Room:     portal.PortalKey,
+		SenderID: zid.MakeUserID(zc.realm, 20),
+		Metadata: &zid.MessageMetadata{},
+	}))
+
+	edit := func(replyTo networkid.MessageID) {
+		require.NoError(t, zc.HandleMatrixEdit(ctx, &bridgev2.MatrixEdit{
+			MatrixEventBase: bridgev2.MatrixEventBase[*event.MessageEventContent]{
+				Content: &event.MessageEventContent{MsgType: event.MsgText, Body: "fixed reply"},
+				Portal:  portal,
+			},
+			EditTarget: &database.Message{
+				ID:      zid.MakeMessageID(zc.realm, 124),
+				ReplyTo: networkid.MessageOptionalPartID{MessageID: replyTo},
+			},
+		}))
+	}
+	edit(zid.MakeMessageID(zc.realm, 123))
+	assert.Regexp(t, `^@_\*\*Bob\|20\*\* \[said\]\(.+/near/123\):\n`+"```quote\noriginal\n```\nfixed reply$", editedContent)
+
+	edit("")
+	assert.Equal(t, "fixed reply", editedContent)
+}
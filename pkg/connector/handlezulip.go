@@ -1,12 +1,16 @@
 package connector
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"time"
 
 	"github.com/rs/zerolog"
+	"go.mau.fi/util/ptr"
 	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/database"
 	"maunium.net/go/mautrix/bridgev2/networkid"
 	"maunium.net/go/mautrix/bridgev2/simplevent"
 	"maunium.net/go/mautrix/event"
@@ -20,43 +24,36 @@ import (
 func (zc *ZulipClient) handleZulipEvent(ctx context.Context, rawEvt events.Event) bool {
 	log := zerolog.Ctx(ctx)
 	log.Trace().Any("data", rawEvt).Msg("Event data")
+	if err := zc.Realm.HandleEvent(rawEvt); err != nil {
+		log.Warn().Err(err).Int("event_id", rawEvt.EventID()).Msg("Failed to update realm state")
+	}
 	switch evt := rawEvt.(type) {
-	case *events.Heartbeat:
+	case *events.Heartbeat, *events.ScheduledMessages:
+		// Scheduled messages are only kept in the realm state for commands
 		return true
 	case *events.UserTopic:
-		if evt.TopicName == "" {
+		if zc.Main.Config.RoomPerTopic {
+			return zc.handleUserTopic(evt)
+		} else if evt.TopicName == "" {
 			return true
 		}
 		return zc.UserLogin.QueueRemoteEvent(zc.makeTopicUpsert(evt.TopicName, evt.StreamID)).Success
+	case *events.Subscription:
+		return zc.handleSubscriptionUpdate(ctx, evt)
+	case *events.AlertWords:
+		zc.syncAlertWords(ctx, evt.AlertWords)
+		return true
 	case *events.Message:
-		if evt.Message.StreamID != 0 && evt.Message.Subject != "" {
+		if evt.Message.StreamID != 0 && evt.Message.Subject != "" && !zc.Main.Config.RoomPerTopic {
 			if !zc.UserLogin.QueueRemoteEvent(zc.makeTopicUpsert(evt.Message.Subject, evt.Message.StreamID)).Success {
 				return false
 			}
 		}
-		return zc.UserLogin.QueueRemoteEvent(&simplevent.Message[*events.MessageData]{
-			EventMeta: simplevent.EventMeta{
-				Type: bridgev2.RemoteEventMessage,
-				LogContext: func(c zerolog.Context) zerolog.Context {
-					return c.
-						Int("evt_id", evt.ID).
-						Int("msg_id", evt.Message.ID).
-						Int("stream_id", evt.Message.StreamID).
-						Int("recipient_id", evt.Message.RecipientID)
-				},
-				PortalKey:    zc.makePortalKey(evt.Message),
-				Sender:       zc.makeEventSender(evt.Message.SenderID),
-				CreatePortal: true,
-				Timestamp:    time.Unix(int64(evt.Message.Timestamp), 0),
-				StreamOrder:  int64(evt.Message.ID),
-			},
-			Data:          &evt.Message,
-			ID:            zid.MakeMessageID(evt.Message.ID),
-			TransactionID: networkid.TransactionID(evt.LocalID),
-			ConvertMessageFunc: func(ctx context.Context, portal *bridgev2.Portal, intent bridgev2.MatrixAPI, data *events.MessageData) (*bridgev2.ConvertedMessage, error) {
-				return msgconv.ToMatrix(ctx, portal, intent, zc.UserLogin, data)
-			},
-		}).Success
+		return zc.UserLogin.QueueRemoteEvent(zc.wrapMessage(evt.ID, &evt.Message, networkid.TransactionID(evt.LocalID))).Success
+	case *events.UpdateMessage:
+		return zc.handleUpdateMessage(ctx, evt)
+	case *events.UpdateMessageFlags:
+		return zc.handleUpdateMessageFlags(ctx, evt)
 	//case *events.DeleteMessage:
 	//	var portalKey networkid.PortalKey
 	//	if evt.StreamID != nil {
@@ -74,13 +71,17 @@ func (zc *ZulipClient) handleZulipEvent(ctx context.Context, rawEvt events.Event
 	//		OnlyForMe:     false,
 	//	})
 	case *events.Reaction:
-		part, err := zc.Main.Bridge.DB.Message.GetPartByID(ctx, zc.UserLogin.ID, zid.MakeMessageID(evt.MessageID), "")
+		part, err := zc.Main.Bridge.DB.Message.GetPartByID(ctx, zc.UserLogin.ID, zid.MakeMessageID(zc.realm, evt.MessageID), "")
 		if err != nil {
 			log.Err(err).Msg("Failed to get portal for reaction")
 			return false
 		} else if part == nil {
 			log.Warn().Int("target_message_id", evt.MessageID).Msg("Reaction target message not found")
 			return true
+		} else if evt.Op == "add" && evt.UserID == zc.ownUserID && networkid.EmojiID(evt.EmojiCode) == zulipStarEmojiID &&
+			zc.hasOwnReaction(ctx, part, starEmojiID) {
+			log.Debug().Int("target_message_id", evt.MessageID).Msg("Ignoring star reaction on starred message")
+			return true
 		} else {
 			return zc.UserLogin.QueueRemoteEvent(&ReactionEvent{zc: zc, Reaction: evt, portal: part.Room}).Success
 		}
@@ -90,6 +91,116 @@ func (zc *ZulipClient) handleZulipEvent(ctx context.Context, rawEvt events.Event
 	}
 }
 
+func (zc *ZulipClient) wrapMessage(evtID int, data *events.MessageData, txnID networkid.TransactionID) bridgev2.RemoteMessage {
+	msg := &simplevent.Message[*events.MessageData]{
+		EventMeta: simplevent.EventMeta{
+			Type: bridgev2.RemoteEventMessage,
+			LogContext: func(c zerolog.Context) zerolog.Context {
+				return c.
+					Int("evt_id", evtID).
+					Int("msg_id", data.ID).
+					Int("stream_id", data.StreamID).
+					Int("recipient_id", data.RecipientID)
+			},
+			PortalKey:    zc.makePortalKey(*data),
+			Sender:       zc.makeEventSender(data.SenderID),
+			CreatePortal: true,
+			Timestamp:    time.Unix(int64(data.Timestamp), 0),
+			StreamOrder:  int64(data.ID),
+		},
+		Data:          data,
+		ID:            zid.MakeMessageID(zc.realm, data.ID),
+		TransactionID: txnID,
+		ConvertMessageFunc: func(ctx context.Context, portal *bridgev2.Portal, intent bridgev2.MatrixAPI, data *events.MessageData) (*bridgev2.ConvertedMessage, error) {
+			return msgconv.ToMatrix(ctx, portal, intent, zc.UserLogin, data)
+		},
+	}
+	if data.StreamID != 0 && zc.Main.Config.RoomPerTopic {
+		return &topicMessage{Message: msg, zc: zc}
+	}
+	return msg
+}
+
+// topicMessage is a message in a topic room. It provides the info for
+// creating the room, as the display name of the topic can't be known from the
+// portal ID alone.
+type topicMessage struct {
+	*simplevent.Message[*events.MessageData]
+	zc *ZulipClient
+}
+
+var _ bridgev2.RemoteChatResyncWithInfo = (*topicMessage)(nil)
+
+func (tm *topicMessage) GetChatInfo(ctx context.Context, portal *bridgev2.Portal) (*bridgev2.ChatInfo, error) {
+	return tm.zc.getTopicChatInfo(ctx, tm.Data.StreamID, tm.Data.Subject)
+}
+
+func (zc *ZulipClient) handleUpdateMessage(ctx context.Context, evt *events.UpdateMessage) bool {
+	log := zerolog.Ctx(ctx)
+	if zc.Main.Config.RoomPerTopic && (evt.Subject != nil || evt.NewStreamID != nil) {
+		ok, rebridged := zc.handleTopicMove(ctx, evt)
+		if !ok || rebridged {
+			// Re-bridged messages are fetched after the move, so they already have the new content
+			return ok
+		}
+	}
+	if evt.RenderedContent == nil {
+		// TODO handle topic and channel moves when topics are bridged as threads
+		return true
+	}
+	part, err := zc.Main.Bridge.DB.Message.GetFirstPartByID(ctx, zc.UserLogin.ID, zid.MakeMessageID(zc.realm, evt.MessageID))
+	if err != nil {
+		log.Err(err).Msg("Failed to get edit target message")
+		return false
+	} else if part == nil {
+		log.Warn().Int("target_message_id", evt.MessageID).Msg("Edit target message not found")
+		return true
+	}
+	_, topic, _ := zid.ParseMessageID(part.ThreadRoot)
+	if _, _, portalTopic, ok := zid.ParseTopicPortalID(part.Room.ID); ok {
+		topic = portalTopic
+	}
+	return zc.UserLogin.QueueRemoteEvent(&simplevent.Message[*events.MessageData]{
+		EventMeta: simplevent.EventMeta{
+			Type: bridgev2.RemoteEventEdit,
+			LogContext: func(c zerolog.Context) zerolog.Context {
+				return c.
+					Int("evt_id", evt.ID).
+					Int("msg_id", evt.MessageID).
+					Bool("rendering_only", evt.RenderingOnly)
+			},
+			PortalKey: part.Room,
+			Sender:    zc.makeGhostEventSender(part.SenderID),
+			Timestamp: time.Unix(int64(evt.EditTimestamp), 0),
+		},
+		Data: &events.MessageData{
+			ID:          evt.MessageID,
+			Content:     *evt.RenderedContent,
+			IsMeMessage: ptr.Val(evt.IsMeMessage),
+			StreamID:    ptr.Val(evt.StreamID),
+			Subject:     topic,
+			Flags:       evt.Flags,
+		},
+		TargetMessage: zid.MakeMessageID(zc.realm, evt.MessageID),
+		ConvertEditFunc: func(ctx context.Context, portal *bridgev2.Portal, intent bridgev2.MatrixAPI, existing []*database.Message, data *events.MessageData) (*bridgev2.ConvertedEdit, error) {
+			if !evt.RenderingOnly && evt.Content != nil && isEditEcho(existing[0], *evt.Content) {
+				return nil, bridgev2.ErrIgnoringRemoteEvent
+			}
+			return msgconv.ToMatrixEdit(ctx, portal, intent, zc.UserLogin, existing, data)
+		},
+	}).Success
+}
+
+// isEditEcho checks if the edit is the echo of an edit sent from Matrix.
+func isEditEcho(target *database.Message, rawContent string) bool {
+	meta, ok := target.Metadata.(*zid.MessageMetadata)
+	if !ok || meta.ContentHash == nil {
+		return false
+	}
+	hash := sha256.Sum256([]byte(rawContent))
+	return bytes.Equal(meta.ContentHash, hash[:])
+}
+
 type ReactionEvent struct {
 	zc     *ZulipClient
 	portal networkid.PortalKey
@@ -121,7 +232,7 @@ func (r *ReactionEvent) GetSender() bridgev2.EventSender {
 }
 
 func (r *ReactionEvent) GetTargetMessage() networkid.MessageID {
-	return zid.MakeMessageID(r.MessageID)
+	return zid.MakeMessageID(r.zc.realm, r.MessageID)
 }
 
 func (r *ReactionEvent) GetReactionEmoji() (emoji string, emojiID networkid.EmojiID) {
@@ -146,7 +257,7 @@ func (r *ReactionEvent) GetRemovedEmojiID() networkid.EmojiID {
 }
 
 func (zc *ZulipClient) makeTopicUpsert(name string, streamID int) bridgev2.RemoteMessage {
-	return &simplevent.PreConvertedMessage{
+	return &simplevent.Message[string]{
 		EventMeta: simplevent.EventMeta{
 			Type: bridgev2.RemoteEventMessageUpsert,
 			LogContext: func(c zerolog.Context) zerolog.Context {
@@ -155,15 +266,31 @@ func (zc *ZulipClient) makeTopicUpsert(name string, streamID int) bridgev2.Remot
 			PortalKey:    zc.makeChannelPortalKey(streamID),
 			CreatePortal: true,
 		},
-		ID: zid.MakeTopicMessageID(name),
-		Data: &bridgev2.ConvertedMessage{
-			Parts: []*bridgev2.ConvertedMessagePart{{
-				Type: event.EventMessage,
-				Content: &event.MessageEventContent{
-					MsgType: event.MsgNotice,
-					Body:    fmt.Sprintf("Topic created: %s", name),
-				},
-			}},
+		ID:                 zid.MakeTopicMessageID(zc.realm, name),
+		Data:               name,
+		ConvertMessageFunc: zc.convertTopicRoot,
+		HandleExistingFunc: func(context.Context, *bridgev2.Portal, bridgev2.MatrixAPI, []*database.Message, string) (bridgev2.UpsertResult, error) {
+			return bridgev2.UpsertResult{}, nil
 		},
 	}
 }
+
+// convertTopicRoot makes the notice that starts the thread of a new topic,
+// unless the topic was started from Matrix and the thread already has a root.
+func (zc *ZulipClient) convertTopicRoot(ctx context.Context, portal *bridgev2.Portal, _ bridgev2.MatrixAPI, name string) (*bridgev2.ConvertedMessage, error) {
+	threadRoot, err := portal.Bridge.DB.Message.GetFirstThreadMessage(ctx, portal.PortalKey, zid.MakeTopicMessageID(zc.realm, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for existing topic thread: %w", err)
+	} else if threadRoot != nil {
+		return nil, fmt.Errorf("%w: topic thread was started from Matrix", bridgev2.ErrIgnoringRemoteEvent)
+	}
+	return &bridgev2.ConvertedMessage{
+		Parts: []*bridgev2.ConvertedMessagePart{{
+			Type: event.EventMessage,
+			Content: &event.MessageEventContent{
+				MsgType: event.MsgNotice,
+				Body:    fmt.Sprintf("Topic created: %s", name),
+			},
+		}},
+	}, nil
+}
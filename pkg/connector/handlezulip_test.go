@@ -0,0 +1,59 @@
+//go:build cgo
+
+package connector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/database"
+	"maunium.net/go/mautrix/bridgev2/networkid"
+
+	"go.mau.fi/mautrix-zulip/pkg/zid"
+)
+
+func TestConvertTopicRoot(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	realm := zid.Realm("0123abcd")
+	zc := &ZulipClient{realm: realm}
+	portal := &bridgev2.Portal{
+		Portal: &database.Portal{PortalKey: networkid.PortalKey{ID: zid.MakeChannelPortalID(realm, 5)}},
+		Bridge: &bridgev2.Bridge{DB: db},
+	}
+	_, err := db.Exec(ctx, `
+		INSERT INTO portal (
+			bridge_id, id, receiver, name, topic, avatar_id, avatar_hash, avatar_mxc,
+			name_set, avatar_set, topic_set, in_space, room_type, metadata
+		) VALUES ('zulip', $1, '', '', '', '', '', '', false, false, false, false, '', '{}')
+	`, portal.ID)
+	require.NoError(t, err)
+	_, err = db.Exec(ctx, `
+		INSERT INTO ghost (
+			bridge_id, id, name, avatar_id, avatar_hash, avatar_mxc, name_set, avatar_set, contact_info_set, is_bot, identifiers, metadata
+		) VALUES ('zulip', $1, '', '', '', '', false, false, false, false, '[]', '{}')
+	`, zid.MakeUserID(realm, testOwnUserID))
+	require.NoError(t, err)
+
+	converted, err := zc.convertTopicRoot(ctx, portal, nil, "Deploy")
+	require.NoError(t, err)
+	require.Len(t, converted.Parts, 1)
+	assert.Equal(t, "Topic created: Deploy", converted.Parts[0].Content.Body)
+
+	// A message sent from Matrix with a topic line is the root of the topic thread
+	require.NoError(t, db.Message.Insert(ctx, &database.Message{
+		ID:         zid.MakeMessageID(realm, 100),
+		MXID:       "$root",
+		Room:       portal.PortalKey,
+		SenderID:   zid.MakeUserID(realm, testOwnUserID),
+		ThreadRoot: zid.MakeTopicMessageID(realm, "Deploy"),
+		Metadata:   &zid.MessageMetadata{},
+	}))
+	_, err = zc.convertTopicRoot(ctx, portal, nil, "Deploy")
+	assert.ErrorIs(t, err, bridgev2.ErrIgnoringRemoteEvent)
+	_, err = zc.convertTopicRoot(ctx, portal, nil, "Other")
+	assert.NoError(t, err)
+}
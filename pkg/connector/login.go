@@ -11,6 +11,7 @@ import (
 
 	"go.mau.fi/mautrix-zulip/pkg/zid"
 	"go.mau.fi/mautrix-zulip/pkg/zulip"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/org"
 	"go.mau.fi/mautrix-zulip/pkg/zulip/users"
 )
 
@@ -77,12 +78,20 @@ func (zl *ZulipLogin) SubmitUserInput(ctx context.Context, input map[string]stri
 	if err != nil {
 		return nil, err
 	}
+	settings, err := org.NewService(cli).GetServerSettings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server settings: %w", err)
+	} else if settings.IsIncompatible {
+		return nil, fmt.Errorf("zulip server version %s is not supported", settings.ZulipVersion)
+	}
+	meta.ServerFeatures = settings.ServerFeatures()
+	cli.SetServerFeatures(meta.ServerFeatures)
 	me, err := users.NewService(cli).GetUserMe(ctx)
 	if err != nil {
 		return nil, err
 	}
 	ul, err := zl.User.NewLogin(ctx, &database.UserLogin{
-		ID:         zid.MakeUserLoginID(me.UserID),
+		ID:         zid.MakeUserLoginID(zid.MakeRealm(meta.URL), me.UserID),
 		RemoteName: me.DeliveryEmail,
 		RemoteProfile: status.RemoteProfile{
 			Email: me.DeliveryEmail,
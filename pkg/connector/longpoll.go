@@ -10,6 +10,7 @@ import (
 
 	"go.mau.fi/mautrix-zulip/pkg/zid"
 	"go.mau.fi/mautrix-zulip/pkg/zulip"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/org"
 	"go.mau.fi/mautrix-zulip/pkg/zulip/realtime"
 	"go.mau.fi/mautrix-zulip/pkg/zulip/realtime/events"
 )
@@ -44,7 +45,7 @@ func (zc *ZulipClient) pollQueue(ctx context.Context) {
 	zc.pollStopped.Store(&stopChan)
 	zc.UserLogin.BridgeState.Send(status.BridgeState{StateEvent: status.StateConnecting})
 
-	var connectedSent bool
+	var connectedSent, replaceTried bool
 	meta := zc.UserLogin.Metadata.(*zid.UserLoginMetadata)
 	for {
 		if meta.QueueID == "" {
@@ -97,10 +98,12 @@ func (zc *ZulipClient) pollQueue(ctx context.Context) {
 			zc.UserLogin.BridgeState.Send(status.BridgeState{StateEvent: status.StateConnected})
 			connectedSent = true
 		}
+		handledAll := true
 		for _, evt := range resp.Events {
 			ok := zc.handleZulipEvent(ctx, evt)
 			if !ok {
 				log.Warn().Int("event_id", evt.EventID()).Msg("Failed to handle event")
+				handledAll = false
 				break
 			}
 			meta.LastEventID = evt.EventID()
@@ -111,10 +114,60 @@ func (zc *ZulipClient) pollQueue(ctx context.Context) {
 				log.Err(err).Msg("Failed to save last event ID")
 			}
 		}
+		if handledAll && !replaceTried && !zc.Realm.IsLoaded() {
+			replaceTried = true
+			zc.replaceResumedQueue(ctx, rtc)
+		}
+	}
+}
+
+// replaceResumedQueue registers a new queue after the events missed while the
+// bridge was offline have been handled from the resumed queue, as only a
+// registration returns the initial state for the realm state cache. The old
+// queue is deleted afterwards. If registering fails, the old queue is kept
+// and the cache stays empty.
+func (zc *ZulipClient) replaceResumedQueue(ctx context.Context, rtc *realtime.Service) {
+	log := zerolog.Ctx(ctx)
+	meta := zc.UserLogin.Metadata.(*zid.UserLoginMetadata)
+	oldQueueID := meta.QueueID
+	if err := zc.registerQueue(ctx, rtc); err != nil {
+		log.Err(err).Msg("Failed to replace resumed event queue")
+		return
+	}
+	if err := zc.UserLogin.Save(ctx); err != nil {
+		log.Err(err).Msg("Failed to save new queue ID")
+	}
+	if _, err := rtc.DeleteEventQueue(ctx, oldQueueID); err != nil {
+		log.Warn().Err(err).Str("queue_id", oldQueueID).Msg("Failed to delete resumed event queue")
+	}
+}
+
+// refreshServerFeatures fetches the server version before registering a queue,
+// so that only client capabilities the server supports are requested.
+func (zc *ZulipClient) refreshServerFeatures(ctx context.Context) {
+	resp, err := org.NewService(zc.Client).GetServerSettings(ctx)
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Msg("Failed to fetch server settings, using cached feature level")
+		return
+	}
+	zc.setServerFeatures(ctx, resp.ServerFeatures())
+	zc.updateRealmSpace(ctx, resp)
+}
+
+func (zc *ZulipClient) setServerFeatures(ctx context.Context, features *zulip.ServerFeatures) {
+	meta := zc.UserLogin.Metadata.(*zid.UserLoginMetadata)
+	if meta.ServerFeatures == nil || *meta.ServerFeatures != *features {
+		zerolog.Ctx(ctx).Debug().
+			Str("zulip_version", features.ZulipVersion).
+			Int("zulip_feature_level", features.ZulipFeatureLevel).
+			Msg("Server version changed")
 	}
+	meta.ServerFeatures = features
+	zc.Client.SetServerFeatures(features)
 }
 
 func (zc *ZulipClient) registerQueue(ctx context.Context, rtc *realtime.Service) error {
+	zc.refreshServerFeatures(ctx)
 	resp, err := rtc.RegisterEventQueue(
 		ctx,
 		realtime.EventTypes(
@@ -128,8 +181,17 @@ func (zc *ZulipClient) registerQueue(ctx context.Context, rtc *realtime.Service)
 			events.TypingType,
 			events.UpdateMessageType,
 			events.DeleteMessageType,
+			events.UpdateMessageFlagsType,
 			events.ReactionType,
+			events.SubscriptionType,
+			events.StreamType,
+			events.CustomProfileFieldsType,
+			events.UserSettingsType,
+			events.UserTopicType,
+			events.ScheduledMessagesType,
 		),
+		realtime.FetchEventTypes(realmInitialStateTypes),
+		realtime.IncludeSubscribers(true),
 		realtime.ClientCapabilities(map[realtime.ClientCapability]bool{
 			realtime.NotificationSettingsNull:   true,
 			realtime.BulkMessageDeletion:        true,
@@ -147,19 +209,20 @@ func (zc *ZulipClient) registerQueue(ctx context.Context, rtc *realtime.Service)
 		realtime.ApplyMarkdown(true),
 	)
 	if err != nil {
-		zerolog.Ctx(ctx).Err(err).Msg("Failed to register event queue")
-		zc.UserLogin.BridgeState.Send(status.BridgeState{
-			StateEvent: status.StateUnknownError,
-			Error:      "zulip-queue-register-error",
-			Info: map[string]any{
-				"go_error": err.Error(),
-			},
-		})
 		return fmt.Errorf("failed to register event queue: %w", err)
 	}
-	zerolog.Ctx(ctx).Debug().Any("queue_register_resp", resp).Msg("Registered queue")
+	zerolog.Ctx(ctx).Debug().
+		Str("queue_id", resp.QueueID).
+		Int("last_event_id", resp.LastEventID).
+		Int("realm_users", len(resp.RealmUsers)).
+		Int("subscriptions", len(resp.Subscriptions)).
+		Msg("Registered queue")
+	zc.Realm.Load(resp)
+	zc.syncAlertWords(ctx, resp.AlertWords)
 	meta := zc.UserLogin.Metadata.(*zid.UserLoginMetadata)
 	meta.QueueID = resp.QueueID
 	meta.LastEventID = resp.LastEventID
+	meta.MaxFileUploadSizeMiB = resp.MaxFileUploadSizeMiB
+	zc.setServerFeatures(ctx, resp.ServerFeatures())
 	return nil
 }
@@ -0,0 +1,84 @@
+package connector
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mau.fi/mautrix-zulip/pkg/msgconv/zuliphtml"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/messages"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/narrow"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/users"
+)
+
+// topicParticipantLookback is how many recent messages are checked to find participants for @topic mentions.
+const topicParticipantLookback = 500
+
+// topicParticipantCacheTime is how long the participants of a topic are
+// reused, so that converting a batch of messages or edits with @topic
+// mentions doesn't fetch the same messages every time.
+const topicParticipantCacheTime = time.Minute
+
+type cachedTopicParticipants struct {
+	userIDs   []int
+	fetchedAt time.Time
+}
+
+var _ zuliphtml.MentionResolver = (*ZulipClient)(nil)
+
+func (zc *ZulipClient) GetUserGroupMembers(ctx context.Context, groupID int) ([]int, error) {
+	resp, err := users.NewService(zc.Client).GetUserGroupMembers(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Members, nil
+}
+
+func (zc *ZulipClient) GetTopicParticipants(ctx context.Context, streamID int, topic string) ([]int, error) {
+	key := userTopicKey{streamID, strings.ToLower(topic)}
+	zc.topicParticipantsLock.Lock()
+	cached, ok := zc.topicParticipants[key]
+	zc.topicParticipantsLock.Unlock()
+	if ok && time.Since(cached.fetchedAt) < topicParticipantCacheTime {
+		return cached.userIDs, nil
+	}
+	participants, err := zc.fetchTopicParticipants(ctx, streamID, topic)
+	if err != nil {
+		return nil, err
+	}
+	zc.topicParticipantsLock.Lock()
+	if zc.topicParticipants == nil {
+		zc.topicParticipants = make(map[userTopicKey]cachedTopicParticipants)
+	}
+	maps.DeleteFunc(zc.topicParticipants, func(_ userTopicKey, cached cachedTopicParticipants) bool {
+		return time.Since(cached.fetchedAt) >= topicParticipantCacheTime
+	})
+	zc.topicParticipants[key] = cachedTopicParticipants{userIDs: participants, fetchedAt: time.Now()}
+	zc.topicParticipantsLock.Unlock()
+	return participants, nil
+}
+
+func (zc *ZulipClient) fetchTopicParticipants(ctx context.Context, streamID int, topic string) ([]int, error) {
+	resp, err := messages.NewService(zc.Client).GetMessages(
+		ctx,
+		messages.Anchor("newest"),
+		messages.NumBefore(topicParticipantLookback),
+		messages.NumAfter(0),
+		messages.ApplyMarkdownMessage(false),
+		messages.NarrowMessage(narrow.NewFilter().
+			Add(narrow.New(narrow.Channel, streamID)).
+			Add(narrow.New(narrow.Topic, topic))),
+	)
+	if err != nil {
+		return nil, err
+	}
+	participants := make([]int, 0)
+	for _, msg := range resp.Messages {
+		if !slices.Contains(participants, msg.SenderID) {
+			participants = append(participants, msg.SenderID)
+		}
+	}
+	return participants, nil
+}
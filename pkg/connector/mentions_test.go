@@ -0,0 +1,42 @@
+package connector
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTopicParticipantsCache(t *testing.T) {
+	requests := 0
+	zc := newTestClient(t, Config{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		require.Equal(t, "/api/v1/messages", r.URL.Path)
+		_, _ = w.Write([]byte(`{"result": "success", "msg": "", "messages": [{"id": 1, "sender_id": 20}, {"id": 2, "sender_id": 30}, {"id": 3, "sender_id": 20}]}`))
+	}))
+	ctx := context.Background()
+
+	participants, err := zc.GetTopicParticipants(ctx, 5, "Deploy")
+	require.NoError(t, err)
+	assert.Equal(t, []int{20, 30}, participants)
+	participants, err = zc.GetTopicParticipants(ctx, 5, "deploy")
+	require.NoError(t, err)
+	assert.Equal(t, []int{20, 30}, participants)
+	assert.Equal(t, 1, requests)
+
+	_, err = zc.GetTopicParticipants(ctx, 5, "Other")
+	require.NoError(t, err)
+	assert.Equal(t, 2, requests)
+
+	zc.topicParticipants[userTopicKey{5, "deploy"}] = cachedTopicParticipants{
+		userIDs:   []int{20},
+		fetchedAt: time.Now().Add(-topicParticipantCacheTime),
+	}
+	participants, err = zc.GetTopicParticipants(ctx, 5, "Deploy")
+	require.NoError(t, err)
+	assert.Equal(t, []int{20, 30}, participants)
+	assert.Equal(t, 3, requests)
+}
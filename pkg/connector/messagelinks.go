@@ -0,0 +1,67 @@
+package connector
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog"
+
+	"go.mau.fi/mautrix-zulip/pkg/zid"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/messages"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/narrow"
+)
+
+// excerptLength is the maximum length of message excerpts in command replies.
+const excerptLength = 100
+
+// getMessagePermalink returns a matrix.to link to a message if it has been
+// bridged, and a link to the message on Zulip otherwise.
+func (zc *ZulipClient) getMessagePermalink(ctx context.Context, msg *messages.Message) string {
+	if link := zc.getBridgedPermalink(ctx, msg.ID); link != "" {
+		return link
+	}
+	return zc.getZulipPermalink(msg)
+}
+
+// getBridgedPermalink returns a matrix.to link to a message, or an empty
+// string if the message hasn't been bridged.
+func (zc *ZulipClient) getBridgedPermalink(ctx context.Context, messageID int) string {
+	part, err := zc.Main.Bridge.DB.Message.GetFirstPartByID(ctx, zc.UserLogin.ID, zid.MakeMessageID(zc.realm, messageID))
+	if err != nil {
+		zerolog.Ctx(ctx).Err(err).Int("message_id", messageID).Msg("Failed to get message for permalink")
+		return ""
+	} else if part == nil {
+		return ""
+	}
+	portal, err := zc.Main.Bridge.GetExistingPortalByKey(ctx, part.Room)
+	if err != nil {
+		zerolog.Ctx(ctx).Err(err).Int("message_id", messageID).Msg("Failed to get portal for permalink")
+		return ""
+	} else if portal == nil || portal.MXID == "" {
+		return ""
+	}
+	return portal.MXID.EventURI(part.MXID, zc.Main.Bridge.Matrix.ServerName()).MatrixToURL()
+}
+
+// getZulipPermalink returns a link to a message in the Zulip web app.
+func (zc *ZulipClient) getZulipPermalink(msg *messages.Message) string {
+	baseURL := zc.UserLogin.Metadata.(*zid.UserLoginMetadata).URL
+	if msg.DisplayRecipient.IsChannel {
+		return narrow.ChannelMessageURL(baseURL, msg.StreamID, msg.DisplayRecipient.Channel, msg.Subject, msg.ID)
+	}
+	userIDs := make([]int, len(msg.DisplayRecipient.Users))
+	for i, user := range msg.DisplayRecipient.Users {
+		userIDs[i] = user.ID
+	}
+	return narrow.DMMessageURL(baseURL, userIDs, msg.ID)
+}
+
+// makeExcerpt shortens the raw content of a message to a single line.
+func makeExcerpt(content string) string {
+	content = strings.Join(strings.Fields(content), " ")
+	if utf8.RuneCountInString(content) <= excerptLength {
+		return content
+	}
+	return string([]rune(content)[:excerptLength-1]) + "…"
+}
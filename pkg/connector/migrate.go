@@ -0,0 +1,356 @@
+package connector
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mau.fi/util/dbutil"
+	"maunium.net/go/mautrix/bridgev2/database"
+	"maunium.net/go/mautrix/bridgev2/networkid"
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+
+	"go.mau.fi/mautrix-zulip/pkg/zid"
+)
+
+// keyRealmIDsMigrated is set once IDs from before they were namespaced by
+// realm have been migrated.
+const keyRealmIDsMigrated database.Key = "zulip_realm_ids_migrated"
+
+// keyLegacyGhostCleanup is "pending" after the migration until the ghosts with
+// legacy IDs have been replaced in all rooms.
+const keyLegacyGhostCleanup database.Key = "zulip_legacy_ghost_cleanup"
+
+const (
+	getLegacyLoginsQuery     = `SELECT id, metadata FROM user_login WHERE bridge_id=$1 ORDER BY id`
+	getLegacyPortalsQuery    = `SELECT id, receiver FROM portal WHERE bridge_id=$1`
+	getPortalLoginQuery      = `SELECT login_id FROM user_portal WHERE bridge_id=$1 AND portal_id=$2 AND portal_receiver=$3 LIMIT 1`
+	getLegacyGhostsQuery     = `SELECT id FROM ghost WHERE bridge_id=$1`
+	getGhostMessageRoomQuery = `SELECT room_id FROM message WHERE bridge_id=$1 AND sender_id=$2 LIMIT 1`
+	migratePortalIDQuery     = `
+		UPDATE portal
+		SET id=$4, receiver=$5,
+		    other_user_id=CASE WHEN other_user_id IS NULL OR other_user_id='' THEN other_user_id ELSE $6 || other_user_id END
+		WHERE bridge_id=$1 AND id=$2 AND receiver=$3
+	`
+	migrateMessageIDsQuery = `
+		UPDATE message
+		SET id=$3 || id,
+		    thread_root_id=CASE WHEN thread_root_id IS NULL OR thread_root_id='' THEN thread_root_id ELSE $3 || thread_root_id END,
+		    reply_to_id=CASE WHEN reply_to_id IS NULL OR reply_to_id='' THEN reply_to_id ELSE $3 || reply_to_id END
+		WHERE bridge_id=$1 AND room_id LIKE $2
+	`
+	// The ghost ID is part of the MXID, so the profile is reset to make the bridge set it on the new MXID.
+	migrateGhostIDQuery = `
+		UPDATE ghost SET id=$3, name='', name_set=false, avatar_set=false, contact_info_set=false
+		WHERE bridge_id=$1 AND id=$2
+	`
+	migrateLoginIDQuery       = `UPDATE user_login SET id=$3 WHERE bridge_id=$1 AND id=$2`
+	migrateBackfillLoginQuery = `UPDATE backfill_task SET user_login_id=$3 WHERE bridge_id=$1 AND user_login_id=$2`
+	// Same as the KV store query, but run here to get errors inside the migration transaction.
+	setMigrationKVQuery = `
+		INSERT INTO kv_store (bridge_id, key, value) VALUES ($1, $2, $3)
+		ON CONFLICT (bridge_id, key) DO UPDATE SET value=$3
+	`
+)
+
+// migrateRealmIDs adds realm prefixes to the IDs of logins, portals, ghosts
+// and messages created before IDs were namespaced by realm.
+//
+// Rows that aren't tied to a single login, like ghosts and non-split portals,
+// are assigned to the realm of a login that uses them. If several realms were
+// bridged before the migration, rows that were shared between them can only
+// be kept in one realm.
+//
+// The ghost IDs are part of their MXIDs, so the migrated ghosts get new MXIDs.
+// The old ghosts are replaced in rooms afterwards by replaceLegacyGhosts.
+func (zc *ZulipConnector) migrateRealmIDs(ctx context.Context) error {
+	db := zc.Bridge.DB
+	if db.KV.Get(ctx, keyRealmIDsMigrated) == "true" {
+		return nil
+	}
+	log := zerolog.Ctx(ctx).With().Str("action", "migrate realm ids").Logger()
+	ctx = log.WithContext(ctx)
+	err := db.DoTxn(ctx, nil, func(ctx context.Context) error {
+		m := &realmIDMigration{db: db.Database, bridgeID: db.BridgeID}
+		if err := m.run(ctx); err != nil {
+			return err
+		} else if m.migratedGhosts > 0 {
+			if err = m.setKV(ctx, keyLegacyGhostCleanup, "pending"); err != nil {
+				return err
+			}
+		}
+		return m.setKV(ctx, keyRealmIDsMigrated, "true")
+	})
+	if err != nil {
+		return fmt.Errorf("failed to migrate IDs to include the realm: %w", err)
+	}
+	return nil
+}
+
+type realmIDMigration struct {
+	db       *dbutil.Database
+	bridgeID networkid.BridgeID
+
+	loginRealms    map[string]zid.Realm
+	defaultRealm   zid.Realm
+	usedRealms     map[zid.Realm]struct{}
+	migratedGhosts int
+}
+
+type legacyPortalKey struct {
+	id       string
+	receiver string
+}
+
+func (m *realmIDMigration) run(ctx context.Context) error {
+	log := zerolog.Ctx(ctx)
+	err := m.loadLogins(ctx)
+	if err != nil {
+		return err
+	} else if len(m.loginRealms) == 0 {
+		log.Debug().Msg("No logins to migrate")
+		return nil
+	}
+	m.usedRealms = make(map[zid.Realm]struct{})
+	if err = m.migratePortals(ctx); err != nil {
+		return err
+	}
+	for realm := range m.usedRealms {
+		prefix := realm.PrefixLegacyID("")
+		if _, err = m.db.Exec(ctx, migrateMessageIDsQuery, m.bridgeID, prefix+"%", prefix); err != nil {
+			return fmt.Errorf("failed to migrate message IDs: %w", err)
+		}
+	}
+	if err = m.migrateGhosts(ctx); err != nil {
+		return err
+	}
+	for oldID, realm := range m.loginRealms {
+		newID := realm.PrefixLegacyID(oldID)
+		if _, err = m.db.Exec(ctx, migrateLoginIDQuery, m.bridgeID, oldID, newID); err != nil {
+			return fmt.Errorf("failed to migrate login %s: %w", oldID, err)
+		} else if _, err = m.db.Exec(ctx, migrateBackfillLoginQuery, m.bridgeID, oldID, newID); err != nil {
+			return fmt.Errorf("failed to migrate backfill tasks of login %s: %w", oldID, err)
+		}
+	}
+	log.Info().Int("logins", len(m.loginRealms)).Int("realms", len(m.usedRealms)).Msg("Migrated IDs to include the realm")
+	return nil
+}
+
+func (m *realmIDMigration) setKV(ctx context.Context, key database.Key, value string) error {
+	if _, err := m.db.Exec(ctx, setMigrationKVQuery, m.bridgeID, key, value); err != nil {
+		return fmt.Errorf("failed to set %s: %w", key, err)
+	}
+	return nil
+}
+
+func (m *realmIDMigration) loadLogins(ctx context.Context) error {
+	rows, err := m.db.Query(ctx, getLegacyLoginsQuery, m.bridgeID)
+	if err != nil {
+		return fmt.Errorf("failed to get logins: %w", err)
+	}
+	defer rows.Close()
+	m.loginRealms = make(map[string]zid.Realm)
+	for rows.Next() {
+		var id string
+		var rawMeta []byte
+		if err = rows.Scan(&id, &rawMeta); err != nil {
+			return fmt.Errorf("failed to scan login: %w", err)
+		}
+		var meta zid.UserLoginMetadata
+		if err = json.Unmarshal(rawMeta, &meta); err != nil {
+			return fmt.Errorf("failed to parse metadata of login %s: %w", id, err)
+		}
+		realm := zid.MakeRealm(meta.URL)
+		m.loginRealms[id] = realm
+		if m.defaultRealm == "" {
+			m.defaultRealm = realm
+		}
+	}
+	return rows.Err()
+}
+
+func (m *realmIDMigration) migratePortals(ctx context.Context) error {
+	rows, err := m.db.Query(ctx, getLegacyPortalsQuery, m.bridgeID)
+	if err != nil {
+		return fmt.Errorf("failed to get portals: %w", err)
+	}
+	var portals []legacyPortalKey
+	for rows.Next() {
+		var key legacyPortalKey
+		if err = rows.Scan(&key.id, &key.receiver); err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("failed to scan portal: %w", err)
+		}
+		portals = append(portals, key)
+	}
+	_ = rows.Close()
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to get portals: %w", err)
+	}
+	for _, key := range portals {
+		realm, err := m.portalRealm(ctx, key)
+		if err != nil {
+			return err
+		}
+		m.usedRealms[realm] = struct{}{}
+		newReceiver := key.receiver
+		if newReceiver != "" {
+			newReceiver = realm.PrefixLegacyID(newReceiver)
+		}
+		_, err = m.db.Exec(
+			ctx, migratePortalIDQuery, m.bridgeID, key.id, key.receiver,
+			migrateLegacyPortalID(realm, key.id), newReceiver, realm.PrefixLegacyID(""),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to migrate portal %s: %w", key.id, err)
+		}
+	}
+	return nil
+}
+
+// portalRealm finds the realm of a portal from its receiver or from a login
+// that is in the portal.
+func (m *realmIDMigration) portalRealm(ctx context.Context, key legacyPortalKey) (zid.Realm, error) {
+	if realm, ok := m.loginRealms[key.receiver]; ok {
+		return realm, nil
+	}
+	var loginID string
+	err := m.db.QueryRow(ctx, getPortalLoginQuery, m.bridgeID, key.id, key.receiver).Scan(&loginID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("failed to get login of portal %s: %w", key.id, err)
+	} else if realm, ok := m.loginRealms[loginID]; ok {
+		return realm, nil
+	}
+	return m.defaultRealm, nil
+}
+
+// migrateLegacyPortalID converts a portal ID to the realm-aware format. The
+// space portals used the realm host instead of a prefix, so they're made from
+// scratch.
+func migrateLegacyPortalID(realm zid.Realm, id string) networkid.PortalID {
+	if strings.HasPrefix(id, "realm:") {
+		return zid.MakeRealmSpacePortalID(realm)
+	} else if strings.HasPrefix(id, "dms:") {
+		userID, _ := strconv.Atoi(id[strings.LastIndexByte(id, ':')+1:])
+		return zid.MakeDMSpacePortalID(realm, userID)
+	}
+	newID := networkid.PortalID(realm.PrefixLegacyID(id))
+	if _, streamID, topic, ok := zid.ParseTopicPortalID(newID); ok {
+		// Topic portal IDs didn't use to be case-insensitive
+		return zid.MakeTopicPortalID(realm, streamID, topic)
+	}
+	return newID
+}
+
+func (m *realmIDMigration) migrateGhosts(ctx context.Context) error {
+	rows, err := m.db.Query(ctx, getLegacyGhostsQuery, m.bridgeID)
+	if err != nil {
+		return fmt.Errorf("failed to get ghosts: %w", err)
+	}
+	var ghostIDs []string
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("failed to scan ghost: %w", err)
+		}
+		ghostIDs = append(ghostIDs, id)
+	}
+	_ = rows.Close()
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to get ghosts: %w", err)
+	}
+	for _, id := range ghostIDs {
+		realm := m.defaultRealm
+		var roomID string
+		err = m.db.QueryRow(ctx, getGhostMessageRoomQuery, m.bridgeID, id).Scan(&roomID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to get room of ghost %s: %w", id, err)
+		} else if roomRealm, _, _, _ := zid.ParsePortalID(networkid.PortalID(roomID)); roomRealm != "" {
+			realm = roomRealm
+		}
+		if _, err = m.db.Exec(ctx, migrateGhostIDQuery, m.bridgeID, id, realm.PrefixLegacyID(id)); err != nil {
+			return fmt.Errorf("failed to migrate ghost %s: %w", id, err)
+		}
+		m.migratedGhosts++
+	}
+	return nil
+}
+
+// replaceLegacyGhosts replaces the ghosts with legacy MXIDs in all portal rooms
+// with the ghosts of the migrated IDs, then clears the profiles of the legacy
+// ghosts. It's retried on every start until all rooms have been handled.
+func (zc *ZulipConnector) replaceLegacyGhosts(ctx context.Context) {
+	db := zc.Bridge.DB
+	if db.KV.Get(ctx, keyLegacyGhostCleanup) != "pending" {
+		return
+	}
+	log := zerolog.Ctx(ctx).With().Str("action", "replace legacy ghosts").Logger()
+	ctx = log.WithContext(ctx)
+	portals, err := db.Portal.GetAllWithMXID(ctx)
+	if err != nil {
+		log.Err(err).Msg("Failed to get portals")
+		return
+	}
+	failed := false
+	legacyGhosts := make(map[networkid.UserID]struct{})
+	for _, portal := range portals {
+		realm, _, _, _ := zid.ParsePortalID(portal.ID)
+		if realm == "" {
+			continue
+		}
+		members, err := zc.Bridge.Matrix.GetMembers(ctx, portal.MXID)
+		if err != nil {
+			log.Err(err).Stringer("room_id", portal.MXID).Msg("Failed to get members")
+			failed = true
+			continue
+		}
+		for userID, member := range members {
+			ghostID, ok := zc.Bridge.Matrix.ParseGhostMXID(userID)
+			if !ok || (member.Membership != event.MembershipJoin && member.Membership != event.MembershipInvite) {
+				continue
+			} else if ghostRealm, _ := zid.ParseUserID(ghostID); ghostRealm != "" {
+				continue
+			}
+			legacyGhosts[ghostID] = struct{}{}
+			err = zc.replaceLegacyGhost(ctx, portal.MXID, ghostID, networkid.UserID(realm.PrefixLegacyID(string(ghostID))))
+			if err != nil {
+				log.Err(err).Stringer("room_id", portal.MXID).Stringer("user_id", userID).Msg("Failed to replace legacy ghost")
+				failed = true
+			}
+		}
+	}
+	for ghostID := range legacyGhosts {
+		intent := zc.Bridge.Matrix.GhostIntent(ghostID)
+		if err = intent.SetDisplayName(ctx, ""); err != nil {
+			log.Err(err).Str("ghost_id", string(ghostID)).Msg("Failed to clear displayname of legacy ghost")
+		}
+		if err = intent.SetAvatarURL(ctx, id.ContentURIString("")); err != nil {
+			log.Err(err).Str("ghost_id", string(ghostID)).Msg("Failed to clear avatar of legacy ghost")
+		}
+	}
+	if !failed {
+		db.KV.Set(ctx, keyLegacyGhostCleanup, "done")
+		log.Info().Int("ghosts", len(legacyGhosts)).Msg("Replaced legacy ghosts")
+	}
+}
+
+func (zc *ZulipConnector) replaceLegacyGhost(ctx context.Context, roomID id.RoomID, oldGhostID, newGhostID networkid.UserID) error {
+	err := zc.Bridge.Matrix.GhostIntent(newGhostID).EnsureJoined(ctx, roomID)
+	if err != nil {
+		return fmt.Errorf("failed to join new ghost: %w", err)
+	}
+	oldIntent := zc.Bridge.Matrix.GhostIntent(oldGhostID)
+	_, err = oldIntent.SendState(ctx, roomID, event.StateMember, oldIntent.GetMXID().String(), &event.Content{
+		Parsed: &event.MemberEventContent{Membership: event.MembershipLeave},
+	}, time.Time{})
+	return err
+}
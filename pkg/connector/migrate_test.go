@@ -0,0 +1,169 @@
+//go:build cgo
+
+package connector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mau.fi/util/dbutil"
+	_ "go.mau.fi/util/dbutil/litestream"
+	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/database"
+
+	"go.mau.fi/mautrix-zulip/pkg/zid"
+)
+
+const (
+	testRealmURLA = "https://a.zulipchat.com"
+	testRealmURLB = "https://b.zulipchat.com"
+)
+
+// legacyFixture is a bridge database from before IDs were namespaced by realm,
+// with logins on two realms that share a DM portal ID.
+var legacyFixture = []string{
+	`INSERT INTO "user" (bridge_id, mxid) VALUES ('zulip', '@alice:example.com')`,
+	`INSERT INTO user_login (bridge_id, user_mxid, id, remote_name, metadata) VALUES
+		('zulip', '@alice:example.com', '10', 'Alice A', '{"url":"` + testRealmURLA + `"}'),
+		('zulip', '@alice:example.com', '20', 'Alice B', '{"url":"` + testRealmURLB + `"}')`,
+	`INSERT INTO portal (
+		bridge_id, id, receiver, mxid, parent_id, parent_receiver, other_user_id,
+		name, topic, avatar_id, avatar_hash, avatar_mxc, name_set, avatar_set, topic_set, in_space, room_type, metadata
+	) VALUES
+		('zulip', 'realm:a.zulipchat.com', '10', '!spacea:example.com', NULL, '', NULL, '', '', '', '', '', false, false, false, false, 'space', '{}'),
+		('zulip', 'dms:20', '20', '!dmsb:example.com', NULL, '', NULL, '', '', '', '', '', false, false, false, false, 'space', '{}'),
+		('zulip', 'stream:5', '', '!stream:example.com', 'realm:a.zulipchat.com', '10', NULL, '', '', '', '', '', false, false, false, false, '', '{}'),
+		('zulip', 'topic:5:Deploy', '', '!topic:example.com', NULL, '', NULL, '', '', '', '', '', false, false, false, false, '', '{}'),
+		('zulip', 'dm:10,20', '10', '!dma:example.com', NULL, '', '20', '', '', '', '', '', false, false, false, false, 'dm', '{}'),
+		('zulip', 'dm:10,20', '20', '!dmb:example.com', NULL, '', '10', '', '', '', '', '', false, false, false, false, 'dm', '{}')`,
+	`INSERT INTO user_portal (bridge_id, user_mxid, login_id, portal_id, portal_receiver, in_space, preferred) VALUES
+		('zulip', '@alice:example.com', '10', 'stream:5', '', true, true),
+		('zulip', '@alice:example.com', '10', 'topic:5:Deploy', '', true, true),
+		('zulip', '@alice:example.com', '10', 'dm:10,20', '10', true, true),
+		('zulip', '@alice:example.com', '20', 'dm:10,20', '20', true, true)`,
+	`INSERT INTO ghost (
+		bridge_id, id, name, avatar_id, avatar_hash, avatar_mxc, name_set, avatar_set, contact_info_set, is_bot, identifiers, metadata
+	) VALUES
+		('zulip', '11', 'Bob', '', '', '', true, true, true, false, '[]', '{}'),
+		('zulip', '21', 'Carol', '', '', '', true, true, true, false, '[]', '{}')`,
+	`INSERT INTO message (
+		bridge_id, id, part_id, mxid, room_id, room_receiver, sender_id, sender_mxid, timestamp, edit_count, thread_root_id, reply_to_id, metadata
+	) VALUES
+		('zulip', '100', '', '$100', 'stream:5', '', '11', '', 1, 0, 'topic:Deploy', NULL, '{}'),
+		('zulip', '101', '', '$101', 'topic:5:Deploy', '', '11', '', 2, 0, NULL, '100', '{}'),
+		('zulip', '200', '', '$200a', 'dm:10,20', '10', '11', '', 3, 0, NULL, NULL, '{}'),
+		('zulip', '200', '', '$200b', 'dm:10,20', '20', '21', '', 3, 0, NULL, NULL, '{}')`,
+	`INSERT INTO reaction (
+		bridge_id, message_id, message_part_id, sender_id, emoji_id, room_id, room_receiver, mxid, timestamp, emoji, metadata
+	) VALUES ('zulip', '200', '', '21', '1f44d', 'dm:10,20', '20', '$react', 4, '👍', '{}')`,
+}
+
+// newTestDB makes an empty bridge database in memory.
+func newTestDB(t *testing.T) *database.Database {
+	rawDB, err := dbutil.NewFromConfig("", dbutil.Config{PoolConfig: dbutil.PoolConfig{
+		Type:         "sqlite3-fk-wal",
+		URI:          ":memory:?_txlock=immediate",
+		MaxOpenConns: 1,
+		MaxIdleConns: 1,
+	}}, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = rawDB.Close() })
+	db := database.New("zulip", (&ZulipConnector{}).GetDBMetaTypes(), rawDB)
+	require.NoError(t, db.Upgrade(context.Background()))
+	return db
+}
+
+func newLegacyTestDB(t *testing.T) *database.Database {
+	ctx := context.Background()
+	db := newTestDB(t)
+	for _, query := range legacyFixture {
+		_, err := db.Exec(ctx, query)
+		require.NoError(t, err)
+	}
+	return db
+}
+
+func queryStrings(t *testing.T, db *database.Database, query string, args ...any) []string {
+	rows, err := db.Query(context.Background(), query, args...)
+	require.NoError(t, err)
+	var values []string
+	for rows.Next() {
+		var value string
+		require.NoError(t, rows.Scan(&value))
+		values = append(values, value)
+	}
+	require.NoError(t, rows.Err())
+	return values
+}
+
+func TestMigrateRealmIDs(t *testing.T) {
+	ctx := context.Background()
+	db := newLegacyTestDB(t)
+	zc := &ZulipConnector{Bridge: &bridgev2.Bridge{DB: db}}
+	realmA, realmB := zid.MakeRealm(testRealmURLA), zid.MakeRealm(testRealmURLB)
+	loginA, loginB := string(zid.MakeUserLoginID(realmA, 10)), string(zid.MakeUserLoginID(realmB, 20))
+
+	require.NoError(t, zc.migrateRealmIDs(ctx))
+	assert.Equal(t, "true", db.KV.Get(ctx, keyRealmIDsMigrated))
+	assert.Equal(t, "pending", db.KV.Get(ctx, keyLegacyGhostCleanup))
+	// Running it again must not prefix the IDs twice
+	require.NoError(t, zc.migrateRealmIDs(ctx))
+
+	assert.Equal(t, []string{loginA, loginB}, queryStrings(t, db, `SELECT id FROM user_login ORDER BY remote_name`))
+	assert.ElementsMatch(t, []string{
+		string(zid.MakeRealmSpacePortalID(realmA)) + "|" + loginA,
+		string(zid.MakeDMSpacePortalID(realmB, 20)) + "|" + loginB,
+		string(zid.MakeChannelPortalID(realmA, 5)) + "|",
+		string(zid.MakeTopicPortalID(realmA, 5, "Deploy")) + "|",
+		string(zid.MakeDMPortalID(realmA, []int{10, 20})) + "|" + loginA,
+		string(zid.MakeDMPortalID(realmB, []int{10, 20})) + "|" + loginB,
+	}, queryStrings(t, db, `SELECT id || '|' || receiver FROM portal`))
+	assert.Equal(t,
+		[]string{string(zid.MakeRealmSpacePortalID(realmA)) + "|" + loginA},
+		queryStrings(t, db, `SELECT parent_id || '|' || parent_receiver FROM portal WHERE parent_id IS NOT NULL`),
+	)
+	assert.ElementsMatch(t,
+		[]string{string(zid.MakeUserID(realmA, 20)), string(zid.MakeUserID(realmB, 10))},
+		queryStrings(t, db, `SELECT other_user_id FROM portal WHERE other_user_id IS NOT NULL`),
+	)
+	assert.ElementsMatch(t, []string{
+		string(zid.MakeChannelPortalID(realmA, 5)) + "|" + loginA,
+		string(zid.MakeTopicPortalID(realmA, 5, "Deploy")) + "|" + loginA,
+		string(zid.MakeDMPortalID(realmA, []int{10, 20})) + "|" + loginA,
+		string(zid.MakeDMPortalID(realmB, []int{10, 20})) + "|" + loginB,
+	}, queryStrings(t, db, `SELECT portal_id || '|' || login_id FROM user_portal`))
+
+	assert.Equal(t, []string{
+		string(zid.MakeMessageID(realmA, 100)) + "|" + string(zid.MakeTopicMessageID(realmA, "Deploy")) + "|",
+		string(zid.MakeMessageID(realmA, 101)) + "||" + string(zid.MakeMessageID(realmA, 100)),
+		string(zid.MakeMessageID(realmA, 200)) + "||",
+		string(zid.MakeMessageID(realmB, 200)) + "||",
+	}, queryStrings(t, db, `
+		SELECT id || '|' || COALESCE(thread_root_id, '') || '|' || COALESCE(reply_to_id, '')
+		FROM message ORDER BY timestamp, mxid
+	`))
+	assert.Equal(t,
+		[]string{string(zid.MakeMessageID(realmB, 200)) + "|" + string(zid.MakeUserID(realmB, 21))},
+		queryStrings(t, db, `SELECT message_id || '|' || sender_id FROM reaction`),
+	)
+
+	assert.ElementsMatch(t,
+		[]string{string(zid.MakeUserID(realmA, 11)), string(zid.MakeUserID(realmB, 21))},
+		queryStrings(t, db, `SELECT id FROM ghost WHERE name='' AND NOT name_set AND NOT avatar_set`),
+	)
+}
+
+func TestMigrateRealmIDsNoLogins(t *testing.T) {
+	ctx := context.Background()
+	db := newLegacyTestDB(t)
+	_, err := db.Exec(ctx, `DELETE FROM user_login`)
+	require.NoError(t, err)
+	zc := &ZulipConnector{Bridge: &bridgev2.Bridge{DB: db}}
+
+	require.NoError(t, zc.migrateRealmIDs(ctx))
+	assert.Equal(t, "true", db.KV.Get(ctx, keyRealmIDsMigrated))
+	assert.Empty(t, db.KV.Get(ctx, keyLegacyGhostCleanup))
+	assert.Contains(t, queryStrings(t, db, `SELECT id FROM portal`), "stream:5")
+}
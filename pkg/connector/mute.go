@@ -0,0 +1,290 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"go.mau.fi/util/ptr"
+	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/commands"
+	"maunium.net/go/mautrix/bridgev2/database"
+	"maunium.net/go/mautrix/bridgev2/networkid"
+	"maunium.net/go/mautrix/bridgev2/simplevent"
+	"maunium.net/go/mautrix/event"
+
+	"go.mau.fi/mautrix-zulip/pkg/zid"
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/channels"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/realtime/events"
+)
+
+// makeUserLocalInfo maps the mute and pin state of a chat to push rules and
+// room tags of the user's double puppet. Pinned chats are favourites and
+// muted chats are low priority.
+func makeUserLocalInfo(muted, pinned bool) *bridgev2.UserLocalPortalInfo {
+	info := &bridgev2.UserLocalPortalInfo{
+		MutedUntil: ptr.Ptr(bridgev2.Unmuted),
+		Tag:        ptr.Ptr(event.RoomTag("")),
+	}
+	if muted {
+		info.MutedUntil = ptr.Ptr(event.MutedForever)
+		info.Tag = ptr.Ptr(event.RoomTagLowPriority)
+	}
+	if pinned {
+		info.Tag = ptr.Ptr(event.RoomTagFavourite)
+	}
+	return info
+}
+
+// getChannelUserLocalInfo returns the mute and pin state of a channel room.
+// Channel spaces only have the pin, as their topic rooms are muted instead.
+//
+// The state is nil until the realm state is loaded, e.g. after resuming a
+// queue, so that resyncs don't reset the state of the room.
+func (zc *ZulipClient) getChannelUserLocalInfo(streamID int) *bridgev2.UserLocalPortalInfo {
+	sub, ok := zc.Realm.GetSubscription(streamID)
+	if !ok {
+		return nil
+	}
+	return makeUserLocalInfo(sub.IsMuted && !zc.Main.Config.RoomPerTopic, sub.PinToTop)
+}
+
+// getTopicUserLocalInfo returns the mute state of a topic room, which follows
+// the channel unless the topic has its own visibility policy. Like for channel
+// rooms, the state is nil until the realm state is loaded.
+func (zc *ZulipClient) getTopicUserLocalInfo(streamID int, topic string) *bridgev2.UserLocalPortalInfo {
+	sub, ok := zc.Realm.GetSubscription(streamID)
+	if !ok {
+		return nil
+	}
+	policy, ok := zc.Realm.GetTopicVisibility(streamID, topic)
+	if !ok {
+		return nil
+	}
+	return makeUserLocalInfo(isTopicMuted(policy, sub.IsMuted), false)
+}
+
+// isChannelMuted returns whether the user has muted a channel, fetching the
+// subscriptions from the server if the realm state isn't loaded.
+func (zc *ZulipClient) isChannelMuted(ctx context.Context, streamID int) (bool, error) {
+	if sub, ok := zc.Realm.GetSubscription(streamID); ok {
+		return sub.IsMuted, nil
+	}
+	resp, err := channels.NewService(zc.Client).GetSubscribedChannels(ctx, channels.IncludeSubscribersList(false))
+	if err != nil {
+		return false, fmt.Errorf("failed to get subscriptions: %w", err)
+	}
+	for _, sub := range resp.Subscriptions {
+		if sub.StreamID == streamID {
+			return sub.IsMuted, nil
+		}
+	}
+	return false, nil
+}
+
+func isTopicMuted(policy zulip.VisibilityPolicy, channelMuted bool) bool {
+	switch policy {
+	case zulip.VisibilityPolicyMuted:
+		return true
+	case zulip.VisibilityPolicyUnmuted, zulip.VisibilityPolicyFollowed:
+		return false
+	default:
+		return channelMuted
+	}
+}
+
+func (zc *ZulipClient) queueUserLocalInfo(key networkid.PortalKey, info *bridgev2.UserLocalPortalInfo) bool {
+	if info == nil {
+		return true
+	}
+	return zc.UserLogin.QueueRemoteEvent(&simplevent.ChatResync{
+		EventMeta: simplevent.EventMeta{
+			Type:      bridgev2.RemoteEventChatResync,
+			PortalKey: key,
+		},
+		ChatInfo: &bridgev2.ChatInfo{UserLocal: info},
+	}).Success
+}
+
+// handleUserTopic updates the mute state of a topic room when the user
+// changes the visibility policy of the topic.
+func (zc *ZulipClient) handleUserTopic(evt *events.UserTopic) bool {
+	return zc.queueUserLocalInfo(
+		zc.makeTopicPortalKey(evt.StreamID, evt.TopicName),
+		zc.getTopicUserLocalInfo(evt.StreamID, evt.TopicName),
+	)
+}
+
+// handleSubscriptionUpdate updates the mute state and tags of channel rooms
+// when the user mutes or pins a channel. When topics are bridged as rooms,
+// muting the channel mutes all topic rooms that don't override it.
+func (zc *ZulipClient) handleSubscriptionUpdate(ctx context.Context, evt *events.Subscription) bool {
+	if evt.Op != events.SubscriptionOpUpdate {
+		return true
+	}
+	switch channels.SubscriptionProperty(evt.Property) {
+	case channels.SubscriptionPropertyPinToTop:
+		return zc.queueUserLocalInfo(zc.makeChannelPortalKey(evt.StreamID), zc.getChannelUserLocalInfo(evt.StreamID))
+	case channels.SubscriptionPropertyIsMuted:
+	default:
+		return true
+	}
+	if !zc.Main.Config.RoomPerTopic {
+		return zc.queueUserLocalInfo(zc.makeChannelPortalKey(evt.StreamID), zc.getChannelUserLocalInfo(evt.StreamID))
+	}
+	topicPortals, err := zc.Main.Bridge.GetChildPortals(ctx, zc.makeChannelPortalKey(evt.StreamID))
+	if err != nil {
+		zerolog.Ctx(ctx).Err(err).Int("stream_id", evt.StreamID).Msg("Failed to get topic portals of muted channel")
+		return false
+	}
+	for _, portal := range topicPortals {
+		_, streamID, topic, ok := zid.ParseTopicPortalID(portal.ID)
+		if !ok || streamID != evt.StreamID {
+			continue
+		}
+		if !zc.queueUserLocalInfo(portal.PortalKey, zc.getTopicUserLocalInfo(streamID, topic)) {
+			return false
+		}
+	}
+	return true
+}
+
+// getCommandTopic returns the topic a command acts on: the topic of a topic
+// room or thread that the command was sent in, or the topic given as the argument.
+func getCommandTopic(ce *commands.Event) (streamID int, topic string, ok bool) {
+	streamID, ok = getCommandChannel(ce)
+	if !ok {
+		return
+	}
+	if topic, ok = getThreadTopic(ce); ok {
+		return streamID, topic, true
+	} else if topic = strings.TrimSpace(ce.RawArgs); topic != "" {
+		return streamID, topic, true
+	}
+	ce.Reply("Send the command in a topic thread or give the name of the topic")
+	return 0, "", false
+}
+
+// getThreadTopic returns the topic of the topic room or thread that a command
+// was sent in.
+func getThreadTopic(ce *commands.Event) (string, bool) {
+	if _, topic, isTopicPortal := getPortalTopic(ce.Portal); isTopicPortal {
+		return topic, true
+	} else if ce.ReplyTo == "" {
+		return "", false
+	}
+	msg, err := ce.Bridge.DB.Message.GetPartByMXID(ce.Ctx, ce.ReplyTo)
+	if err != nil {
+		ce.Log.Err(err).Msg("Failed to get thread message")
+		return "", false
+	} else if msg == nil {
+		return "", false
+	}
+	return getMessageTopic(msg)
+}
+
+// getMessageTopic returns the topic that a bridged channel message or topic root is in.
+func getMessageTopic(msg *database.Message) (string, bool) {
+	for _, id := range []networkid.MessageID{msg.ID, msg.ThreadRoot} {
+		if _, topic, messageID := zid.ParseMessageID(id); id != "" && messageID == 0 {
+			return topic, true
+		}
+	}
+	if meta, ok := msg.Metadata.(*zid.MessageMetadata); ok && meta.ThreadTopic != "" {
+		return meta.ThreadTopic, true
+	}
+	return "", false
+}
+
+func makeTopicVisibilityCommand(name, description string, getPolicy func(channelMuted bool) zulip.VisibilityPolicy) *commands.FullHandler {
+	return &commands.FullHandler{
+		Func: func(ce *commands.Event) {
+			zc := getCommandClient(ce)
+			if zc == nil {
+				return
+			}
+			streamID, topic, ok := getCommandTopic(ce)
+			if !ok {
+				return
+			}
+			channelMuted, err := zc.isChannelMuted(ce.Ctx, streamID)
+			if err != nil {
+				ce.Reply("Failed to update topic: %v", err)
+				return
+			}
+			_, err = channels.NewService(zc.Client).UpdateUserTopic(ce.Ctx, streamID, topic, getPolicy(channelMuted))
+			if err != nil {
+				ce.Reply("Failed to update topic: %v", err)
+				return
+			} else if !zc.Main.Config.RoomPerTopic {
+				ce.Reply("Updated the topic on Zulip. Topics are bridged as threads, which can't be muted separately on Matrix.")
+				return
+			}
+			ce.React("✅")
+		},
+		Name: name,
+		Help: commands.HelpMeta{
+			Section:     HelpSectionZulip,
+			Description: description,
+			Args:        "[_topic_]",
+		},
+		RequiresLogin:  true,
+		RequiresPortal: true,
+	}
+}
+
+var cmdMuteTopic = makeTopicVisibilityCommand("mute-topic", "Mute the current topic", func(bool) zulip.VisibilityPolicy {
+	return zulip.VisibilityPolicyMuted
+})
+
+var cmdUnmuteTopic = makeTopicVisibilityCommand("unmute-topic", "Unmute the current topic", func(channelMuted bool) zulip.VisibilityPolicy {
+	if channelMuted {
+		return zulip.VisibilityPolicyUnmuted
+	}
+	return zulip.VisibilityPolicyInherit
+})
+
+var cmdFollowTopic = makeTopicVisibilityCommand("follow-topic", "Follow the current topic to be notified about all messages in it", func(bool) zulip.VisibilityPolicy {
+	return zulip.VisibilityPolicyFollowed
+})
+
+var cmdUnfollowTopic = makeTopicVisibilityCommand("unfollow-topic", "Stop following the current topic", func(bool) zulip.VisibilityPolicy {
+	return zulip.VisibilityPolicyInherit
+})
+
+func makeChannelMuteCommand(name, description string, muted bool) *commands.FullHandler {
+	return &commands.FullHandler{
+		Func: func(ce *commands.Event) {
+			zc := getCommandClient(ce)
+			if zc == nil {
+				return
+			}
+			streamID, ok := getCommandChannel(ce)
+			if !ok {
+				return
+			}
+			_, err := channels.NewService(zc.Client).UpdateSubscriptionSettings(ce.Ctx, []channels.SubscriptionSetting{{
+				StreamID: streamID,
+				Property: channels.SubscriptionPropertyIsMuted,
+				Value:    muted,
+			}})
+			if err != nil {
+				ce.Reply("Failed to update channel: %v", err)
+				return
+			}
+			ce.React("✅")
+		},
+		Name: name,
+		Help: commands.HelpMeta{
+			Section:     HelpSectionZulip,
+			Description: description,
+		},
+		RequiresLogin:  true,
+		RequiresPortal: true,
+	}
+}
+
+var cmdMuteChannel = makeChannelMuteCommand("mute-channel", "Mute the current channel", true)
+var cmdUnmuteChannel = makeChannelMuteCommand("unmute-channel", "Unmute the current channel", false)
@@ -0,0 +1,76 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maunium.net/go/mautrix/event"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/channels"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/realtime"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/realtime/events"
+)
+
+// loadTestRealmState loads the realm state from the JSON of a queue registration.
+func loadTestRealmState(t *testing.T, zc *ZulipClient, state string) {
+	var resp realtime.RegisterEventQueueResponse
+	require.NoError(t, json.Unmarshal([]byte(`{"result": "success", "msg": "", `+state[1:]), &resp))
+	zc.Realm.Load(&resp)
+}
+
+func TestUserLocalInfoNeedsLoadedState(t *testing.T) {
+	zc := newTestClient(t, Config{RoomPerTopic: true}, http.NotFoundHandler())
+	// The subscription is known from an event, but the queue was resumed
+	require.NoError(t, zc.Realm.HandleEvent(&events.Subscription{
+		Op: events.SubscriptionOpAdd,
+		SubscriptionData: events.SubscriptionData{
+			Subscriptions: []channels.SubscribedChannel{{StreamID: 5, IsMuted: true}},
+		},
+	}))
+	assert.Nil(t, zc.getChannelUserLocalInfo(5))
+	assert.Nil(t, zc.getTopicUserLocalInfo(5, "Deploy"))
+
+	loadTestRealmState(t, zc, fmt.Sprintf(
+		`{"subscriptions": [{"stream_id": 5, "is_muted": true, "pin_to_top": true}], "user_topics": [{"stream_id": 5, "topic_name": "deploy", "visibility_policy": %d}]}`,
+		zulip.VisibilityPolicyUnmuted,
+	))
+	channelInfo := zc.getChannelUserLocalInfo(5)
+	require.NotNil(t, channelInfo)
+	assert.Equal(t, event.RoomTagFavourite, *channelInfo.Tag)
+	topicInfo := zc.getTopicUserLocalInfo(5, "Deploy")
+	require.NotNil(t, topicInfo)
+	assert.Equal(t, event.RoomTag(""), *topicInfo.Tag)
+	topicInfo = zc.getTopicUserLocalInfo(5, "Other")
+	require.NotNil(t, topicInfo)
+	assert.Equal(t, event.RoomTagLowPriority, *topicInfo.Tag)
+}
+
+func TestIsChannelMutedFallback(t *testing.T) {
+	requests := 0
+	zc := newTestClient(t, Config{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		require.Equal(t, "/api/v1/users/me/subscriptions", r.URL.Path)
+		_, _ = w.Write([]byte(`{"result": "success", "msg": "", "subscriptions": [{"stream_id": 5, "is_muted": true}]}`))
+	}))
+	ctx := context.Background()
+
+	muted, err := zc.isChannelMuted(ctx, 5)
+	require.NoError(t, err)
+	assert.True(t, muted)
+	muted, err = zc.isChannelMuted(ctx, 6)
+	require.NoError(t, err)
+	assert.False(t, muted)
+	assert.Equal(t, 2, requests)
+
+	loadTestRealmState(t, zc, `{"subscriptions": [{"stream_id": 5, "is_muted": false}]}`)
+	muted, err = zc.isChannelMuted(ctx, 5)
+	require.NoError(t, err)
+	assert.False(t, muted)
+	assert.Equal(t, 2, requests)
+}
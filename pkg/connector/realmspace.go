@@ -0,0 +1,129 @@
+package connector
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/rs/zerolog"
+	"go.mau.fi/util/ptr"
+	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/database"
+	"maunium.net/go/mautrix/bridgev2/networkid"
+	"maunium.net/go/mautrix/bridgev2/simplevent"
+	"maunium.net/go/mautrix/event"
+
+	"go.mau.fi/mautrix-zulip/pkg/zid"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/org"
+)
+
+// dmSpaceName is the name of the space that DMs are grouped in when the dm_space option is enabled.
+const dmSpaceName = "Direct messages"
+
+func (zc *ZulipClient) realmSpaceID() networkid.PortalID {
+	return zid.MakeRealmSpacePortalID(zc.realm)
+}
+
+func (zc *ZulipClient) realmSpaceKey() (pk networkid.PortalKey) {
+	if zc.UserLogin.Bridge.Config.SplitPortals {
+		pk.Receiver = zc.UserLogin.ID
+	}
+	pk.ID = zc.realmSpaceID()
+	return pk
+}
+
+// dmParentID returns the space that DM portals are put in.
+func (zc *ZulipClient) dmParentID() networkid.PortalID {
+	if zc.Main.Config.DMSpace {
+		return zid.MakeDMSpacePortalID(zc.realm, zc.ownUserID)
+	}
+	return zc.realmSpaceID()
+}
+
+func (zc *ZulipClient) getRealmSpaceInfo(ctx context.Context) (*bridgev2.ChatInfo, error) {
+	settings, err := org.NewService(zc.Client).GetServerSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	meta := zc.UserLogin.Metadata.(*zid.UserLoginMetadata)
+	meta.RealmName = settings.RealmName
+	meta.RealmIcon = settings.RealmIcon
+	return zc.wrapRealmSpaceInfo(settings), nil
+}
+
+func (zc *ZulipClient) wrapRealmSpaceInfo(settings *org.GetServerSettingsResponse) *bridgev2.ChatInfo {
+	info := &bridgev2.ChatInfo{
+		Name:    &settings.RealmName,
+		Topic:   &settings.RealmDescription,
+		Members: zc.makeSpaceMembers(),
+		Type:    ptr.Ptr(database.RoomTypeSpace),
+	}
+	if settings.RealmIcon != "" {
+		iconURL := zc.makeAbsoluteURL(settings.RealmIcon)
+		info.Avatar = &bridgev2.Avatar{
+			ID: networkid.AvatarID(iconURL),
+			Get: func(ctx context.Context) ([]byte, error) {
+				return downloadAvatar(ctx, iconURL)
+			},
+		}
+	}
+	return info
+}
+
+func (zc *ZulipClient) wrapDMSpaceInfo() *bridgev2.ChatInfo {
+	return &bridgev2.ChatInfo{
+		Name:     ptr.Ptr(dmSpaceName),
+		Members:  zc.makeSpaceMembers(),
+		Type:     ptr.Ptr(database.RoomTypeSpace),
+		ParentID: ptr.Ptr(zc.realmSpaceID()),
+	}
+}
+
+// makeSpaceMembers returns the member list of spaces, which only contains the
+// logged in user. Spaces are only used for grouping rooms, so other users
+// don't need to be in them.
+func (zc *ZulipClient) makeSpaceMembers() *bridgev2.ChatMemberList {
+	return &bridgev2.ChatMemberList{
+		MemberMap: map[networkid.UserID]bridgev2.ChatMember{
+			zid.MakeUserID(zc.realm, zc.ownUserID): {
+				EventSender: zc.makeEventSender(zc.ownUserID),
+				Membership:  event.MembershipJoin,
+			},
+		},
+	}
+}
+
+// updateRealmSpace resyncs the realm space if the name or icon of the realm
+// changed since they were last seen.
+func (zc *ZulipClient) updateRealmSpace(ctx context.Context, settings *org.GetServerSettingsResponse) {
+	meta := zc.UserLogin.Metadata.(*zid.UserLoginMetadata)
+	if meta.RealmName == settings.RealmName && meta.RealmIcon == settings.RealmIcon {
+		return
+	}
+	zerolog.Ctx(ctx).Debug().
+		Str("realm_name", settings.RealmName).
+		Str("realm_icon", settings.RealmIcon).
+		Msg("Realm name or icon changed")
+	meta.RealmName = settings.RealmName
+	meta.RealmIcon = settings.RealmIcon
+	zc.UserLogin.QueueRemoteEvent(&simplevent.ChatResync{
+		EventMeta: simplevent.EventMeta{
+			Type:      bridgev2.RemoteEventChatResync,
+			PortalKey: zc.realmSpaceKey(),
+		},
+		ChatInfo: zc.wrapRealmSpaceInfo(settings),
+	})
+}
+
+// makeAbsoluteURL resolves a path on the Zulip server into a full URL.
+func (zc *ZulipClient) makeAbsoluteURL(ref string) string {
+	meta := zc.UserLogin.Metadata.(*zid.UserLoginMetadata)
+	base, err := url.Parse(meta.URL)
+	if err != nil {
+		return ref
+	}
+	parsed, err := base.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return parsed.String()
+}
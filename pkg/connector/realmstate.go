@@ -0,0 +1,318 @@
+package connector
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/channels"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/realtime"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/realtime/events"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/scheduledmessages"
+)
+
+// realmInitialStateTypes are the event types whose initial state is fetched when registering a queue.
+var realmInitialStateTypes = []events.EventType{
+	events.RealmType,
+	events.RealmUserType,
+	events.SubscriptionType,
+	events.RealmEmojiType,
+	events.CustomProfileFieldsType,
+	events.UserSettingsType,
+	events.UserTopicType,
+	events.ScheduledMessagesType,
+	events.AlertWordsType,
+}
+
+// RealmState is an in-memory copy of the realm data fetched when registering
+// the event queue, which is kept up to date by events from the queue.
+//
+// The state is empty until a queue is registered. A queue resumed after a
+// restart is replaced with a new one once the missed events have been
+// handled, so callers must fall back to the API until then.
+type RealmState struct {
+	lock          sync.RWMutex
+	loaded        bool
+	usersLoaded   bool
+	users         map[int]*events.Person
+	subscriptions map[int]*channels.SubscribedChannel
+	emoji         map[string]events.RealmEmojiItem
+	profileFields []events.CustomProfileField
+	userSettings  *events.UserSettings
+	userTopics    map[userTopicKey]zulip.VisibilityPolicy
+	scheduled     map[int]scheduledmessages.ScheduledMessage
+}
+
+type userTopicKey struct {
+	streamID int
+	topic    string
+}
+
+func newRealmState() *RealmState {
+	return &RealmState{
+		users:         make(map[int]*events.Person),
+		subscriptions: make(map[int]*channels.SubscribedChannel),
+		emoji:         make(map[string]events.RealmEmojiItem),
+		userTopics:    make(map[userTopicKey]zulip.VisibilityPolicy),
+		scheduled:     make(map[int]scheduledmessages.ScheduledMessage),
+	}
+}
+
+// Load replaces the state with the initial state from a queue registration.
+func (rs *RealmState) Load(resp *realtime.RegisterEventQueueResponse) {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	rs.users = make(map[int]*events.Person, len(resp.RealmUsers)+len(resp.RealmNonActiveUsers)+len(resp.CrossRealmBots))
+	for _, list := range [][]events.Person{resp.RealmUsers, resp.RealmNonActiveUsers, resp.CrossRealmBots} {
+		for _, person := range list {
+			rs.users[person.UserID] = &person
+		}
+	}
+	rs.subscriptions = make(map[int]*channels.SubscribedChannel, len(resp.Subscriptions))
+	for _, sub := range resp.Subscriptions {
+		rs.subscriptions[sub.StreamID] = &sub
+	}
+	rs.emoji = resp.RealmEmoji
+	if rs.emoji == nil {
+		rs.emoji = make(map[string]events.RealmEmojiItem)
+	}
+	rs.profileFields = resp.CustomProfileFields
+	rs.userSettings = resp.UserSettings
+	rs.userTopics = make(map[userTopicKey]zulip.VisibilityPolicy, len(resp.UserTopics))
+	for _, userTopic := range resp.UserTopics {
+		rs.setUserTopic(userTopic)
+	}
+	rs.scheduled = make(map[int]scheduledmessages.ScheduledMessage, len(resp.ScheduledMessages))
+	for _, msg := range resp.ScheduledMessages {
+		rs.scheduled[msg.ScheduledMessageID] = msg
+	}
+	rs.loaded = true
+	rs.usersLoaded = true
+}
+
+// IsLoaded returns whether the state has been loaded from a queue registration.
+func (rs *RealmState) IsLoaded() bool {
+	rs.lock.RLock()
+	defer rs.lock.RUnlock()
+	return rs.loaded
+}
+
+// LoadUsers replaces the cached user directory with users fetched from the
+// API, so that it can be used before a queue has been registered. Users that
+// are already loaded are kept, as they're updated by events.
+func (rs *RealmState) LoadUsers(people []events.Person) {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	if rs.usersLoaded {
+		return
+	}
+	rs.users = make(map[int]*events.Person, len(people))
+	for _, person := range people {
+		rs.users[person.UserID] = &person
+	}
+	rs.usersLoaded = true
+}
+
+// GetUser returns a copy of the cached user.
+func (rs *RealmState) GetUser(userID int) (events.Person, bool) {
+	rs.lock.RLock()
+	defer rs.lock.RUnlock()
+	person, ok := rs.users[userID]
+	if !rs.usersLoaded || !ok {
+		return events.Person{}, false
+	}
+	return *person, true
+}
+
+// GetUsers returns copies of all cached users, including deactivated users
+// and bots. ok is false if the users haven't been loaded yet.
+func (rs *RealmState) GetUsers() (users []events.Person, ok bool) {
+	rs.lock.RLock()
+	defer rs.lock.RUnlock()
+	if !rs.usersLoaded {
+		return nil, false
+	}
+	users = make([]events.Person, 0, len(rs.users))
+	for _, person := range rs.users {
+		users = append(users, *person)
+	}
+	return users, true
+}
+
+// GetSubscription returns a copy of a cached channel subscription.
+func (rs *RealmState) GetSubscription(streamID int) (channels.SubscribedChannel, bool) {
+	rs.lock.RLock()
+	defer rs.lock.RUnlock()
+	sub, ok := rs.subscriptions[streamID]
+	if !rs.loaded || !ok {
+		return channels.SubscribedChannel{}, false
+	}
+	out := *sub
+	out.Subscribers = slices.Clone(sub.Subscribers)
+	return out, true
+}
+
+// GetEmoji returns a custom emoji by its ID.
+func (rs *RealmState) GetEmoji(id string) (events.RealmEmojiItem, bool) {
+	rs.lock.RLock()
+	defer rs.lock.RUnlock()
+	emoji, ok := rs.emoji[id]
+	return emoji, ok
+}
+
+// GetCustomProfileFields returns the custom profile fields defined in the realm.
+func (rs *RealmState) GetCustomProfileFields() []events.CustomProfileField {
+	rs.lock.RLock()
+	defer rs.lock.RUnlock()
+	return slices.Clone(rs.profileFields)
+}
+
+// GetUserSettings returns a copy of the settings of the logged in user.
+func (rs *RealmState) GetUserSettings() (events.UserSettings, bool) {
+	rs.lock.RLock()
+	defer rs.lock.RUnlock()
+	if rs.userSettings == nil {
+		return events.UserSettings{}, false
+	}
+	return *rs.userSettings, true
+}
+
+// GetTopicVisibility returns the visibility policy the user has set for a
+// topic. ok is false if the state hasn't been loaded, as the policies can't
+// be fetched separately.
+func (rs *RealmState) GetTopicVisibility(streamID int, topic string) (policy zulip.VisibilityPolicy, ok bool) {
+	rs.lock.RLock()
+	defer rs.lock.RUnlock()
+	if !rs.loaded {
+		return zulip.VisibilityPolicyInherit, false
+	}
+	return rs.userTopics[userTopicKey{streamID, strings.ToLower(topic)}], true
+}
+
+// GetScheduledMessages returns the messages the user has scheduled, sorted by delivery time.
+func (rs *RealmState) GetScheduledMessages() ([]scheduledmessages.ScheduledMessage, bool) {
+	rs.lock.RLock()
+	defer rs.lock.RUnlock()
+	if !rs.loaded {
+		return nil, false
+	}
+	msgs := slices.Collect(maps.Values(rs.scheduled))
+	slices.SortFunc(msgs, func(a, b scheduledmessages.ScheduledMessage) int {
+		return cmp.Or(
+			cmp.Compare(a.ScheduledDeliveryTimestamp, b.ScheduledDeliveryTimestamp),
+			cmp.Compare(a.ScheduledMessageID, b.ScheduledMessageID),
+		)
+	})
+	return msgs, true
+}
+
+// HandleEvent applies a live event to the state. Events that don't affect the state are ignored.
+func (rs *RealmState) HandleEvent(rawEvt events.Event) error {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	switch evt := rawEvt.(type) {
+	case *events.RealmUser:
+		return rs.handleRealmUser(evt)
+	case *events.Subscription:
+		return rs.handleSubscription(evt)
+	case *events.Stream:
+		if evt.Op == events.StreamOpUpdate {
+			if sub, ok := rs.subscriptions[evt.StreamID]; ok {
+				return evt.ApplyTo(sub)
+			}
+		}
+	case *events.RealmEmoji:
+		if evt.RealmEmoji != nil {
+			rs.emoji = evt.RealmEmoji
+		}
+	case *events.UserTopic:
+		rs.setUserTopic(evt.UserTopicData)
+	case *events.ScheduledMessages:
+		rs.handleScheduledMessages(evt)
+	case *events.CustomProfileFields:
+		rs.profileFields = evt.Fields
+	case *events.UserSettingsEvent:
+		if rs.userSettings != nil {
+			return evt.ApplyTo(rs.userSettings)
+		}
+	}
+	return nil
+}
+
+func (rs *RealmState) handleRealmUser(evt *events.RealmUser) error {
+	switch evt.Op {
+	case events.RealmUserOpAdd:
+		rs.users[evt.Person.UserID] = &evt.Person
+	case events.RealmUserOpRemove:
+		delete(rs.users, evt.Person.UserID)
+	case events.RealmUserOpUpdate:
+		if person, ok := rs.users[evt.Person.UserID]; ok {
+			return evt.ApplyTo(person)
+		}
+	}
+	return nil
+}
+
+func (rs *RealmState) setUserTopic(userTopic events.UserTopicData) {
+	// Topic names are case-insensitive
+	key := userTopicKey{userTopic.StreamID, strings.ToLower(userTopic.TopicName)}
+	if userTopic.VisibilityPolicy == zulip.VisibilityPolicyInherit {
+		delete(rs.userTopics, key)
+	} else {
+		rs.userTopics[key] = userTopic.VisibilityPolicy
+	}
+}
+
+func (rs *RealmState) handleScheduledMessages(evt *events.ScheduledMessages) {
+	switch evt.Op {
+	case events.ScheduledMessagesOpAdd:
+		for _, msg := range evt.ScheduledMessages {
+			rs.scheduled[msg.ScheduledMessageID] = msg
+		}
+	case events.ScheduledMessagesOpUpdate:
+		if evt.ScheduledMessage != nil {
+			rs.scheduled[evt.ScheduledMessage.ScheduledMessageID] = *evt.ScheduledMessage
+		}
+	case events.ScheduledMessagesOpRemove:
+		delete(rs.scheduled, evt.ScheduledMessageID)
+	}
+}
+
+func (rs *RealmState) handleSubscription(evt *events.Subscription) error {
+	switch evt.Op {
+	case events.SubscriptionOpAdd:
+		for _, sub := range evt.Subscriptions {
+			rs.subscriptions[sub.StreamID] = &sub
+		}
+	case events.SubscriptionOpRemove:
+		for _, sub := range evt.Subscriptions {
+			delete(rs.subscriptions, sub.StreamID)
+		}
+	case events.SubscriptionOpUpdate:
+		if sub, ok := rs.subscriptions[evt.StreamID]; ok {
+			return evt.ApplyTo(sub)
+		}
+	case events.SubscriptionOpPeerAdd:
+		for _, streamID := range evt.StreamIDs {
+			if sub, ok := rs.subscriptions[streamID]; ok {
+				for _, userID := range evt.UserIDs {
+					if !slices.Contains(sub.Subscribers, userID) {
+						sub.Subscribers = append(sub.Subscribers, userID)
+					}
+				}
+			}
+		}
+	case events.SubscriptionOpPeerRemove:
+		for _, streamID := range evt.StreamIDs {
+			if sub, ok := rs.subscriptions[streamID]; ok {
+				sub.Subscribers = slices.DeleteFunc(sub.Subscribers, func(userID int) bool {
+					return slices.Contains(evt.UserIDs, userID)
+				})
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"maunium.net/go/mautrix/bridgev2/commands"
+
+	"go.mau.fi/mautrix-zulip/pkg/zid"
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/messages/recipient"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/scheduledmessages"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/users"
+)
+
+// scheduleTimeFormats are the accepted formats for absolute delivery times.
+// Times without a zone are in the user's Zulip time zone.
+var scheduleTimeFormats = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+}
+
+// displayTimeFormat is the format of times in command replies.
+const displayTimeFormat = "Mon, 2 Jan 2006 15:04 MST"
+
+var cmdSchedule = &commands.FullHandler{
+	Func: fnSchedule,
+	Name: "schedule",
+	Help: commands.HelpMeta{
+		Section: HelpSectionZulip,
+		Description: "Schedule a message to be sent to the current chat or thread later. " +
+			"The time can be `YYYY-MM-DDTHH:MM` in your Zulip time zone (UTC if it isn't set), `HH:MM` for the next time it's that time, or a duration like `+1h30m`.",
+		Args: "<_time_> <_message_>",
+	},
+	RequiresLogin:  true,
+	RequiresPortal: true,
+}
+
+func fnSchedule(ce *commands.Event) {
+	zc := getCommandClient(ce)
+	if zc == nil {
+		return
+	}
+	if !zc.Client.SupportsFeature(zulip.FeatureLevelScheduledMessages) {
+		ce.Reply("This Zulip server doesn't support scheduled messages")
+		return
+	}
+	timeArg, content, _ := strings.Cut(strings.TrimSpace(ce.RawArgs), " ")
+	content = strings.TrimSpace(content)
+	if timeArg == "" || content == "" {
+		ce.Reply("Usage: `$cmdprefix schedule <time> <message>`")
+		return
+	}
+	loc, tzKnown := zc.getUserTimezone(ce.Ctx)
+	deliverAt, err := parseScheduleTime(timeArg, time.Now().In(loc))
+	if err != nil {
+		ce.Reply("Invalid time: %v", err)
+		return
+	} else if !deliverAt.After(time.Now()) {
+		ce.Reply("The time must be in the future")
+		return
+	}
+	_, streamID, userIDs, err := zid.ParsePortalID(ce.Portal.ID)
+	if err != nil {
+		ce.Reply("This command can only be used in Zulip chats")
+		return
+	}
+	var to recipient.Recipient = recipient.ToUsers(userIDs)
+	var opts []scheduledmessages.CreateScheduledMessageOption
+	if streamID != 0 {
+		to = recipient.ToChannel(streamID)
+		topic, ok := getThreadTopic(ce)
+		if !ok {
+			topic = getDefaultTopic(ce.Portal)
+		}
+		if topic != "" {
+			opts = append(opts, scheduledmessages.ToTopic(topic))
+		} else if !zc.Client.SupportsFeature(zulip.FeatureLevelEmptyTopicName) {
+			ce.Reply("Send the command in a topic thread or set a default topic with `$cmdprefix default-topic <name>`")
+			return
+		}
+	}
+	resp, err := scheduledmessages.NewService(zc.Client).CreateScheduledMessage(ce.Ctx, to, content, deliverAt, opts...)
+	if err != nil {
+		ce.Reply("Failed to schedule message: %v", err)
+		return
+	}
+	reply := fmt.Sprintf("Scheduled message `%d` for %s", resp.ScheduledMessageID, deliverAt.In(loc).Format(displayTimeFormat))
+	if !tzKnown && !hasExplicitZone(timeArg) {
+		reply += ". Your Zulip time zone isn't known, so the time was read as UTC. " +
+			"Use `+<duration>` or a time with an offset like `2006-01-02T15:04:05+02:00` to avoid this."
+	}
+	ce.Reply(reply)
+}
+
+// getUserTimezone returns the time zone set in the user's Zulip settings.
+// The own user's profile is used if the realm state isn't loaded, e.g. right
+// after resuming a queue. ok is false if the time zone isn't set or can't be
+// found, in which case UTC is returned.
+func (zc *ZulipClient) getUserTimezone(ctx context.Context) (loc *time.Location, ok bool) {
+	var name string
+	if settings, loaded := zc.Realm.GetUserSettings(); loaded {
+		name = settings.Timezone
+	} else if person, found := zc.Realm.GetUser(zc.ownUserID); found {
+		name = person.Timezone
+	} else if me, err := users.NewService(zc.Client).GetUserMe(ctx); err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Msg("Failed to get own user to find time zone")
+	} else {
+		name = me.Timezone
+	}
+	if name == "" {
+		return time.UTC, false
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Str("timezone", name).Msg("Failed to load time zone")
+		return time.UTC, false
+	}
+	return loc, true
+}
+
+// hasExplicitZone checks if a delivery time doesn't depend on the user's time zone.
+func hasExplicitZone(input string) bool {
+	if strings.HasPrefix(input, "+") {
+		return true
+	}
+	_, err := time.Parse(time.RFC3339, input)
+	return err == nil
+}
+
+// parseScheduleTime parses a delivery time relative to now, whose location is
+// used for times without a zone.
+func parseScheduleTime(input string, now time.Time) (time.Time, error) {
+	if duration, ok := strings.CutPrefix(input, "+"); ok {
+		d, err := time.ParseDuration(duration)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return now.Add(d), nil
+	}
+	if clock, err := time.ParseInLocation("15:04", input, now.Location()); err == nil {
+		next := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		return next, nil
+	}
+	for _, format := range scheduleTimeFormats {
+		if t, err := time.ParseInLocation(format, input, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("expected `YYYY-MM-DDTHH:MM`, `HH:MM` or `+<duration>`, got `%s`", input)
+}
+
+var cmdListScheduled = &commands.FullHandler{
+	Func: fnListScheduled,
+	Name: "list-scheduled",
+	Help: commands.HelpMeta{
+		Section:     HelpSectionZulip,
+		Description: "List your scheduled Zulip messages",
+	},
+	RequiresLogin: true,
+}
+
+func fnListScheduled(ce *commands.Event) {
+	zc := getCommandClient(ce)
+	if zc == nil {
+		return
+	}
+	msgs, ok := zc.Realm.GetScheduledMessages()
+	if !ok {
+		resp, err := scheduledmessages.NewService(zc.Client).GetScheduledMessages(ce.Ctx)
+		if err != nil {
+			ce.Reply("Failed to get scheduled messages: %v", err)
+			return
+		}
+		msgs = resp.ScheduledMessages
+	}
+	if len(msgs) == 0 {
+		ce.Reply("You don't have any scheduled messages")
+		return
+	}
+	loc, _ := zc.getUserTimezone(ce.Ctx)
+	lines := make([]string, 0, len(msgs)+1)
+	lines = append(lines, "Your scheduled messages:")
+	for _, msg := range msgs {
+		line := fmt.Sprintf(
+			"* `%d` at %s to %s: %s",
+			msg.ScheduledMessageID,
+			time.Unix(msg.ScheduledDeliveryTimestamp, 0).In(loc).Format(displayTimeFormat),
+			zc.describeScheduledRecipient(&msg),
+			makeExcerpt(msg.Content),
+		)
+		if msg.Failed {
+			line += " (**failed to send**)"
+		}
+		lines = append(lines, line)
+	}
+	ce.Reply(strings.Join(lines, "\n"))
+}
+
+// describeScheduledRecipient returns the channel and topic, or the other
+// participants of a DM, that a scheduled message will be sent to.
+func (zc *ZulipClient) describeScheduledRecipient(msg *scheduledmessages.ScheduledMessage) string {
+	if msg.Type == scheduledmessages.TypeChannel {
+		channelName := strconv.Itoa(msg.To.StreamID)
+		if sub, ok := zc.Realm.GetSubscription(msg.To.StreamID); ok {
+			channelName = sub.Name
+		}
+		return fmt.Sprintf("#%s > %s", channelName, formatTopicName(msg.Topic))
+	}
+	var names []string
+	for _, userID := range msg.To.UserIDs {
+		if userID == zc.ownUserID {
+			continue
+		} else if person, ok := zc.Realm.GetUser(userID); ok {
+			names = append(names, person.FullName)
+		} else {
+			names = append(names, strconv.Itoa(userID))
+		}
+	}
+	if len(names) == 0 {
+		return "yourself"
+	}
+	return strings.Join(names, ", ")
+}
+
+var cmdCancelScheduled = &commands.FullHandler{
+	Func: fnCancelScheduled,
+	Name: "cancel-scheduled",
+	Help: commands.HelpMeta{
+		Section:     HelpSectionZulip,
+		Description: "Cancel a scheduled Zulip message",
+		Args:        "<_id_>",
+	},
+	RequiresLogin: true,
+}
+
+func fnCancelScheduled(ce *commands.Event) {
+	zc := getCommandClient(ce)
+	if zc == nil {
+		return
+	}
+	if len(ce.Args) != 1 {
+		ce.Reply("Usage: `$cmdprefix cancel-scheduled <id>` (see `$cmdprefix list-scheduled` for IDs)")
+		return
+	}
+	id, err := strconv.Atoi(strings.Trim(ce.Args[0], "`#"))
+	if err != nil {
+		ce.Reply("Invalid scheduled message ID `%s`", ce.Args[0])
+		return
+	}
+	_, err = scheduledmessages.NewService(zc.Client).DeleteScheduledMessage(ce.Ctx, id)
+	if err != nil {
+		ce.Reply("Failed to cancel scheduled message: %v", err)
+		return
+	}
+	ce.React("✅")
+}
@@ -0,0 +1,46 @@
+package connector
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetUserTimezone(t *testing.T) {
+	timezone := "Europe/Helsinki"
+	requests := 0
+	zc := newTestClient(t, Config{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		require.Equal(t, "/api/v1/users/me", r.URL.Path)
+		_, _ = w.Write([]byte(`{"result": "success", "msg": "", "user_id": 10, "timezone": "` + timezone + `"}`))
+	}))
+	ctx := context.Background()
+
+	loc, ok := zc.getUserTimezone(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "Europe/Helsinki", loc.String())
+	assert.Equal(t, 1, requests)
+
+	timezone = ""
+	loc, ok = zc.getUserTimezone(ctx)
+	assert.False(t, ok)
+	assert.Equal(t, time.UTC, loc)
+
+	loadTestRealmState(t, zc, `{"user_settings": {"timezone": "America/New_York"}}`)
+	loc, ok = zc.getUserTimezone(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "America/New_York", loc.String())
+	assert.Equal(t, 2, requests)
+}
+
+func TestHasExplicitZone(t *testing.T) {
+	assert.True(t, hasExplicitZone("+1h30m"))
+	assert.True(t, hasExplicitZone("2026-10-20T09:00:00+03:00"))
+	assert.True(t, hasExplicitZone("2026-10-20T09:00:00Z"))
+	assert.False(t, hasExplicitZone("2026-10-20T09:00"))
+	assert.False(t, hasExplicitZone("09:00"))
+}
@@ -0,0 +1,87 @@
+package connector
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"maunium.net/go/mautrix/bridgev2/commands"
+
+	"go.mau.fi/mautrix-zulip/pkg/zid"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/messages"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/narrow"
+)
+
+// maxSearchMatches is the number of messages returned by the search command.
+const maxSearchMatches = 10
+
+var cmdSearchMessages = &commands.FullHandler{
+	Func: fnSearchMessages,
+	Name: "search-messages",
+	Help: commands.HelpMeta{
+		Section:     HelpSectionZulip,
+		Description: "Search messages in the current chat, or in the current topic when used in a thread",
+		Args:        "<_query_>",
+	},
+	RequiresLogin:  true,
+	RequiresPortal: true,
+}
+
+func fnSearchMessages(ce *commands.Event) {
+	_, streamID, userIDs, err := zid.ParsePortalID(ce.Portal.ID)
+	if err != nil {
+		ce.Reply("This command can only be used in Zulip chats")
+		return
+	}
+	zc := getCommandClient(ce)
+	if zc == nil {
+		return
+	}
+	query := strings.TrimSpace(ce.RawArgs)
+	if query == "" {
+		ce.Reply("Usage: `$cmdprefix search-messages <query>`")
+		return
+	}
+	filter := narrow.NewFilter()
+	if streamID != 0 {
+		filter = filter.Add(narrow.New(narrow.Channel, streamID))
+		if topic, ok := getThreadTopic(ce); ok {
+			filter = filter.Add(narrow.New(narrow.Topic, topic))
+		}
+	} else {
+		filter = filter.Add(narrow.New(narrow.Dm, userIDs))
+	}
+	filter = filter.Add(narrow.New(narrow.Search, query))
+	resp, err := messages.NewService(zc.Client).GetMessages(
+		ce.Ctx,
+		messages.Anchor("newest"),
+		messages.NumBefore(maxSearchMatches),
+		messages.NumAfter(0),
+		messages.NarrowMessage(filter),
+		messages.ApplyMarkdownMessage(false),
+	)
+	if err != nil {
+		ce.Reply("Failed to search messages: %v", err)
+		return
+	} else if len(resp.Messages) == 0 {
+		ce.Reply("No messages found")
+		return
+	}
+	loc, _ := zc.getUserTimezone(ce.Ctx)
+	lines := make([]string, 0, len(resp.Messages)+1)
+	lines = append(lines, fmt.Sprintf("Latest %d matching messages:", len(resp.Messages)))
+	// Messages are returned oldest first
+	for i := len(resp.Messages) - 1; i >= 0; i-- {
+		msg := &resp.Messages[i]
+		sentAt := time.Unix(int64(msg.Timestamp), 0).In(loc).Format(displayTimeFormat)
+		if link := zc.getBridgedPermalink(ce.Ctx, msg.ID); link != "" {
+			lines = append(lines, fmt.Sprintf("* [%s](%s) by **%s**", sentAt, link, msg.SenderFullName))
+		} else {
+			lines = append(lines, fmt.Sprintf(
+				"* [%s](%s) by **%s** (not bridged)\n  > %s",
+				sentAt, zc.getZulipPermalink(msg), msg.SenderFullName, makeExcerpt(msg.Content),
+			))
+		}
+	}
+	ce.Reply(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,210 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"go.mau.fi/util/variationselector"
+	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/commands"
+	"maunium.net/go/mautrix/bridgev2/database"
+	"maunium.net/go/mautrix/bridgev2/networkid"
+	"maunium.net/go/mautrix/bridgev2/simplevent"
+	"maunium.net/go/mautrix/event"
+
+	"go.mau.fi/mautrix-zulip/pkg/zid"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/messages"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/narrow"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/realtime/events"
+)
+
+var _ bridgev2.ReactionHandlingNetworkAPI = (*ZulipClient)(nil)
+
+// Starred messages are bridged as a ⭐ reaction from the user's double puppet.
+// The emoji ID is separate from Zulip's own star emoji reaction, which other
+// users can see. Only one of them is bridged if the user has both, as Matrix
+// doesn't allow the same reaction twice.
+const (
+	starEmoji                          = "⭐"
+	starEmojiID      networkid.EmojiID = "starred"
+	zulipStarEmojiID networkid.EmojiID = "2b50"
+)
+
+// Defaults and limits for the number of messages listed by the starred command.
+const (
+	defaultStarredListCount = 20
+	maxStarredListCount     = 100
+)
+
+// matrixOnlyEmojiIDPrefix is prepended to the Matrix key for the emoji IDs
+// of other reactions. Zulip only accepts reactions with the emoji's name,
+// which isn't known for Matrix reactions, so they're kept on Matrix without
+// sending anything to Zulip.
+const matrixOnlyEmojiIDPrefix = "matrix:"
+
+func (zc *ZulipClient) PreHandleMatrixReaction(ctx context.Context, msg *bridgev2.MatrixReaction) (bridgev2.MatrixReactionPreResponse, error) {
+	resp := bridgev2.MatrixReactionPreResponse{
+		SenderID: zid.MakeUserID(zc.realm, zc.ownUserID),
+		EmojiID:  starEmojiID,
+		Emoji:    starEmoji,
+	}
+	if key := variationselector.Remove(msg.Content.RelatesTo.Key); key != starEmoji {
+		resp.EmojiID = networkid.EmojiID(matrixOnlyEmojiIDPrefix + key)
+		resp.Emoji = msg.Content.RelatesTo.Key
+	}
+	return resp, nil
+}
+
+func (zc *ZulipClient) HandleMatrixReaction(ctx context.Context, msg *bridgev2.MatrixReaction) (*database.Reaction, error) {
+	if msg.PreHandleResp.EmojiID != starEmojiID {
+		return nil, nil
+	}
+	return nil, zc.setStarred(ctx, msg.TargetMessage.ID, messages.OperationAdd)
+}
+
+func (zc *ZulipClient) HandleMatrixReactionRemove(ctx context.Context, msg *bridgev2.MatrixReactionRemove) error {
+	if msg.TargetReaction.EmojiID != starEmojiID {
+		return nil
+	}
+	return zc.setStarred(ctx, msg.TargetReaction.MessageID, messages.OperationRemove)
+}
+
+func (zc *ZulipClient) setStarred(ctx context.Context, target networkid.MessageID, op messages.Operation) error {
+	_, _, messageID := zid.ParseMessageID(target)
+	if messageID == 0 {
+		return bridgev2.WrapErrorInStatus(errors.New("topics can't be starred")).
+			WithIsCertain(true).WithErrorAsMessage().WithErrorReason(event.MessageStatusUnsupported)
+	}
+	_, err := messages.NewService(zc.Client).UpdatePersonalMessageFlags(ctx, []int{messageID}, op, messages.FlagStarred)
+	if err != nil {
+		return fmt.Errorf("failed to update starred flag: %w", err)
+	}
+	return nil
+}
+
+// handleUpdateMessageFlags bridges messages being starred or unstarred on
+// Zulip. This includes the echoes of stars from Matrix, which are ignored by
+// the bridge as duplicates.
+func (zc *ZulipClient) handleUpdateMessageFlags(ctx context.Context, evt *events.UpdateMessageFlags) bool {
+	if messages.Flag(evt.Flag) != messages.FlagStarred {
+		return true
+	}
+	evtType := bridgev2.RemoteEventReaction
+	if evt.Op == events.UpdateMessageFlagsOpRemove {
+		evtType = bridgev2.RemoteEventReactionRemove
+	}
+	for _, messageID := range evt.Messages {
+		part, err := zc.Main.Bridge.DB.Message.GetFirstPartByID(ctx, zc.UserLogin.ID, zid.MakeMessageID(zc.realm, messageID))
+		if err != nil {
+			zerolog.Ctx(ctx).Err(err).Int("message_id", messageID).Msg("Failed to get starred message")
+			return false
+		} else if part == nil {
+			continue
+		} else if evtType == bridgev2.RemoteEventReaction && zc.hasOwnReaction(ctx, part, zulipStarEmojiID) {
+			continue
+		}
+		res := zc.UserLogin.QueueRemoteEvent(&simplevent.Reaction{
+			EventMeta: simplevent.EventMeta{
+				Type: evtType,
+				LogContext: func(c zerolog.Context) zerolog.Context {
+					return c.Int("message_id", messageID)
+				},
+				PortalKey: part.Room,
+				Sender:    zc.makeEventSender(zc.ownUserID),
+			},
+			TargetMessage: part.ID,
+			EmojiID:       starEmojiID,
+			Emoji:         starEmoji,
+		})
+		if !res.Success {
+			return false
+		}
+	}
+	return true
+}
+
+// hasOwnReaction checks if the user already has a bridged reaction with the
+// emoji ID on a message.
+func (zc *ZulipClient) hasOwnReaction(ctx context.Context, part *database.Message, emojiID networkid.EmojiID) bool {
+	reaction, err := zc.Main.Bridge.DB.Reaction.GetByIDWithoutMessagePart(ctx, part.Room.Receiver, part.ID, zid.MakeUserID(zc.realm, zc.ownUserID), emojiID)
+	if err != nil {
+		zerolog.Ctx(ctx).Err(err).Str("message_id", string(part.ID)).Msg("Failed to check for existing reaction")
+		return false
+	}
+	return reaction != nil
+}
+
+var cmdStarred = &commands.FullHandler{
+	Func: fnStarred,
+	Name: "starred",
+	Help: commands.HelpMeta{
+		Section:     HelpSectionZulip,
+		Description: "List your most recently starred Zulip messages",
+		Args:        "[_count_]",
+	},
+	RequiresLogin: true,
+}
+
+func fnStarred(ce *commands.Event) {
+	zc := getCommandClient(ce)
+	if zc == nil {
+		return
+	}
+	count := defaultStarredListCount
+	if len(ce.Args) > 0 {
+		var err error
+		count, err = strconv.Atoi(ce.Args[0])
+		if err != nil || count < 1 || count > maxStarredListCount {
+			ce.Reply("Count must be a number between 1 and %d", maxStarredListCount)
+			return
+		}
+	}
+	resp, err := messages.NewService(zc.Client).GetMessages(
+		ce.Ctx,
+		messages.Anchor("newest"),
+		messages.NumBefore(count),
+		messages.NumAfter(0),
+		messages.NarrowMessage(narrow.NewFilter().Add(narrow.IsStarred)),
+		messages.ApplyMarkdownMessage(false),
+	)
+	if err != nil {
+		ce.Reply("Failed to get starred messages: %v", err)
+		return
+	} else if len(resp.Messages) == 0 {
+		ce.Reply("You don't have any starred messages")
+		return
+	}
+	lines := make([]string, 0, len(resp.Messages)+1)
+	lines = append(lines, fmt.Sprintf("Your %d most recently starred messages:", len(resp.Messages)))
+	// Messages are returned oldest first
+	for i := len(resp.Messages) - 1; i >= 0; i-- {
+		msg := &resp.Messages[i]
+		lines = append(lines, fmt.Sprintf(
+			"* **%s** in %s: %s ([link](%s))",
+			msg.SenderFullName, zc.describeConversation(msg), makeExcerpt(msg.Content), zc.getMessagePermalink(ce.Ctx, msg),
+		))
+	}
+	ce.Reply(strings.Join(lines, "\n"))
+}
+
+// describeConversation returns the channel and topic, or the other
+// participants of a DM, that a message was sent in.
+func (zc *ZulipClient) describeConversation(msg *messages.Message) string {
+	if msg.DisplayRecipient.IsChannel {
+		return fmt.Sprintf("#%s > %s", msg.DisplayRecipient.Channel, formatTopicName(msg.Subject))
+	}
+	var names []string
+	for _, user := range msg.DisplayRecipient.Users {
+		if user.ID != zc.ownUserID {
+			names = append(names, user.FullName)
+		}
+	}
+	if len(names) == 0 {
+		return "your notes to self"
+	}
+	return "a DM with " + strings.Join(names, ", ")
+}
@@ -0,0 +1,99 @@
+//go:build cgo
+
+package connector
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/database"
+	"maunium.net/go/mautrix/bridgev2/networkid"
+	"maunium.net/go/mautrix/event"
+
+	"go.mau.fi/mautrix-zulip/pkg/zid"
+)
+
+func TestHasOwnReaction(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	realm := zid.Realm("0123abcd")
+	zc := &ZulipClient{Main: &ZulipConnector{Bridge: &bridgev2.Bridge{DB: db}}, realm: realm, ownUserID: testOwnUserID}
+	ownUserID := zid.MakeUserID(realm, testOwnUserID)
+	_, err := db.Exec(ctx, `
+		INSERT INTO portal (
+			bridge_id, id, receiver, name, topic, avatar_id, avatar_hash, avatar_mxc,
+			name_set, avatar_set, topic_set, in_space, room_type, metadata
+		) VALUES ('zulip', $1, '', '', '', '', '', '', false, false, false, false, '', '{}')
+	`, zid.MakeChannelPortalID(realm, 5))
+	require.NoError(t, err)
+	_, err = db.Exec(ctx, `
+		INSERT INTO ghost (
+			bridge_id, id, name, avatar_id, avatar_hash, avatar_mxc, name_set, avatar_set, contact_info_set, is_bot, identifiers, metadata
+		) VALUES ('zulip', $1, '', '', '', '', false, false, false, false, '[]', '{}')
+	`, ownUserID)
+	require.NoError(t, err)
+	part := &database.Message{
+		ID:       zid.MakeMessageID(realm, 100),
+		MXID:     "$msg",
+		Room:     networkid.PortalKey{ID: zid.MakeChannelPortalID(realm, 5)},
+		SenderID: ownUserID,
+		Metadata: &zid.MessageMetadata{},
+	}
+	require.NoError(t, db.Message.Insert(ctx, part))
+
+	assert.False(t, zc.hasOwnReaction(ctx, part, starEmojiID))
+	require.NoError(t, db.Reaction.Upsert(ctx, &database.Reaction{
+		Room:      part.Room,
+		MessageID: part.ID,
+		SenderID:  ownUserID,
+		EmojiID:   zulipStarEmojiID,
+		Emoji:     starEmoji,
+		MXID:      "$star",
+		Metadata:  &struct{}{},
+	}))
+	assert.True(t, zc.hasOwnReaction(ctx, part, zulipStarEmojiID))
+	assert.False(t, zc.hasOwnReaction(ctx, part, starEmojiID))
+}
+
+func TestMatrixReactions(t *testing.T) {
+	var starRequests int
+	zc := newTestClient(t, Config{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v1/messages/flags", r.URL.Path)
+		starRequests++
+		_, _ = w.Write([]byte(`{"result": "success", "msg": "", "messages": [100]}`))
+	}))
+	ctx := context.Background()
+	target := &database.Message{ID: zid.MakeMessageID(zc.realm, 100)}
+	react := func(key string) *bridgev2.MatrixReaction {
+		msg := &bridgev2.MatrixReaction{
+			MatrixEventBase: bridgev2.MatrixEventBase[*event.ReactionEventContent]{
+				Content: &event.ReactionEventContent{RelatesTo: event.RelatesTo{Type: event.RelAnnotation, Key: key}},
+			},
+			TargetMessage: target,
+		}
+		preResp, err := zc.PreHandleMatrixReaction(ctx, msg)
+		require.NoError(t, err)
+		msg.PreHandleResp = &preResp
+		_, err = zc.HandleMatrixReaction(ctx, msg)
+		require.NoError(t, err)
+		return msg
+	}
+
+	thumbsUp := react("👍")
+	assert.Equal(t, networkid.EmojiID("matrix:👍"), thumbsUp.PreHandleResp.EmojiID)
+	assert.Equal(t, 0, starRequests)
+	star := react("⭐️")
+	assert.Equal(t, starEmojiID, star.PreHandleResp.EmojiID)
+	assert.Equal(t, 1, starRequests)
+
+	for _, emojiID := range []networkid.EmojiID{thumbsUp.PreHandleResp.EmojiID, starEmojiID} {
+		require.NoError(t, zc.HandleMatrixReactionRemove(ctx, &bridgev2.MatrixReactionRemove{
+			TargetReaction: &database.Reaction{MessageID: target.ID, EmojiID: emojiID},
+		}))
+	}
+	assert.Equal(t, 2, starRequests)
+}
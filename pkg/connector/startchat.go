@@ -0,0 +1,265 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"maunium.net/go/mautrix"
+	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/networkid"
+
+	"go.mau.fi/mautrix-zulip/pkg/zid"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/realtime/events"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/users"
+)
+
+var (
+	_ bridgev2.IdentifierResolvingNetworkAPI = (*ZulipClient)(nil)
+	_ bridgev2.GhostDMCreatingNetworkAPI     = (*ZulipClient)(nil)
+	_ bridgev2.GroupCreatingNetworkAPI       = (*ZulipClient)(nil)
+	_ bridgev2.IdentifierValidatingNetwork   = (*ZulipConnector)(nil)
+)
+
+// groupDMType is the group type for creating group DMs from Matrix.
+// Zulip group DMs don't exist until the first message is sent, so there's
+// nothing to create on the server and they have no name, topic or avatar.
+const groupDMType = "group_dm"
+
+func (zc *ZulipConnector) ValidateUserID(id networkid.UserID) bool {
+	realm, userID := zid.ParseUserID(id)
+	return realm != "" && userID > 0
+}
+
+// ResolveIdentifier finds a Zulip user by a ghost ID, a numeric user ID, an
+// email address or their full name.
+func (zc *ZulipClient) ResolveIdentifier(ctx context.Context, identifier string, createChat bool) (*bridgev2.ResolveIdentifierResponse, error) {
+	person, err := zc.resolveUser(ctx, strings.TrimSpace(identifier))
+	if err != nil {
+		return nil, err
+	}
+	userID := zid.MakeUserID(zc.realm, person.UserID)
+	ghost, err := zc.Main.Bridge.GetGhostByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ghost: %w", err)
+	}
+	userInfo, err := wrapPersonInfo(person)
+	if err != nil {
+		return nil, err
+	}
+	resp := &bridgev2.ResolveIdentifierResponse{
+		Ghost:    ghost,
+		UserID:   userID,
+		UserInfo: userInfo,
+	}
+	if createChat {
+		resp.Chat, err = zc.makeDMChat([]int{person.UserID})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// CreateChatWithGhost returns the DM portal with a ghost, which is used when
+// a ghost is invited to a new Matrix room. Ghosts of other realms are left
+// for the logins of that realm.
+func (zc *ZulipClient) CreateChatWithGhost(ctx context.Context, ghost *bridgev2.Ghost) (*bridgev2.CreateChatResponse, error) {
+	userID, err := zc.parseGhostID(ghost.ID)
+	if err != nil {
+		return nil, err
+	}
+	return zc.makeDMChat([]int{userID})
+}
+
+// CreateGroup creates a group DM or a channel. If the group is created for an
+// existing Matrix room, that room becomes the portal.
+func (zc *ZulipClient) CreateGroup(ctx context.Context, params *bridgev2.GroupCreateParams) (*bridgev2.CreateChatResponse, error) {
+	var resp *bridgev2.CreateChatResponse
+	var err error
+	switch params.Type {
+	case groupDMType:
+		resp, err = zc.createGroupDM(params.Participants)
+	case channelType, privateChannelType:
+		resp, err = zc.createChannel(ctx, params)
+	default:
+		return nil, fmt.Errorf("unsupported group type %q", params.Type)
+	}
+	if err != nil || params.RoomID == "" {
+		return resp, err
+	}
+	portal, err := zc.Main.Bridge.GetPortalByKey(ctx, resp.PortalKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get portal: %w", err)
+	}
+	err = portal.UpdateMatrixRoomID(ctx, params.RoomID, bridgev2.UpdateMatrixRoomIDParams{
+		FailIfMXIDSet:  true,
+		ChatInfo:       resp.PortalInfo,
+		ChatInfoSource: zc.UserLogin,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to bridge existing room: %w", err)
+	}
+	resp.Portal = portal
+	return resp, nil
+}
+
+func (zc *ZulipClient) createGroupDM(participants []networkid.UserID) (*bridgev2.CreateChatResponse, error) {
+	userIDs := make([]int, 0, len(participants))
+	for _, participant := range participants {
+		userID, err := zc.parseGhostID(participant)
+		if err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return zc.makeDMChat(userIDs)
+}
+
+// parseGhostID returns the Zulip user ID of a ghost in the realm of this login.
+func (zc *ZulipClient) parseGhostID(ghostID networkid.UserID) (int, error) {
+	realm, userID := zid.ParseUserID(ghostID)
+	if realm != zc.realm {
+		return 0, fmt.Errorf("%w: %s is in a different realm", bridgev2.ErrResolveIdentifierTryNext, ghostID)
+	} else if userID <= 0 {
+		return 0, fmt.Errorf("invalid user ID %s", ghostID)
+	}
+	return userID, nil
+}
+
+// makeDMChat returns the DM portal with the given users, excluding the own user.
+func (zc *ZulipClient) makeDMChat(userIDs []int) (*bridgev2.CreateChatResponse, error) {
+	others := make([]int, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if userID != zc.ownUserID && !slices.Contains(others, userID) {
+			others = append(others, userID)
+		}
+	}
+	if len(others) == 0 {
+		return nil, bridgev2.RespError(mautrix.MInvalidParam.WithMessage("Can't start a chat with yourself"))
+	}
+	info, err := zc.wrapDMInfo(others)
+	if err != nil {
+		return nil, err
+	}
+	return &bridgev2.CreateChatResponse{
+		PortalKey:  zc.makeDMPortalKey(others),
+		PortalInfo: info,
+	}, nil
+}
+
+func (zc *ZulipClient) makeDMPortalKey(otherUserIDs []int) networkid.PortalKey {
+	return networkid.PortalKey{
+		ID:       zid.MakeDMPortalID(zc.realm, otherUserIDs),
+		Receiver: zc.UserLogin.ID,
+	}
+}
+
+// resolveUser finds a user by a ghost ID, a numeric user ID, an email address or an exact full name.
+func (zc *ZulipClient) resolveUser(ctx context.Context, identifier string) (events.Person, error) {
+	if realm, userID := zid.ParseUserID(networkid.UserID(identifier)); realm != "" && userID > 0 {
+		userID, err := zc.parseGhostID(networkid.UserID(identifier))
+		if err != nil {
+			return events.Person{}, err
+		}
+		return zc.getPerson(ctx, userID)
+	} else if userID, err := strconv.Atoi(identifier); err == nil {
+		return zc.getPerson(ctx, userID)
+	} else if email, ok := strings.CutPrefix(identifier, "mailto:"); ok || strings.Contains(identifier, "@") {
+		return zc.getPersonByEmail(ctx, email)
+	}
+	return zc.getPersonByName(ctx, identifier)
+}
+
+func (zc *ZulipClient) getPerson(ctx context.Context, userID int) (events.Person, error) {
+	if person, ok := zc.Realm.GetUser(userID); ok {
+		return person, nil
+	}
+	user, err := users.NewService(zc.Client).GetUser(ctx, userID)
+	if err != nil {
+		return events.Person{}, fmt.Errorf("failed to get user %d: %w", userID, err)
+	}
+	return userDataToPerson(user.User), nil
+}
+
+func (zc *ZulipClient) getPersonByEmail(ctx context.Context, email string) (events.Person, error) {
+	if cached, ok := zc.Realm.GetUsers(); ok {
+		for _, person := range cached {
+			if strings.EqualFold(person.Email, email) || strings.EqualFold(person.DeliveryEmail, email) {
+				return person, nil
+			}
+		}
+	}
+	user, err := users.NewService(zc.Client).GetUserByEmail(ctx, email)
+	if err != nil {
+		return events.Person{}, fmt.Errorf("failed to get user %s: %w", email, err)
+	}
+	return userDataToPerson(user.User), nil
+}
+
+// getPersonByName finds the active user whose full name matches the given
+// name case-insensitively. Ambiguous names are an error rather than a guess.
+func (zc *ZulipClient) getPersonByName(ctx context.Context, name string) (events.Person, error) {
+	allUsers, err := zc.listUsers(ctx)
+	if err != nil {
+		return events.Person{}, err
+	}
+	var matches []events.Person
+	for _, person := range allUsers {
+		if person.IsActive && strings.EqualFold(person.FullName, name) {
+			matches = append(matches, person)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return events.Person{}, bridgev2.RespError(mautrix.MNotFound.WithMessage("No user named %q found", name))
+	case 1:
+		return matches[0], nil
+	default:
+		return events.Person{}, bridgev2.RespError(mautrix.MInvalidParam.WithMessage("Multiple users are named %q, use an email address or user ID instead", name))
+	}
+}
+
+// listUsers returns all users of the realm, preferring the cached realm state
+// over fetching them from the server.
+func (zc *ZulipClient) listUsers(ctx context.Context) ([]events.Person, error) {
+	if cached, ok := zc.Realm.GetUsers(); ok {
+		return cached, nil
+	}
+	resp, err := users.NewService(zc.Client).GetUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	people := make([]events.Person, len(resp.Members))
+	for i, member := range resp.Members {
+		people[i] = events.Person{
+			AvatarURL:     member.AvatarURL,
+			AvatarVersion: member.AvatarVersion,
+			DeliveryEmail: member.DeliveryEmail,
+			Email:         member.Email,
+			FullName:      member.FullName,
+			IsActive:      member.IsActive,
+			IsBot:         member.IsBot,
+			Role:          member.Role,
+			UserID:        member.UserID,
+		}
+	}
+	zc.Realm.LoadUsers(people)
+	return people, nil
+}
+
+func userDataToPerson(user users.UserData) events.Person {
+	return events.Person{
+		AvatarURL:     user.AvatarURL,
+		AvatarVersion: user.AvatarVersion,
+		DeliveryEmail: user.DeliveryEmail,
+		Email:         user.Email,
+		FullName:      user.FullName,
+		IsActive:      user.IsActive,
+		IsBot:         user.IsBot,
+		Role:          user.Role,
+		UserID:        user.UserID,
+	}
+}
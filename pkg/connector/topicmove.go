@@ -0,0 +1,223 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/rs/zerolog"
+	"go.mau.fi/util/ptr"
+	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/networkid"
+	"maunium.net/go/mautrix/bridgev2/simplevent"
+
+	"go.mau.fi/mautrix-zulip/pkg/zid"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/messages"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/realtime/events"
+)
+
+// propagateModeAll is the propagate mode of moves that apply to every message in the topic.
+const propagateModeAll = "change_all"
+
+// handleTopicMove updates topic rooms after messages were moved to another
+// topic or channel. When the whole topic is moved, the portal is re-ID'd to
+// the new topic, which also covers renames. If the new topic already has a
+// room, the old room is tombstoned into it and the moved messages are bridged
+// there again. Renames that only change the case keep the portal and just
+// update its name. When only some messages are moved, they're removed from the
+// old room and bridged again in the new one, in which case rebridged is true.
+func (zc *ZulipClient) handleTopicMove(ctx context.Context, evt *events.UpdateMessage) (ok, rebridged bool) {
+	if evt.StreamID == nil {
+		// Direct messages can't be moved
+		return true, false
+	}
+	oldTopic := ptr.Val(evt.OrigSubject)
+	newTopic := oldTopic
+	if evt.Subject != nil {
+		newTopic = *evt.Subject
+	}
+	newStreamID := *evt.StreamID
+	if evt.NewStreamID != nil {
+		newStreamID = *evt.NewStreamID
+	}
+	oldKey := zc.makeTopicPortalKey(*evt.StreamID, oldTopic)
+	newKey := zc.makeTopicPortalKey(newStreamID, newTopic)
+	if oldKey == newKey {
+		if newTopic != oldTopic && ptr.Val(evt.PropagateMode) == propagateModeAll {
+			return zc.resyncTopicPortal(newKey, newStreamID, newTopic), false
+		}
+		return true, false
+	}
+	if ptr.Val(evt.PropagateMode) == propagateModeAll {
+		return zc.moveTopicPortal(ctx, evt, oldKey, newKey, newStreamID, newTopic), false
+	}
+	return zc.moveTopicMessages(ctx, evt), true
+}
+
+func (zc *ZulipClient) moveTopicPortal(
+	ctx context.Context, evt *events.UpdateMessage, oldKey, newKey networkid.PortalKey, newStreamID int, newTopic string,
+) bool {
+	log := zerolog.Ctx(ctx)
+	bridged, err := zc.getMessagesToRebridge(ctx, oldKey, newKey, evt.MessageIDs)
+	if err != nil {
+		log.Err(err).Msg("Failed to get moved messages")
+		return false
+	}
+	result, _, err := zc.Main.Bridge.ReIDPortal(ctx, oldKey, newKey)
+	if err != nil {
+		log.Err(err).Msg("Failed to move topic portal")
+		return false
+	}
+	switch result {
+	case bridgev2.ReIDResultSourceReIDd, bridgev2.ReIDResultTargetDeletedAndSourceReIDd:
+		// Resync to update the room name and move the room to the new channel's space
+		return zc.resyncTopicPortal(newKey, newStreamID, newTopic)
+	case bridgev2.ReIDResultSourceTombstonedIntoTarget:
+		return zc.rebridgeMovedMessages(ctx, evt.ID, bridged)
+	default:
+		return true
+	}
+}
+
+// getMessagesToRebridge finds the moved messages that were bridged into the
+// old topic room if the new topic already has a room. Moving the portal then
+// tombstones the old room and deletes its messages from the database, so they
+// have to be bridged into the existing room again.
+func (zc *ZulipClient) getMessagesToRebridge(ctx context.Context, oldKey, newKey networkid.PortalKey, messageIDs []int) ([]int, error) {
+	target, err := zc.Main.Bridge.GetExistingPortalByKey(ctx, newKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get target portal: %w", err)
+	} else if target == nil || target.MXID == "" {
+		return nil, nil
+	}
+	var bridged []int
+	for _, messageID := range messageIDs {
+		part, err := zc.Main.Bridge.DB.Message.GetFirstPartByID(ctx, zc.UserLogin.ID, zid.MakeMessageID(zc.realm, messageID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get message %d: %w", messageID, err)
+		} else if part != nil && part.Room == oldKey {
+			bridged = append(bridged, messageID)
+		}
+	}
+	slices.Sort(bridged)
+	return bridged, nil
+}
+
+// rebridgeMovedMessages bridges messages again after their old room was
+// tombstoned into the room of their new topic.
+func (zc *ZulipClient) rebridgeMovedMessages(ctx context.Context, evtID int, messageIDs []int) bool {
+	srv := messages.NewService(zc.Client)
+	for _, messageID := range messageIDs {
+		resp, err := srv.FetchSingleMessage(ctx, messageID)
+		if err != nil {
+			zerolog.Ctx(ctx).Err(err).Int("message_id", messageID).Msg("Failed to fetch moved message")
+			return false
+		}
+		if !zc.UserLogin.QueueRemoteEvent(zc.wrapMessage(evtID, messageToEventData(&resp.Message), "")).Success {
+			return false
+		}
+	}
+	return true
+}
+
+// resyncTopicPortal updates the name and space of a topic room after the
+// topic was renamed or moved.
+func (zc *ZulipClient) resyncTopicPortal(key networkid.PortalKey, streamID int, topic string) bool {
+	return zc.UserLogin.QueueRemoteEvent(&simplevent.ChatResync{
+		EventMeta: simplevent.EventMeta{
+			Type:      bridgev2.RemoteEventChatResync,
+			PortalKey: key,
+		},
+		GetChatInfoFunc: func(ctx context.Context, portal *bridgev2.Portal) (*bridgev2.ChatInfo, error) {
+			return zc.getTopicChatInfo(ctx, streamID, topic)
+		},
+	}).Success
+}
+
+// moveTopicMessages moves individual messages between topic rooms. Messages
+// that were never bridged are left alone.
+func (zc *ZulipClient) moveTopicMessages(ctx context.Context, evt *events.UpdateMessage) bool {
+	log := zerolog.Ctx(ctx)
+	srv := messages.NewService(zc.Client)
+	for _, messageID := range evt.MessageIDs {
+		part, err := zc.Main.Bridge.DB.Message.GetFirstPartByID(ctx, zc.UserLogin.ID, zid.MakeMessageID(zc.realm, messageID))
+		if err != nil {
+			log.Err(err).Int("message_id", messageID).Msg("Failed to get moved message")
+			return false
+		} else if part == nil {
+			continue
+		}
+		resp, err := srv.FetchSingleMessage(ctx, messageID)
+		if err != nil {
+			log.Err(err).Int("message_id", messageID).Msg("Failed to fetch moved message")
+			return false
+		}
+		ok := zc.UserLogin.QueueRemoteEvent(&movedMessageRemove{
+			MessageRemove: simplevent.MessageRemove{
+				EventMeta: simplevent.EventMeta{
+					Type: bridgev2.RemoteEventMessageRemove,
+					LogContext: func(c zerolog.Context) zerolog.Context {
+						return c.Int("evt_id", evt.ID).Int("msg_id", messageID)
+					},
+					PortalKey: part.Room,
+					Sender:    zc.makeGhostEventSender(part.SenderID),
+				},
+				TargetMessage: part.ID,
+			},
+			zc:       zc,
+			rebridge: zc.wrapMessage(evt.ID, messageToEventData(&resp.Message), ""),
+		}).Success
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// movedMessageRemove removes a message from the room of its old topic and
+// then bridges it again into the room of its new topic. The new message is
+// only queued after the removal, as it has the same ID as the old one.
+type movedMessageRemove struct {
+	simplevent.MessageRemove
+	zc       *ZulipClient
+	rebridge bridgev2.RemoteMessage
+}
+
+var _ bridgev2.RemotePostHandler = (*movedMessageRemove)(nil)
+
+func (mmr *movedMessageRemove) PostHandle(ctx context.Context, portal *bridgev2.Portal) {
+	mmr.zc.UserLogin.QueueRemoteEvent(mmr.rebridge)
+}
+
+// messageToEventData converts a message fetched from the API into the format
+// used by message events.
+func messageToEventData(msg *messages.Message) *events.MessageData {
+	data := &events.MessageData{
+		ID:             msg.ID,
+		Type:           msg.Type,
+		AvatarURL:      msg.AvatarURL,
+		Client:         msg.Client,
+		Content:        msg.Content,
+		ContentType:    msg.ContentType,
+		IsMeMessage:    msg.IsMeMessage,
+		RecipientID:    msg.RecipientID,
+		SenderEmail:    msg.SenderEmail,
+		SenderFullName: msg.SenderFullName,
+		SenderID:       msg.SenderID,
+		SenderRealmStr: msg.SenderRealmStr,
+		StreamID:       msg.StreamID,
+		Subject:        msg.Subject,
+		Timestamp:      msg.Timestamp,
+		Flags:          msg.Flags,
+	}
+	data.DisplayRecipient.IsChannel = msg.DisplayRecipient.IsChannel
+	data.DisplayRecipient.Channel = msg.DisplayRecipient.Channel
+	for _, user := range msg.DisplayRecipient.Users {
+		data.DisplayRecipient.Users = append(data.DisplayRecipient.Users, events.DisplayRecipientObject{
+			ID:       user.ID,
+			Email:    user.Email,
+			FullName: user.FullName,
+		})
+	}
+	return data
+}
@@ -0,0 +1,172 @@
+package connector
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"maunium.net/go/mautrix/bridgev2"
+
+	"go.mau.fi/mautrix-zulip/pkg/zid"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/realtime/events"
+)
+
+var (
+	_ bridgev2.ContactListingNetworkAPI = (*ZulipClient)(nil)
+	_ bridgev2.UserSearchingNetworkAPI  = (*ZulipClient)(nil)
+)
+
+// maxSearchResults is the maximum number of users returned by a search.
+const maxSearchResults = 50
+
+// GetContactList returns everyone in the organization who can be messaged,
+// which excludes deactivated users, bots and the own user.
+func (zc *ZulipClient) GetContactList(ctx context.Context) ([]*bridgev2.ResolveIdentifierResponse, error) {
+	allUsers, err := zc.listUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	contacts := slices.DeleteFunc(allUsers, func(person events.Person) bool {
+		return !zc.isContact(person)
+	})
+	slices.SortFunc(contacts, func(a, b events.Person) int {
+		return strings.Compare(strings.ToLower(a.FullName), strings.ToLower(b.FullName))
+	})
+	return zc.wrapContacts(ctx, contacts)
+}
+
+// SearchUsers returns the contacts whose name or email fuzzily matches the
+// query, with the best matches first.
+func (zc *ZulipClient) SearchUsers(ctx context.Context, query string) ([]*bridgev2.ResolveIdentifierResponse, error) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil, nil
+	}
+	allUsers, err := zc.listUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	type match struct {
+		person events.Person
+		score  int
+	}
+	var matches []match
+	for _, person := range allUsers {
+		if !zc.isContact(person) {
+			continue
+		}
+		if score := matchUser(person, query); score > 0 {
+			matches = append(matches, match{person, score})
+		}
+	}
+	slices.SortFunc(matches, func(a, b match) int {
+		return cmp.Or(
+			cmp.Compare(b.score, a.score),
+			strings.Compare(strings.ToLower(a.person.FullName), strings.ToLower(b.person.FullName)),
+		)
+	})
+	if len(matches) > maxSearchResults {
+		matches = matches[:maxSearchResults]
+	}
+	results := make([]events.Person, len(matches))
+	for i, m := range matches {
+		results[i] = m.person
+	}
+	return zc.wrapContacts(ctx, results)
+}
+
+func (zc *ZulipClient) isContact(person events.Person) bool {
+	return person.IsActive && !person.IsBot && person.UserID != zc.ownUserID
+}
+
+func (zc *ZulipClient) wrapContacts(ctx context.Context, people []events.Person) ([]*bridgev2.ResolveIdentifierResponse, error) {
+	resp := make([]*bridgev2.ResolveIdentifierResponse, len(people))
+	for i, person := range people {
+		userID := zid.MakeUserID(zc.realm, person.UserID)
+		ghost, err := zc.Main.Bridge.GetGhostByID(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get ghost for %d: %w", person.UserID, err)
+		}
+		userInfo, err := wrapPersonInfo(person)
+		if err != nil {
+			return nil, err
+		}
+		resp[i] = &bridgev2.ResolveIdentifierResponse{
+			Ghost:    ghost,
+			UserID:   userID,
+			UserInfo: userInfo,
+			Chat: &bridgev2.CreateChatResponse{
+				PortalKey: zc.makeDMPortalKey([]int{person.UserID}),
+			},
+		}
+	}
+	return resp, nil
+}
+
+// matchUser scores how well a lowercase query matches the name or email of a
+// user. Higher is better and 0 means no match:
+//
+//  5. the full name or email is exactly the query
+//  4. the full name or email starts with the query
+//  3. every word of the query starts a word of the name, e.g. "jo sm" for "John Smith"
+//  2. the name or email contains the query
+//  1. the characters of the query appear in order in the name, e.g. "jsmth"
+func matchUser(person events.Person, query string) int {
+	name := strings.ToLower(person.FullName)
+	emails := []string{strings.ToLower(person.Email), strings.ToLower(person.DeliveryEmail)}
+	anyEmail := func(fn func(email string) bool) bool {
+		return slices.ContainsFunc(emails, func(email string) bool {
+			return email != "" && fn(email)
+		})
+	}
+	switch {
+	case name == query || anyEmail(func(email string) bool { return email == query }):
+		return 5
+	case strings.HasPrefix(name, query) || anyEmail(func(email string) bool { return strings.HasPrefix(email, query) }):
+		return 4
+	case matchWordPrefixes(strings.Fields(name), strings.Fields(query)):
+		return 3
+	case strings.Contains(name, query) || anyEmail(func(email string) bool { return strings.Contains(email, query) }):
+		return 2
+	case isSubsequence(query, name):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// matchWordPrefixes checks that each query word is a prefix of a different name word.
+func matchWordPrefixes(nameWords, queryWords []string) bool {
+	if len(queryWords) == 0 || len(queryWords) > len(nameWords) {
+		return false
+	}
+	used := make([]bool, len(nameWords))
+QueryWords:
+	for _, queryWord := range queryWords {
+		for i, nameWord := range nameWords {
+			if !used[i] && strings.HasPrefix(nameWord, queryWord) {
+				used[i] = true
+				continue QueryWords
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func isSubsequence(query, target string) bool {
+	targetRunes := []rune(target)
+	for _, r := range query {
+		if r == ' ' {
+			continue
+		}
+		idx := slices.Index(targetRunes, r)
+		if idx < 0 {
+			return false
+		}
+		targetRunes = targetRunes[idx+1:]
+	}
+	return true
+}
@@ -0,0 +1,42 @@
+package connector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip/realtime/events"
+)
+
+func TestMatchUser(t *testing.T) {
+	john := events.Person{FullName: "John Smith", Email: "user11@zulip.example.com", DeliveryEmail: "John.Smith@Example.com"}
+	shouting := events.Person{FullName: "ANNA NG", Email: "Anna@Example.com"}
+	tests := []struct {
+		name   string
+		person events.Person
+		query  string
+		score  int
+	}{
+		{"exact name", john, "john smith", 5},
+		{"exact email", john, "user11@zulip.example.com", 5},
+		{"exact delivery email", john, "john.smith@example.com", 5},
+		{"name prefix", john, "john", 4},
+		{"email prefix", john, "user11", 4},
+		{"delivery email prefix", john, "john.sm", 4},
+		{"word prefixes", john, "jo sm", 3},
+		{"word prefixes out of order", john, "smi jo", 3},
+		{"repeated word prefix", john, "jo jo", 0},
+		{"name substring", john, "n sm", 2},
+		{"email substring", john, "example.com", 2},
+		{"subsequence", john, "jsmth", 1},
+		{"no match", john, "jane", 0},
+		{"name case", shouting, "anna ng", 5},
+		{"email case", shouting, "anna@", 4},
+		{"only lowercase query matches", john, "John", 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.score, matchUser(test.person, test.query))
+		})
+	}
+}
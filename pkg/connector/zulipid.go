@@ -12,27 +12,56 @@ import (
 func (zc *ZulipClient) makeEventSender(id int) bridgev2.EventSender {
 	return bridgev2.EventSender{
 		IsFromMe:    id == zc.ownUserID,
-		SenderLogin: zid.MakeUserLoginID(id),
-		Sender:      zid.MakeUserID(id),
+		SenderLogin: zid.MakeUserLoginID(zc.realm, id),
+		Sender:      zid.MakeUserID(zc.realm, id),
 	}
 }
 
+// makeGhostEventSender makes an event sender from a ghost ID stored in the database.
+func (zc *ZulipClient) makeGhostEventSender(userID networkid.UserID) bridgev2.EventSender {
+	_, id := zid.ParseUserID(userID)
+	return zc.makeEventSender(id)
+}
+
 func (zc *ZulipClient) makeChannelPortalKey(streamID int) (pk networkid.PortalKey) {
 	if zc.UserLogin.Bridge.Config.SplitPortals {
 		pk.Receiver = zc.UserLogin.ID
 	}
-	pk.ID = zid.MakeChannelPortalID(streamID)
+	pk.ID = zid.MakeChannelPortalID(zc.realm, streamID)
+	return pk
+}
+
+func (zc *ZulipClient) makeTopicPortalKey(streamID int, topic string) (pk networkid.PortalKey) {
+	if zc.UserLogin.Bridge.Config.SplitPortals {
+		pk.Receiver = zc.UserLogin.ID
+	}
+	pk.ID = zid.MakeTopicPortalID(zc.realm, streamID, topic)
 	return pk
 }
 
+// getPortalTopic returns the stream ID and display name of the topic of a
+// topic portal. ok is false for all other portals.
+func getPortalTopic(portal *bridgev2.Portal) (streamID int, topic string, ok bool) {
+	_, streamID, topic, ok = zid.ParseTopicPortalID(portal.ID)
+	if !ok {
+		return
+	}
+	if meta, isPortalMeta := portal.Metadata.(*zid.PortalMetadata); isPortalMeta && meta.TopicName != "" {
+		topic = meta.TopicName
+	}
+	return streamID, topic, true
+}
+
 func (zc *ZulipClient) makePortalKey(message events.MessageData) (pk networkid.PortalKey) {
 	if zc.UserLogin.Bridge.Config.SplitPortals || message.StreamID == 0 {
 		pk.Receiver = zc.UserLogin.ID
 	}
-	if message.StreamID != 0 {
-		pk.ID = zid.MakeChannelPortalID(message.StreamID)
+	if message.StreamID != 0 && zc.Main.Config.RoomPerTopic {
+		pk.ID = zid.MakeTopicPortalID(zc.realm, message.StreamID, message.Subject)
+	} else if message.StreamID != 0 {
+		pk.ID = zid.MakeChannelPortalID(zc.realm, message.StreamID)
 	} else {
-		pk.ID = zid.MakeDMPortalID(exslices.CastFuncFilter(message.DisplayRecipient.Users, func(from events.DisplayRecipientObject) (int, bool) {
+		pk.ID = zid.MakeDMPortalID(zc.realm, exslices.CastFuncFilter(message.DisplayRecipient.Users, func(from events.DisplayRecipientObject) (int, bool) {
 			if from.ID == zc.ownUserID {
 				return 0, false
 			}
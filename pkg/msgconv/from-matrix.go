@@ -1 +1,42 @@
 package msgconv
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip/messages"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/narrow"
+)
+
+var fenceRegex = regexp.MustCompile("(?m)^\\s*(`{3,})")
+
+// unusedFence returns a code fence that's longer than any fence inside the content.
+func unusedFence(content string) string {
+	length := 3
+	for _, match := range fenceRegex.FindAllStringSubmatch(content, -1) {
+		length = max(length, len(match[1])+1)
+	}
+	return strings.Repeat("`", length)
+}
+
+// QuoteReply prepends the same quote block to a reply that Zulip's "Quote and
+// reply" feature inserts: a silent mention of the original sender with a link
+// to the quoted message, followed by its raw Markdown content in a quote fence.
+func QuoteReply(baseURL string, quoted *messages.Message, rawContent, reply string) string {
+	var link string
+	if quoted.DisplayRecipient.IsChannel {
+		link = narrow.ChannelMessageURL(baseURL, quoted.StreamID, quoted.DisplayRecipient.Channel, quoted.Subject, quoted.ID)
+	} else {
+		userIDs := make([]int, len(quoted.DisplayRecipient.Users))
+		for i, user := range quoted.DisplayRecipient.Users {
+			userIDs[i] = user.ID
+		}
+		link = narrow.DMMessageURL(baseURL, userIDs, quoted.ID)
+	}
+	fence := unusedFence(rawContent)
+	return fmt.Sprintf(
+		"@_**%s|%d** [said](%s):\n%squote\n%s\n%s\n%s",
+		quoted.SenderFullName, quoted.SenderID, link, fence, rawContent, fence, reply,
+	)
+}
@@ -0,0 +1,72 @@
+//go:build cgo
+
+package msgconv
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/database"
+	"maunium.net/go/mautrix/bridgev2/networkid"
+	"maunium.net/go/mautrix/id"
+
+	"go.mau.fi/mautrix-zulip/pkg/msgconv/internal/bridgetest"
+	"go.mau.fi/mautrix-zulip/pkg/zid"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/messages"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/realtime/events"
+)
+
+func TestToMatrixMentions(t *testing.T) {
+	br := bridgetest.New(t)
+	realm := zid.Realm("0123abcd")
+	portal := &bridgev2.Portal{
+		Portal: &database.Portal{PortalKey: networkid.PortalKey{ID: zid.MakeChannelPortalID(realm, 5)}},
+		Bridge: br,
+	}
+	source := &bridgev2.UserLogin{
+		UserLogin: &database.UserLogin{
+			ID:       zid.MakeUserLoginID(realm, 10),
+			UserMXID: "@alice:example.com",
+			Metadata: &zid.UserLoginMetadata{URL: "https://zulip.example.com"},
+		},
+	}
+	bobMXID := bridgetest.GhostMXID(zid.MakeUserID(realm, 20))
+	const bobMention = `<p><span class="user-mention" data-user-id="20">@Bob</span> hi</p>`
+	const wildcardMention = `<p><span class="user-mention" data-user-id="*">@all</span> hi</p>`
+
+	tests := []struct {
+		name     string
+		content  string
+		flags    []string
+		expected []id.UserID
+		room     bool
+	}{
+		{"no flags", "<p>hi</p>", nil, nil, false},
+		{"other flags", "<p>hi</p>", []string{string(messages.FlagRead), string(messages.FlagHasAlertWord)}, nil, false},
+		{"mentioned without html mention", "<p>hi</p>", []string{string(messages.FlagMentioned)}, []id.UserID{source.UserMXID}, false},
+		{"wildcard mentioned without html mention", "<p>hi</p>", []string{string(messages.FlagWildcardMentioned)}, []id.UserID{source.UserMXID}, false},
+		{"html mention of someone else", bobMention, nil, []id.UserID{bobMXID}, false},
+		{"mentioned with html mention", bobMention, []string{string(messages.FlagMentioned)}, []id.UserID{bobMXID, source.UserMXID}, false},
+		{"html wildcard mention", wildcardMention, nil, nil, true},
+		{"wildcard mentioned with html wildcard mention", wildcardMention, []string{string(messages.FlagWildcardMentioned)}, []id.UserID{source.UserMXID}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			converted, err := ToMatrix(context.Background(), portal, nil, source, &events.MessageData{
+				ID:       100,
+				Content:  test.content,
+				StreamID: 5,
+				Flags:    test.flags,
+			})
+			require.NoError(t, err)
+			require.Len(t, converted.Parts, 1)
+			mentions := converted.Parts[0].Content.Mentions
+			require.NotNil(t, mentions)
+			assert.Equal(t, test.expected, mentions.UserIDs)
+			assert.Equal(t, test.room, mentions.Room)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+//go:build cgo
+
+// Package bridgetest provides a bridge backed by an in-memory database for
+// testing message conversion.
+package bridgetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"go.mau.fi/util/dbutil"
+	_ "go.mau.fi/util/dbutil/litestream"
+	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/database"
+	"maunium.net/go/mautrix/bridgev2/networkid"
+	"maunium.net/go/mautrix/id"
+
+	"go.mau.fi/mautrix-zulip/pkg/zid"
+)
+
+// matrix is a Matrix connector that only knows the MXIDs of ghosts.
+type matrix struct {
+	bridgev2.MatrixConnector
+}
+
+func (m *matrix) Init(*bridgev2.Bridge)         {}
+func (m *matrix) BotIntent() bridgev2.MatrixAPI { return nil }
+func (m *matrix) GhostIntent(userID networkid.UserID) bridgev2.MatrixAPI {
+	return &intent{mxid: GhostMXID(userID)}
+}
+
+type intent struct {
+	bridgev2.MatrixAPI
+	mxid id.UserID
+}
+
+func (i *intent) GetMXID() id.UserID { return i.mxid }
+
+type network struct {
+	bridgev2.NetworkConnector
+}
+
+func (n *network) Init(*bridgev2.Bridge) {}
+func (n *network) GetDBMetaTypes() database.MetaTypes {
+	return database.MetaTypes{
+		Message:   func() any { return &zid.MessageMetadata{} },
+		UserLogin: func() any { return &zid.UserLoginMetadata{} },
+	}
+}
+
+// GhostMXID returns the Matrix user ID of a ghost in the test bridge.
+func GhostMXID(userID networkid.UserID) id.UserID {
+	return id.NewUserID("zulip_"+string(userID), "example.com")
+}
+
+// New makes a bridge with an empty database. Ghosts get their MXIDs from
+// GhostMXID and nothing can be sent to Matrix.
+func New(t *testing.T) *bridgev2.Bridge {
+	rawDB, err := dbutil.NewFromConfig("", dbutil.Config{PoolConfig: dbutil.PoolConfig{
+		Type:         "sqlite3-fk-wal",
+		URI:          ":memory:?_txlock=immediate",
+		MaxOpenConns: 1,
+		MaxIdleConns: 1,
+	}}, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = rawDB.Close() })
+	br := bridgev2.NewBridge("zulip", rawDB, zerolog.Nop(), nil, &matrix{}, &network{}, func(*bridgev2.Bridge) bridgev2.CommandProcessor {
+		return nil
+	})
+	// Loading portals needs the context that is normally made when starting the bridge
+	br.BackgroundCtx = context.Background()
+	require.NoError(t, br.DB.Upgrade(br.BackgroundCtx))
+	return br
+}
+
+// AddMessage inserts a bridged message along with its portal and sender.
+func AddMessage(t *testing.T, br *bridgev2.Bridge, msg *database.Message) {
+	ctx := context.Background()
+	_, err := br.DB.Exec(ctx, `
+		INSERT INTO portal (
+			bridge_id, id, receiver, name, topic, avatar_id, avatar_hash, avatar_mxc,
+			name_set, avatar_set, topic_set, in_space, room_type, metadata
+		) VALUES ('zulip', $1, $2, '', '', '', '', '', false, false, false, false, '', '{}')
+		ON CONFLICT DO NOTHING
+	`, msg.Room.ID, msg.Room.Receiver)
+	require.NoError(t, err)
+	_, err = br.DB.Exec(ctx, `
+		INSERT INTO ghost (
+			bridge_id, id, name, avatar_id, avatar_hash, avatar_mxc, name_set, avatar_set, contact_info_set, is_bot, identifiers, metadata
+		) VALUES ('zulip', $1, '', '', '', '', false, false, false, false, '[]', '{}')
+		ON CONFLICT DO NOTHING
+	`, msg.SenderID)
+	require.NoError(t, err)
+	if msg.Metadata == nil {
+		msg.Metadata = &zid.MessageMetadata{}
+	}
+	require.NoError(t, br.DB.Message.Insert(ctx, msg))
+}
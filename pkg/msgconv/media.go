@@ -0,0 +1,412 @@
+package msgconv
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/rs/zerolog"
+	"golang.org/x/sync/semaphore"
+	"maunium.net/go/mautrix"
+	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/networkid"
+	"maunium.net/go/mautrix/event"
+
+	"go.mau.fi/mautrix-zulip/pkg/msgconv/mediameta"
+	"go.mau.fi/mautrix-zulip/pkg/msgconv/zuliphtml"
+	"go.mau.fi/mautrix-zulip/pkg/zid"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/messages"
+)
+
+var MediaClient = &http.Client{
+	Timeout: 60 * time.Second,
+}
+
+// UseDirectMedia makes files uploaded to Zulip be bridged as direct media
+// URIs instead of being reuploaded to Matrix. It's set by the connector when
+// direct media is enabled in the bridge config.
+var UseDirectMedia = false
+
+// MediaDownloadLimiter is implemented by network clients to limit how many
+// files are downloaded from the server at the same time.
+type MediaDownloadLimiter interface {
+	MediaDownloadSemaphore() *semaphore.Weighted
+}
+
+const (
+	// maxSmallFileSize is the largest link preview image or thumbnail that is reuploaded to Matrix.
+	maxSmallFileSize = 10 * 1024 * 1024
+	// maxBlurhashPixels is the largest image that is fully decoded to compute a
+	// blurhash. Decoded images take up to 8 bytes per pixel and several files
+	// are converted at once, so larger images only get a blurhash from their
+	// thumbnail, if it's in a format that can be decoded.
+	maxBlurhashPixels = 4_000_000
+)
+
+type mediaConverter struct {
+	portal  *bridgev2.Portal
+	intent  bridgev2.MatrixAPI
+	loginID networkid.UserLoginID
+	meta    *zid.UserLoginMetadata
+	sem     *semaphore.Weighted
+}
+
+func newMediaConverter(portal *bridgev2.Portal, intent bridgev2.MatrixAPI, source *bridgev2.UserLogin) *mediaConverter {
+	mc := &mediaConverter{
+		portal:  portal,
+		intent:  intent,
+		loginID: source.ID,
+		meta:    source.Metadata.(*zid.UserLoginMetadata),
+	}
+	if limiter, ok := source.Client.(MediaDownloadLimiter); ok {
+		mc.sem = limiter.MediaDownloadSemaphore()
+	}
+	return mc
+}
+
+// convertAll converts the link previews and attachments of a message
+// concurrently. The results are in the same order as the input.
+func (mc *mediaConverter) convertAll(
+	ctx context.Context, previews []zuliphtml.LinkPreview, attachments []zuliphtml.Attachment,
+) ([]*event.BeeperLinkPreview, []*bridgev2.ConvertedMessagePart) {
+	var wg sync.WaitGroup
+	var convertedPreviews []*event.BeeperLinkPreview
+	if len(previews) > 0 {
+		convertedPreviews = make([]*event.BeeperLinkPreview, len(previews))
+	}
+	for i, preview := range previews {
+		wg.Go(func() {
+			convertedPreviews[i] = mc.linkPreviewToMatrix(ctx, preview)
+		})
+	}
+	parts := make([]*bridgev2.ConvertedMessagePart, len(attachments))
+	for i, attachment := range attachments {
+		wg.Go(func() {
+			parts[i] = mc.attachmentToMatrix(ctx, attachment)
+		})
+	}
+	wg.Wait()
+	return convertedPreviews, parts
+}
+
+// download sends a request for a file, waiting for a free download slot first.
+// The caller must close the response body and call the release function,
+// which is safe to call multiple times.
+func (mc *mediaConverter) download(ctx context.Context, url string) (resp *http.Response, release func(), err error) {
+	release = func() {}
+	if mc.sem != nil {
+		if err = mc.sem.Acquire(ctx, 1); err != nil {
+			return
+		}
+		release = sync.OnceFunc(func() { mc.sem.Release(1) })
+	}
+	defer func() {
+		if err != nil {
+			release()
+		}
+	}()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, release, fmt.Errorf("failed to prepare request: %w", err)
+	}
+	req.Header.Set("User-Agent", mautrix.DefaultUserAgent)
+	req.Header.Set("Accept", "*/*")
+	if strings.HasPrefix(url, mc.meta.URL) {
+		req.SetBasicAuth(mc.meta.Email, mc.meta.Token)
+	}
+	resp, err = MediaClient.Do(req)
+	if err != nil {
+		return nil, release, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		_ = resp.Body.Close()
+		return nil, release, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return resp, release, nil
+}
+
+// downloadSmall downloads a file into memory.
+func (mc *mediaConverter) downloadSmall(ctx context.Context, url string) (data []byte, mimeType string, err error) {
+	resp, release, err := mc.download(ctx, url)
+	if err != nil {
+		return nil, "", err
+	}
+	defer release()
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	data, err = io.ReadAll(io.LimitReader(resp.Body, maxSmallFileSize+1))
+	if err != nil {
+		return nil, "", err
+	} else if len(data) > maxSmallFileSize {
+		return nil, "", fmt.Errorf("file is larger than %d bytes", maxSmallFileSize)
+	}
+	mimeType = resp.Header.Get("Content-Type")
+	if mimeType == "" || mimeType == "application/octet-stream" {
+		mimeType = mimetype.Detect(data).String()
+	}
+	return data, mimeType, nil
+}
+
+func (mc *mediaConverter) attachmentToMatrix(ctx context.Context, attachment zuliphtml.Attachment) *bridgev2.ConvertedMessagePart {
+	var part *bridgev2.ConvertedMessagePart
+	var err error
+	uploadPath, isUpload := strings.CutPrefix(attachment.URL, mc.meta.URL+messages.UserUploadsPrefix)
+	if UseDirectMedia && isUpload {
+		part, err = mc.directMediaToMatrix(ctx, uploadPath, attachment)
+	} else {
+		part, err = mc.reuploadToMatrix(ctx, attachment)
+	}
+	if err != nil {
+		return &bridgev2.ConvertedMessagePart{
+			Type: event.EventMessage,
+			Content: &event.MessageEventContent{
+				MsgType: event.MsgNotice,
+				Body:    fmt.Sprintf("Failed to fetch attachment: %v", err),
+			},
+		}
+	}
+	return part
+}
+
+// directMediaToMatrix makes a message with a direct media URI, which is
+// downloaded from Zulip only when someone actually fetches it. The metadata
+// is taken from what Zulip included in the HTML or guessed from the file name.
+func (mc *mediaConverter) directMediaToMatrix(
+	ctx context.Context, uploadPath string, attachment zuliphtml.Attachment,
+) (*bridgev2.ConvertedMessagePart, error) {
+	mxc, err := mc.portal.Bridge.Matrix.GenerateContentURI(ctx, zid.MakeUploadMediaID(mc.loginID, uploadPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate content URI: %w", err)
+	}
+	mimeType := attachment.MimeType
+	if mimeType == "" {
+		mimeType = mime.TypeByExtension(path.Ext(uploadPath))
+	}
+	return &bridgev2.ConvertedMessagePart{
+		Type: event.EventMessage,
+		Content: &event.MessageEventContent{
+			MsgType: attachment.MsgType,
+			Body:    attachment.FileName,
+			URL:     mxc,
+			Info: &event.FileInfo{
+				MimeType: mimeType,
+				Width:    attachment.Width,
+				Height:   attachment.Height,
+			},
+		},
+	}, nil
+}
+
+func (mc *mediaConverter) reuploadToMatrix(ctx context.Context, attachment zuliphtml.Attachment) (*bridgev2.ConvertedMessagePart, error) {
+	resp, release, err := mc.download(ctx, attachment.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	content := &event.MessageEventContent{
+		MsgType: attachment.MsgType,
+		Body:    attachment.FileName,
+		Info: &event.FileInfo{
+			MimeType: resp.Header.Get("Content-Type"),
+			Width:    attachment.Width,
+			Height:   attachment.Height,
+		},
+	}
+	content.URL, content.File, err = mc.intent.UploadMediaStream(ctx, mc.portal.MXID, -1, true, func(file io.Writer) (*bridgev2.FileStreamResult, error) {
+		n, err := io.Copy(file, resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		content.Info.Size = int(n)
+		realFile := file.(*os.File)
+		if content.Info.MimeType == "" {
+			if _, err = realFile.Seek(0, io.SeekStart); err != nil {
+				return nil, fmt.Errorf("failed to seek to start: %w", err)
+			}
+			mime, err := mimetype.DetectReader(realFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to detect mime type: %w", err)
+			}
+			content.Info.MimeType = mime.String()
+		}
+		if err = addFileMetadata(ctx, realFile, attachment.MsgType, content.Info); err != nil {
+			return nil, err
+		}
+		return &bridgev2.FileStreamResult{
+			FileName: attachment.FileName,
+			MimeType: content.Info.MimeType,
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// The download slot isn't needed for the thumbnail anymore
+	release()
+	if attachment.ThumbnailURL != "" {
+		err = mc.thumbnailToMatrix(ctx, attachment.ThumbnailURL, content.Info)
+		if err != nil {
+			zerolog.Ctx(ctx).Warn().Err(err).Str("thumbnail_url", attachment.ThumbnailURL).Msg("Failed to reupload thumbnail")
+		}
+	}
+	return &bridgev2.ConvertedMessagePart{
+		Type:    event.EventMessage,
+		Content: content,
+	}, nil
+}
+
+// addFileMetadata fills the dimensions and blurhash of images and the
+// dimensions and duration of videos. Unsupported formats are ignored.
+func addFileMetadata(ctx context.Context, file *os.File, msgType event.MessageType, info *event.FileInfo) error {
+	log := zerolog.Ctx(ctx)
+	if msgType != event.MsgImage && msgType != event.MsgVideo {
+		return nil
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek to start: %w", err)
+	}
+	switch msgType {
+	case event.MsgImage:
+		cfg, _, err := image.DecodeConfig(file)
+		if err != nil {
+			log.Debug().Err(err).Msg("Failed to decode image config")
+			return nil
+		}
+		info.Width = cfg.Width
+		info.Height = cfg.Height
+		if cfg.Width*cfg.Height > maxBlurhashPixels {
+			return nil
+		} else if _, err = file.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to seek to start: %w", err)
+		}
+		img, _, err := image.Decode(file)
+		if err != nil {
+			log.Debug().Err(err).Msg("Failed to decode image for blurhash")
+			return nil
+		}
+		info.Blurhash = mediameta.Blurhash(img, 4, 3)
+	case event.MsgVideo:
+		videoInfo, err := mediameta.ParseMP4(file)
+		if err != nil {
+			log.Debug().Err(err).Msg("Failed to parse video metadata")
+			return nil
+		}
+		info.Width = videoInfo.Width
+		info.Height = videoInfo.Height
+		info.Duration = int(videoInfo.Duration.Milliseconds())
+	}
+	return nil
+}
+
+// thumbnailToMatrix reuploads a thumbnail generated by the Zulip server.
+func (mc *mediaConverter) thumbnailToMatrix(ctx context.Context, thumbnailURL string, info *event.FileInfo) error {
+	data, mimeType, err := mc.downloadSmall(ctx, thumbnailURL)
+	if err != nil {
+		return err
+	}
+	thumbnailInfo := &event.FileInfo{
+		MimeType: mimeType,
+		Size:     len(data),
+	}
+	if cfg, blurhash, ok := decodeSmallImage(data); ok {
+		thumbnailInfo.Width = cfg.Width
+		thumbnailInfo.Height = cfg.Height
+		if info.Blurhash == "" {
+			info.Blurhash = blurhash
+		}
+	} else {
+		// Thumbnails are usually WebP, which can't be decoded, so calculate the size instead
+		thumbnailInfo.Width, thumbnailInfo.Height = thumbnailSize(thumbnailURL, info.Width, info.Height)
+	}
+	info.ThumbnailURL, info.ThumbnailFile, err = mc.intent.UploadMedia(ctx, mc.portal.MXID, data, "", mimeType)
+	if err != nil {
+		return err
+	}
+	info.ThumbnailInfo = thumbnailInfo
+	return nil
+}
+
+// decodeSmallImage reads the size of an image that was downloaded into memory.
+// The image is only fully decoded for the blurhash if the size in its header is
+// at most maxBlurhashPixels, as the header of untrusted images can claim any size.
+func decodeSmallImage(data []byte) (cfg image.Config, blurhash string, ok bool) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return cfg, "", false
+	} else if cfg.Width*cfg.Height > maxBlurhashPixels {
+		return cfg, "", true
+	}
+	if img, _, err := image.Decode(bytes.NewReader(data)); err == nil {
+		blurhash = mediameta.Blurhash(img, 4, 3)
+	}
+	return cfg, blurhash, true
+}
+
+// thumbnailSize calculates the size of a thumbnail from the original size and
+// the bounding box in the thumbnail URL, e.g. .../image.png/840x560.webp.
+// Thumbnails keep the aspect ratio and are never larger than the original.
+func thumbnailSize(thumbnailURL string, width, height int) (int, int) {
+	format := path.Base(thumbnailURL)
+	format = strings.TrimSuffix(format, path.Ext(format))
+	// Animated thumbnails have a -anim suffix
+	format = strings.TrimSuffix(format, "-anim")
+	maxWidthStr, maxHeightStr, _ := strings.Cut(format, "x")
+	maxWidth, err1 := strconv.Atoi(maxWidthStr)
+	maxHeight, err2 := strconv.Atoi(maxHeightStr)
+	if err1 != nil || err2 != nil || width <= 0 || height <= 0 {
+		return 0, 0
+	}
+	scale := min(float64(maxWidth)/float64(width), float64(maxHeight)/float64(height), 1)
+	return int(float64(width) * scale), int(float64(height) * scale)
+}
+
+func (mc *mediaConverter) linkPreviewToMatrix(ctx context.Context, preview zuliphtml.LinkPreview) *event.BeeperLinkPreview {
+	out := &event.BeeperLinkPreview{
+		MatchedURL: preview.URL,
+		LinkPreview: event.LinkPreview{
+			CanonicalURL: preview.URL,
+			Title:        preview.Title,
+			Description:  preview.Description,
+		},
+	}
+	if preview.ImageURL != "" {
+		err := mc.linkPreviewImageToMatrix(ctx, preview.ImageURL, out)
+		if err != nil {
+			zerolog.Ctx(ctx).Warn().Err(err).Str("image_url", preview.ImageURL).Msg("Failed to reupload link preview image")
+		}
+	}
+	return out
+}
+
+func (mc *mediaConverter) linkPreviewImageToMatrix(ctx context.Context, imageURL string, preview *event.BeeperLinkPreview) error {
+	data, mimeType, err := mc.downloadSmall(ctx, imageURL)
+	if err != nil {
+		return err
+	}
+	if cfg, blurhash, ok := decodeSmallImage(data); ok {
+		preview.ImageWidth = event.IntOrString(cfg.Width)
+		preview.ImageHeight = event.IntOrString(cfg.Height)
+		preview.ImageBlurhash = blurhash
+	}
+	preview.ImageSize = event.IntOrString(len(data))
+	preview.ImageType = mimeType
+	preview.ImageURL, preview.ImageEncryption, err = mc.intent.UploadMedia(ctx, mc.portal.MXID, data, "", mimeType)
+	return err
+}
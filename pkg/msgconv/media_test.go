@@ -0,0 +1,55 @@
+package msgconv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeTestPNG(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := range width {
+		for y := range height {
+			img.Set(x, y, color.RGBA{R: uint8(x * 40), G: uint8(y * 40), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// withPNGSize changes the size in the header of a PNG without changing the image data.
+func withPNGSize(data []byte, width, height uint32) []byte {
+	data = bytes.Clone(data)
+	// The IHDR chunk comes right after the 8 byte signature, its data after the length and type
+	ihdr := data[16:29]
+	binary.BigEndian.PutUint32(ihdr[0:4], width)
+	binary.BigEndian.PutUint32(ihdr[4:8], height)
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(data[12:29]))
+	return data
+}
+
+func TestDecodeSmallImage(t *testing.T) {
+	small := encodeTestPNG(t, 6, 4)
+	cfg, blurhash, ok := decodeSmallImage(small)
+	assert.True(t, ok)
+	assert.Equal(t, 6, cfg.Width)
+	assert.Equal(t, 4, cfg.Height)
+	assert.NotEmpty(t, blurhash)
+
+	cfg, blurhash, ok = decodeSmallImage(withPNGSize(small, 30000, 30000))
+	assert.True(t, ok)
+	assert.Equal(t, 30000, cfg.Width)
+	assert.Equal(t, 30000, cfg.Height)
+	assert.Empty(t, blurhash)
+
+	_, _, ok = decodeSmallImage([]byte("not an image"))
+	assert.False(t, ok)
+}
@@ -0,0 +1,116 @@
+// Package mediameta extracts metadata from media files without external binaries.
+package mediameta
+
+import (
+	"image"
+	"math"
+	"strings"
+)
+
+const base83Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// blurhashSamples is the maximum number of pixels sampled in each direction.
+// Blurhashes only contain a few low-frequency components, so looking at every
+// pixel of a large image would only waste time.
+const blurhashSamples = 64
+
+// Blurhash encodes the image into a blurhash (https://blurha.sh) with the given
+// number of horizontal and vertical components (1-9).
+func Blurhash(img image.Image, xComponents, yComponents int) string {
+	bounds := img.Bounds()
+	width := min(bounds.Dx(), blurhashSamples)
+	height := min(bounds.Dy(), blurhashSamples)
+	if width == 0 || height == 0 {
+		return ""
+	}
+
+	// Convert a downsampled version of the image to linear RGB once
+	pixels := make([][3]float64, width*height)
+	for y := range height {
+		for x := range width {
+			r, g, b, _ := img.At(
+				bounds.Min.X+x*bounds.Dx()/width,
+				bounds.Min.Y+y*bounds.Dy()/height,
+			).RGBA()
+			pixels[y*width+x] = [3]float64{srgbToLinear(r >> 8), srgbToLinear(g >> 8), srgbToLinear(b >> 8)}
+		}
+	}
+
+	factors := make([][3]float64, 0, xComponents*yComponents)
+	for j := range yComponents {
+		for i := range xComponents {
+			var factor [3]float64
+			for y := range height {
+				basisY := math.Cos(math.Pi * float64(j) * float64(y) / float64(height))
+				for x := range width {
+					basis := math.Cos(math.Pi*float64(i)*float64(x)/float64(width)) * basisY
+					pixel := pixels[y*width+x]
+					factor[0] += basis * pixel[0]
+					factor[1] += basis * pixel[1]
+					factor[2] += basis * pixel[2]
+				}
+			}
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1
+			}
+			scale := normalisation / float64(width*height)
+			factors = append(factors, [3]float64{factor[0] * scale, factor[1] * scale, factor[2] * scale})
+		}
+	}
+
+	var hash strings.Builder
+	encodeBase83(&hash, (xComponents-1)+(yComponents-1)*9, 1)
+	dc, ac := factors[0], factors[1:]
+	maximumValue := 1.0
+	if len(ac) > 0 {
+		var actualMaximumValue float64
+		for _, factor := range ac {
+			actualMaximumValue = max(actualMaximumValue, math.Abs(factor[0]), math.Abs(factor[1]), math.Abs(factor[2]))
+		}
+		quantisedMaximumValue := int(max(0, min(82, math.Floor(actualMaximumValue*166-0.5))))
+		maximumValue = float64(quantisedMaximumValue+1) / 166
+		encodeBase83(&hash, quantisedMaximumValue, 1)
+	} else {
+		encodeBase83(&hash, 0, 1)
+	}
+	encodeBase83(&hash, linearToSRGB(dc[0])<<16+linearToSRGB(dc[1])<<8+linearToSRGB(dc[2]), 4)
+	for _, factor := range ac {
+		encodeBase83(&hash, encodeAC(factor, maximumValue), 2)
+	}
+	return hash.String()
+}
+
+func encodeAC(factor [3]float64, maximumValue float64) int {
+	quant := func(value float64) int {
+		return int(max(0, min(18, math.Floor(signPow(value/maximumValue, 0.5)*9+9.5))))
+	}
+	return quant(factor[0])*19*19 + quant(factor[1])*19 + quant(factor[2])
+}
+
+func encodeBase83(buf *strings.Builder, value, length int) {
+	for i := 1; i <= length; i++ {
+		digit := (value / int(math.Pow(83, float64(length-i)))) % 83
+		buf.WriteByte(base83Chars[digit])
+	}
+}
+
+func srgbToLinear(value uint32) float64 {
+	v := float64(value) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(value float64) int {
+	v := max(0, min(1, value))
+	if v <= 0.0031308 {
+		return int(math.Round(v * 12.92 * 255))
+	}
+	return int(math.Round((1.055*math.Pow(v, 1/2.4) - 0.055) * 255))
+}
+
+func signPow(value, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(value), exp), value)
+}
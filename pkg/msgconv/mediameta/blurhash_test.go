@@ -0,0 +1,61 @@
+package mediameta_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.mau.fi/mautrix-zulip/pkg/msgconv/mediameta"
+)
+
+func solidImage(c color.Color, width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestBlurhashSolid(t *testing.T) {
+	assert.Equal(t, "L00000fQfQfQfQfQfQfQfQfQfQfQ", mediameta.Blurhash(solidImage(color.Black, 32, 32), 4, 3))
+	// The discrete cosine sums aren't exactly zero, so only the DC component is checked for white
+	white := mediameta.Blurhash(solidImage(color.White, 200, 100), 4, 3)
+	assert.Len(t, white, 28)
+	assert.Equal(t, "TSUA", white[2:6])
+	assert.Equal(t, "00TSUA", mediameta.Blurhash(solidImage(color.White, 1, 1), 1, 1))
+}
+
+func TestBlurhashGradient(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	for y := range 200 {
+		for x := range 300 {
+			img.Set(x, y, color.RGBA{R: uint8(x * 255 / 300), G: uint8(y * 255 / 200), B: 128, A: 255})
+		}
+	}
+
+	hash := mediameta.Blurhash(img, 4, 3)
+	assert.Len(t, hash, 28)
+	assert.NotContains(t, hash, "fQfQfQ")
+}
+
+// TestBlurhashReference compares against hashes from the C reference
+// implementation (https://github.com/woltapp/blurhash/tree/master/C).
+func TestBlurhashReference(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 32, 24))
+	for y := range 24 {
+		for x := range 32 {
+			img.Set(x, y, color.RGBA{R: uint8(x * 8), G: uint8(255 - y*10), B: uint8(x * y * 3 % 256), A: 255})
+		}
+	}
+
+	assert.Equal(t, "L;H2[l6_wuX4q3X4jrf$gZfifRfl", mediameta.Blurhash(img, 4, 3))
+	assert.Equal(t, "T;H2[l6_wuq3X4jrgZfifRo[W:ju", mediameta.Blurhash(img, 3, 4))
+}
+
+func TestBlurhashEmpty(t *testing.T) {
+	assert.Empty(t, mediameta.Blurhash(image.NewRGBA(image.Rectangle{}), 4, 3))
+}
@@ -0,0 +1,143 @@
+package mediameta
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// VideoInfo is the metadata of a video file.
+type VideoInfo struct {
+	Duration time.Duration
+	Width    int
+	Height   int
+}
+
+var ErrNoMovieBox = errors.New("moov box not found")
+
+// containerBoxes are the boxes on the way from the root to the movie and
+// track headers, which are descended into.
+var containerBoxes = map[string]bool{
+	"moov": true,
+	"trak": true,
+}
+
+// maxHeaderBoxSize is the largest mvhd or tkhd box that is read into memory.
+const maxHeaderBoxSize = 1024
+
+// ParseMP4 reads the duration and dimensions of an MP4 or QuickTime video
+// from the movie header (mvhd) and the first track header (tkhd) with a size.
+// Media data is skipped by seeking, so it's cheap even for large files.
+func ParseMP4(r io.ReadSeeker) (*VideoInfo, error) {
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	info := &VideoInfo{}
+	foundMovie, err := parseBoxes(r, 0, end, info)
+	if err != nil {
+		return nil, err
+	} else if !foundMovie {
+		return nil, ErrNoMovieBox
+	}
+	return info, nil
+}
+
+func parseBoxes(r io.ReadSeeker, start, end int64, info *VideoInfo) (foundMovie bool, err error) {
+	for offset := start; offset+8 <= end; {
+		if _, err = r.Seek(offset, io.SeekStart); err != nil {
+			return
+		}
+		var header [16]byte
+		if _, err = io.ReadFull(r, header[:8]); err != nil {
+			return
+		}
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		boxType := string(header[4:8])
+		headerSize := int64(8)
+		switch size {
+		case 0:
+			size = end - offset
+		case 1:
+			if _, err = io.ReadFull(r, header[8:16]); err != nil {
+				return
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		}
+		if size < headerSize || offset+size > end {
+			return foundMovie, fmt.Errorf("invalid size of %q box at %d", boxType, offset)
+		}
+		bodyStart, bodyEnd := offset+headerSize, offset+size
+		switch {
+		case containerBoxes[boxType]:
+			var found bool
+			found, err = parseBoxes(r, bodyStart, bodyEnd, info)
+			if err != nil {
+				return
+			}
+			foundMovie = foundMovie || found || boxType == "moov"
+		case boxType == "mvhd":
+			if err = parseFullBox(r, bodyEnd-bodyStart, info.parseMovieHeader); err != nil {
+				return
+			}
+		case boxType == "tkhd" && info.Width == 0:
+			if err = parseFullBox(r, bodyEnd-bodyStart, info.parseTrackHeader); err != nil {
+				return
+			}
+		}
+		offset = bodyEnd
+	}
+	return
+}
+
+func parseFullBox(r io.Reader, size int64, parse func(version byte, body []byte) error) error {
+	if size < 4 || size > maxHeaderBoxSize {
+		return fmt.Errorf("unexpected header box size %d", size)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return err
+	}
+	return parse(data[0], data[4:])
+}
+
+func (info *VideoInfo) parseMovieHeader(version byte, body []byte) error {
+	var timescale, duration uint64
+	if version == 1 {
+		if len(body) < 28 {
+			return errors.New("mvhd box too short")
+		}
+		timescale = uint64(binary.BigEndian.Uint32(body[16:20]))
+		duration = binary.BigEndian.Uint64(body[20:28])
+	} else {
+		if len(body) < 16 {
+			return errors.New("mvhd box too short")
+		}
+		timescale = uint64(binary.BigEndian.Uint32(body[8:12]))
+		duration = uint64(binary.BigEndian.Uint32(body[12:16]))
+	}
+	if timescale > 0 && duration != 0xFFFFFFFF && duration != 0xFFFFFFFFFFFFFFFF {
+		info.Duration = time.Duration(duration) * time.Second / time.Duration(timescale)
+	}
+	return nil
+}
+
+func (info *VideoInfo) parseTrackHeader(version byte, body []byte) error {
+	// Skip the times, track ID and duration, which are longer in version 1
+	offset := 20
+	if version == 1 {
+		offset = 32
+	}
+	// reserved (8), layer (2), alternate group (2), volume (2), reserved (2) and matrix (36)
+	offset += 52
+	if len(body) < offset+8 {
+		return errors.New("tkhd box too short")
+	}
+	// Dimensions are 16.16 fixed point numbers
+	info.Width = int(binary.BigEndian.Uint32(body[offset:offset+4]) >> 16)
+	info.Height = int(binary.BigEndian.Uint32(body[offset+4:offset+8]) >> 16)
+	return nil
+}
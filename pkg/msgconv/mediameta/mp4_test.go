@@ -0,0 +1,62 @@
+package mediameta_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-zulip/pkg/msgconv/mediameta"
+)
+
+func box(boxType string, children ...[]byte) []byte {
+	body := bytes.Join(children, nil)
+	out := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	out = append(out, boxType...)
+	return append(out, body...)
+}
+
+func movieHeader(timescale, duration uint32) []byte {
+	body := make([]byte, 100)
+	binary.BigEndian.PutUint32(body[12:], timescale)
+	binary.BigEndian.PutUint32(body[16:], duration)
+	return box("mvhd", body)
+}
+
+func trackHeader(width, height uint32) []byte {
+	body := make([]byte, 84)
+	binary.BigEndian.PutUint32(body[76:], width<<16)
+	binary.BigEndian.PutUint32(body[80:], height<<16)
+	return box("tkhd", body)
+}
+
+func TestParseMP4(t *testing.T) {
+	file := bytes.Join([][]byte{
+		box("ftyp", []byte("isom\x00\x00\x02\x00isomiso2mp41")),
+		box("mdat", make([]byte, 4096)),
+		box("moov",
+			movieHeader(1000, 12345),
+			box("trak", trackHeader(0, 0)),
+			box("trak", trackHeader(1280, 720)),
+		),
+	}, nil)
+
+	info, err := mediameta.ParseMP4(bytes.NewReader(file))
+	require.NoError(t, err)
+	assert.Equal(t, &mediameta.VideoInfo{
+		Duration: 12345 * time.Millisecond,
+		Width:    1280,
+		Height:   720,
+	}, info)
+}
+
+func TestParseMP4NoMovie(t *testing.T) {
+	_, err := mediameta.ParseMP4(bytes.NewReader(box("ftyp", []byte("isom"))))
+	assert.ErrorIs(t, err, mediameta.ErrNoMovieBox)
+
+	_, err = mediameta.ParseMP4(bytes.NewReader([]byte("\x00\x00\x00\xFFmoov")))
+	assert.Error(t, err)
+}
@@ -0,0 +1,85 @@
+package zuliphtml
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// LinkPreview is a link preview embedded in a Zulip message. The image is a
+// URL on the Zulip server (usually proxied through Camo), which still needs
+// to be reuploaded to Matrix.
+type LinkPreview struct {
+	URL         string
+	Title       string
+	Description string
+	ImageURL    string
+}
+
+var backgroundImageRegex = regexp.MustCompile(`background-image:\s*url\(\s*(?:"((?:[^"\\]|\\.)*)"|'((?:[^'\\]|\\.)*)'|([^)\s]*))\s*\)`)
+
+// parseBackgroundImage extracts the URL from a `background-image: url(...)` style.
+func parseBackgroundImage(style string) string {
+	match := backgroundImageRegex.FindStringSubmatch(style)
+	if match == nil {
+		return ""
+	}
+	rawURL := match[1] + match[2] + match[3]
+	return strings.NewReplacer(`\"`, `"`, `\'`, `'`, `\\`, `\`).Replace(rawURL)
+}
+
+// processMessageEmbed converts the open graph previews that Zulip adds after
+// messages into link previews.
+func (zhp *zulipHTMLParser) processMessageEmbed(node *html.Node) (bool, error) {
+	var preview LinkPreview
+	if imageLink := findChild(node, atom.A, "message_embed_image"); imageLink != nil {
+		preview.URL, _ = getAttribute(imageLink.Attr, "href")
+		style, _ := getAttribute(imageLink.Attr, "style")
+		if imageURL := parseBackgroundImage(style); imageURL != "" {
+			preview.ImageURL = zhp.makeAbsoluteURL(imageURL)
+		}
+	}
+	if titleNode := findChild(node, atom.Div, "message_embed_title"); titleNode != nil {
+		preview.Title = strings.TrimSpace(nodeText(titleNode))
+		if titleLink := findChild(titleNode, atom.A, ""); titleLink != nil && preview.URL == "" {
+			preview.URL, _ = getAttribute(titleLink.Attr, "href")
+		}
+	}
+	if descriptionNode := findChild(node, atom.Div, "message_embed_description"); descriptionNode != nil {
+		preview.Description = strings.TrimSpace(nodeText(descriptionNode))
+	}
+	if preview.URL == "" {
+		return false, nil
+	}
+	preview.URL = zhp.makeAbsoluteURL(preview.URL)
+	zhp.linkPreviews = append(zhp.linkPreviews, preview)
+	node.Parent.RemoveChild(node)
+	return true, nil
+}
+
+// processVideoEmbed converts YouTube, Vimeo and other oEmbed video thumbnails
+// into link previews. The video link itself is already in the message text.
+func (zhp *zulipHTMLParser) processVideoEmbed(node *html.Node) (bool, error) {
+	videoLink := findChild(node, atom.A, "")
+	if videoLink == nil {
+		return false, nil
+	}
+	href, ok := getAttribute(videoLink.Attr, "href")
+	if !ok {
+		return false, nil
+	}
+	preview := LinkPreview{
+		URL: zhp.makeAbsoluteURL(href),
+	}
+	preview.Title, _ = getAttribute(videoLink.Attr, "title")
+	if thumbnail := findChild(videoLink, atom.Img, ""); thumbnail != nil {
+		if src, ok := getAttribute(thumbnail.Attr, "src"); ok {
+			preview.ImageURL = zhp.makeAbsoluteURL(src)
+		}
+	}
+	zhp.linkPreviews = append(zhp.linkPreviews, preview)
+	node.Parent.RemoveChild(node)
+	return true, nil
+}
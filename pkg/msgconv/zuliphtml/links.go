@@ -0,0 +1,133 @@
+package zuliphtml
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"golang.org/x/net/html"
+	"maunium.net/go/mautrix/bridgev2/networkid"
+
+	"go.mau.fi/mautrix-zulip/pkg/zid"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/narrow"
+)
+
+func (zhp *zulipHTMLParser) processChannelLink(node *html.Node) (bool, error) {
+	return zhp.processNarrowLink(node)
+}
+
+func (zhp *zulipHTMLParser) processTopicLink(node *html.Node) (bool, error) {
+	return zhp.processNarrowLink(node)
+}
+
+func (zhp *zulipHTMLParser) processMessageLink(node *html.Node) (bool, error) {
+	return zhp.processNarrowLink(node)
+}
+
+// processNarrowLink replaces the href of a link and drops the Zulip-specific
+// attributes. The link isn't marked as handled, so the children are still
+// processed normally (the rewritten href is left as is).
+func (zhp *zulipHTMLParser) processNarrowLink(node *html.Node) (bool, error) {
+	hrefIdx := getAttributeIndex(node.Attr, "href")
+	if hrefIdx < 0 {
+		return false, nil
+	}
+	node.Attr = []html.Attribute{{
+		Key: "href",
+		Val: zhp.rewriteLink(node.Attr[hrefIdx].Val),
+	}}
+	return false, nil
+}
+
+// rewriteLink turns links to channels, topics and messages on the Zulip
+// server into matrix.to permalinks if they're bridged. Other links are made
+// absolute.
+func (zhp *zulipHTMLParser) rewriteLink(href string) string {
+	absURL := zhp.makeAbsoluteURL(href)
+	if zhp.br == nil || zhp.baseURL == "" || !strings.HasPrefix(absURL, zhp.baseURL+"/") {
+		return absURL
+	}
+	filter, err := narrow.ParseURL(absURL)
+	if err != nil {
+		return absURL
+	}
+	permalink, err := zhp.resolveNarrow(filter)
+	if err != nil {
+		zerolog.Ctx(zhp.ctx).Warn().Err(err).Str("url", absURL).Msg("Failed to resolve Zulip link to Matrix permalink")
+	}
+	if permalink == "" {
+		return absURL
+	}
+	return permalink
+}
+
+func (zhp *zulipHTMLParser) resolveNarrow(filter narrow.Filter) (string, error) {
+	var streamID int
+	if channel, ok := filter.Get(narrow.Channel); ok {
+		streamID, ok = narrow.ParseChannelOperand(channel)
+		if !ok {
+			return "", nil
+		}
+	}
+	var channelPortalID networkid.PortalID
+	if streamID != 0 {
+		channelPortalID = zid.MakeChannelPortalID(zhp.realm, streamID)
+	}
+	for _, op := range []narrow.Operator{narrow.Near, narrow.With, narrow.ID} {
+		operand, ok := filter.Get(op)
+		if !ok {
+			continue
+		}
+		messageID, err := strconv.Atoi(operand.(string))
+		if err != nil {
+			break
+		}
+		link, err := zhp.messagePermalink(zid.MakeMessageID(zhp.realm, messageID), "")
+		if link != "" || err != nil {
+			return link, err
+		}
+		break
+	}
+	if streamID == 0 {
+		return "", nil
+	}
+	if topic, ok := filter.Get(narrow.Topic); ok {
+		link, err := zhp.messagePermalink(zid.MakeTopicMessageID(zhp.realm, topic.(string)), channelPortalID)
+		if link != "" || err != nil {
+			return link, err
+		}
+		// The topic may be bridged as a separate room instead of a thread
+		link, err = zhp.portalPermalink(zid.MakeTopicPortalID(zhp.realm, streamID, topic.(string)))
+		if link != "" || err != nil {
+			return link, err
+		}
+	}
+	return zhp.portalPermalink(channelPortalID)
+}
+
+// portalPermalink returns a matrix.to link to the room of the given portal.
+func (zhp *zulipHTMLParser) portalPermalink(portalID networkid.PortalID) (string, error) {
+	portalKey := networkid.PortalKey{ID: portalID}
+	if zhp.br.Config.SplitPortals {
+		portalKey.Receiver = zhp.receiver
+	}
+	portal, err := zhp.br.GetExistingPortalByKey(zhp.ctx, portalKey)
+	if err != nil || portal == nil || portal.MXID == "" {
+		return "", err
+	}
+	return portal.MXID.URI(zhp.br.Matrix.ServerName()).MatrixToURL(), nil
+}
+
+// messagePermalink returns a matrix.to link to the given message. If portalID
+// is set, the message must be in that portal.
+func (zhp *zulipHTMLParser) messagePermalink(messageID networkid.MessageID, portalID networkid.PortalID) (string, error) {
+	msg, err := zhp.br.DB.Message.GetFirstPartByID(zhp.ctx, zhp.receiver, messageID)
+	if err != nil || msg == nil || (portalID != "" && msg.Room.ID != portalID) {
+		return "", err
+	}
+	portal, err := zhp.br.GetExistingPortalByKey(zhp.ctx, msg.Room)
+	if err != nil || portal == nil || portal.MXID == "" {
+		return "", err
+	}
+	return portal.MXID.EventURI(msg.MXID, zhp.br.Matrix.ServerName()).MatrixToURL(), nil
+}
@@ -0,0 +1,103 @@
+package zuliphtml
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/rs/zerolog"
+	"golang.org/x/net/html"
+	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/id"
+
+	"go.mau.fi/mautrix-zulip/pkg/zid"
+)
+
+// MaxExpandedMentions is the largest number of users a group or topic
+// mention is expanded to. Larger groups are left as plain text, so that
+// mentioning a big group doesn't ping lots of Matrix users at once.
+const MaxExpandedMentions = 50
+
+// MentionResolver looks up the users behind group and topic mentions.
+type MentionResolver interface {
+	// GetUserGroupMembers returns the IDs of all members of a user group, including subgroups.
+	GetUserGroupMembers(ctx context.Context, groupID int) ([]int, error)
+	// GetTopicParticipants returns the IDs of users who have recently sent messages to the topic.
+	GetTopicParticipants(ctx context.Context, streamID int, topic string) ([]int, error)
+}
+
+func (zhp *zulipHTMLParser) userMXID(userID int) (id.UserID, error) {
+	ghost, err := zhp.br.GetGhostByID(zhp.ctx, zid.MakeUserID(zhp.realm, userID))
+	if err != nil {
+		return "", fmt.Errorf("failed to get ghost of mentioned user %d: %w", userID, err)
+	}
+	mxid := ghost.Intent.GetMXID()
+	var userLogin *bridgev2.UserLogin
+	userLogin, err = zhp.br.GetExistingUserLoginByID(zhp.ctx, zid.MakeUserLoginID(zhp.realm, userID))
+	if err != nil {
+		return "", fmt.Errorf("failed to get user login of mentioned user %d: %w", userID, err)
+	} else if userLogin != nil {
+		mxid = userLogin.UserMXID
+	}
+	return mxid, nil
+}
+
+func (zhp *zulipHTMLParser) processUserGroupMention(node *html.Node) (bool, error) {
+	groupIDStr, ok := getAttribute(node.Attr, "data-user-group-id")
+	if !ok {
+		return false, nil
+	}
+	groupID, err := strconv.Atoi(groupIDStr)
+	if err != nil {
+		return false, nil
+	}
+	if zhp.resolver != nil && !slices.Contains(getClass(node.Attr), "silent") {
+		members, err := zhp.resolver.GetUserGroupMembers(zhp.ctx, groupID)
+		if err != nil {
+			zerolog.Ctx(zhp.ctx).Warn().Err(err).Int("group_id", groupID).Msg("Failed to get members of mentioned user group")
+		} else if err = zhp.addMentions(members); err != nil {
+			return false, err
+		}
+	}
+	zhp.replaceWithText(node)
+	return true, nil
+}
+
+func (zhp *zulipHTMLParser) processTopicMention(node *html.Node) (bool, error) {
+	if zhp.resolver != nil && zhp.streamID != 0 && !slices.Contains(getClass(node.Attr), "silent") {
+		participants, err := zhp.resolver.GetTopicParticipants(zhp.ctx, zhp.streamID, zhp.topic)
+		if err != nil {
+			zerolog.Ctx(zhp.ctx).Warn().Err(err).Msg("Failed to get participants of mentioned topic")
+		} else if err = zhp.addMentions(participants); err != nil {
+			return false, err
+		}
+	}
+	zhp.replaceWithText(node)
+	return true, nil
+}
+
+// addMentions adds the given users to the mentions of the message, unless there are too many of them.
+func (zhp *zulipHTMLParser) addMentions(userIDs []int) error {
+	if len(userIDs) > MaxExpandedMentions {
+		zerolog.Ctx(zhp.ctx).Debug().
+			Int("user_count", len(userIDs)).
+			Msg("Not expanding mention with too many users")
+		return nil
+	}
+	for _, userID := range userIDs {
+		mxid, err := zhp.userMXID(userID)
+		if err != nil {
+			return err
+		}
+		zhp.mentions.Add(mxid)
+	}
+	return nil
+}
+
+func (zhp *zulipHTMLParser) replaceWithText(node *html.Node) {
+	*node = rebuildNode(node, &html.Node{
+		Type: html.TextNode,
+		Data: nodeText(node),
+	})
+}
@@ -7,41 +7,111 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/rs/zerolog"
 	"golang.org/x/net/html"
 	"golang.org/x/net/html/atom"
 	"maunium.net/go/mautrix/bridgev2"
+	"maunium.net/go/mautrix/bridgev2/networkid"
 	"maunium.net/go/mautrix/event"
 
 	"go.mau.fi/mautrix-zulip/pkg/msgconv/zulipemoji"
 	"go.mau.fi/mautrix-zulip/pkg/zid"
 )
 
-func Parse(
-	ctx context.Context, bridge *bridgev2.Bridge, baseURL, inputHTML string,
-) (outputHTML string, attachments []Attachment, mentions *event.Mentions, err error) {
+// Params are the context needed to convert a single message.
+type Params struct {
+	Bridge *bridgev2.Bridge
+	// Receiver is the user login the message was received through, used to
+	// find portals and messages that links point at. The realm of the login
+	// is used for the IDs of mentioned users.
+	Receiver networkid.UserLoginID
+	BaseURL  string
+	// StreamID and Topic are where the message was sent, used for @topic mentions.
+	StreamID int
+	Topic    string
+	// MeMessage is set for /me messages. The rendered "/me" prefix is removed from them.
+	MeMessage bool
+	// Portal is the portal the message is bridged into. Quote-and-reply
+	// blocks only become Matrix replies if the quoted message is in the same portal.
+	Portal networkid.PortalKey
+	// Resolver is used to expand group and topic mentions. They're left as
+	// plain text if it's nil.
+	Resolver MentionResolver
+}
+
+// Result is the output of converting a single message.
+type Result struct {
+	HTML         string
+	Attachments  []Attachment
+	LinkPreviews []LinkPreview
+	Mentions     *event.Mentions
+	// ReplyTo is the quoted message if the message started with a quote-and-reply block.
+	ReplyTo *networkid.MessageID
+}
+
+// Parse converts Zulip-rendered message HTML into Matrix HTML.
+//
+// If params.Bridge is nil, mentions and links aren't resolved into Matrix
+// users and rooms.
+func Parse(ctx context.Context, params Params, inputHTML string) (*Result, error) {
+	realm, _ := zid.ParseUserLoginID(params.Receiver)
 	parser := &zulipHTMLParser{
-		ctx:     ctx,
-		br:      bridge,
-		baseURL: baseURL,
+		ctx:      ctx,
+		br:       params.Bridge,
+		receiver: params.Receiver,
+		realm:    realm,
+		portal:   params.Portal,
+		baseURL:  params.BaseURL,
+		streamID: params.StreamID,
+		topic:    params.Topic,
+		resolver: params.Resolver,
+		isMe:     params.MeMessage,
 	}
-	err = parser.Parse(inputHTML)
-	return parser.output, parser.attachments, &parser.mentions, err
+	err := parser.Parse(inputHTML)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{
+		HTML:         parser.output,
+		Attachments:  parser.attachments,
+		LinkPreviews: parser.linkPreviews,
+		Mentions:     &parser.mentions,
+		ReplyTo:      parser.replyTo,
+	}, nil
 }
 
 type Attachment struct {
 	URL      string
 	MsgType  event.MessageType
 	FileName string
+	// MimeType, Width and Height are set if the server included the metadata
+	// of the original file in the thumbnail (Zulip 9.0+).
+	MimeType string `json:",omitempty"`
+	Width    int    `json:",omitempty"`
+	Height   int    `json:",omitempty"`
+	// ThumbnailURL is a server-generated thumbnail of the file, if there is one.
+	ThumbnailURL string `json:",omitempty"`
 }
 
 type zulipHTMLParser struct {
 	ctx         context.Context
 	br          *bridgev2.Bridge
+	receiver    networkid.UserLoginID
+	realm       zid.Realm
+	portal      networkid.PortalKey
 	baseURL     string
+	streamID    int
+	topic       string
+	resolver    MentionResolver
+	isMe        bool
 	output      string
 	attachments []Attachment
 	mentions    event.Mentions
+
+	linkPreviews []LinkPreview
+	replyTo      *networkid.MessageID
 }
 
 func (zhp *zulipHTMLParser) Parse(input string) error {
@@ -60,6 +130,13 @@ func (zhp *zulipHTMLParser) Parse(input string) error {
 		Type:       html.DocumentNode,
 		FirstChild: node,
 	})
+	err = zhp.processQuoteReply(node)
+	if err != nil {
+		return err
+	}
+	if zhp.isMe {
+		stripMePrefix(node)
+	}
 	err = zhp.processChildren(node)
 	if err != nil {
 		return err
@@ -73,12 +150,35 @@ func (zhp *zulipHTMLParser) Parse(input string) error {
 	return nil
 }
 
-func (zhp *zulipHTMLParser) processChildren(node *html.Node) error {
+// stripMePrefix removes the "/me " prefix that Zulip leaves in the rendered
+// content of /me messages. It's in the first text node of the message.
+func stripMePrefix(node *html.Node) bool {
 	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		switch child.Type {
+		case html.TextNode:
+			if strings.TrimSpace(child.Data) == "" {
+				continue
+			}
+			child.Data = strings.TrimPrefix(strings.TrimLeft(child.Data, " "), "/me ")
+			return true
+		case html.ElementNode:
+			if stripMePrefix(child) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (zhp *zulipHTMLParser) processChildren(node *html.Node) error {
+	for child := node.FirstChild; child != nil; {
+		// Handlers may remove the node, so get the next sibling first
+		next := child.NextSibling
 		err := zhp.processSingleNode(child)
 		if err != nil {
 			return err
 		}
+		child = next
 	}
 	return nil
 }
@@ -101,7 +201,7 @@ func (zhp *zulipHTMLParser) processSingleNode(node *html.Node) error {
 	case atom.A:
 		hrefIdx := getAttributeIndex(node.Attr, "href")
 		if hrefIdx >= 0 {
-			node.Attr[hrefIdx].Val = zhp.makeAbsoluteURL(node.Attr[hrefIdx].Val)
+			node.Attr[hrefIdx].Val = zhp.rewriteLink(node.Attr[hrefIdx].Val)
 		}
 	}
 	return zhp.processChildren(node)
@@ -117,12 +217,17 @@ var handlers = map[atom.Atom]map[string]func(*zulipHTMLParser, *html.Node) (bool
 		"codehilite":           (*zulipHTMLParser).processCodeBlock,
 		"message_inline_image": (*zulipHTMLParser).processInlineMedia,
 		"spoiler-block":        (*zulipHTMLParser).processSpoilerBlock,
+		"message_embed":        (*zulipHTMLParser).processMessageEmbed,
+		"youtube-video":        (*zulipHTMLParser).processVideoEmbed,
+		"vimeo-video":          (*zulipHTMLParser).processVideoEmbed,
+		"embed-video":          (*zulipHTMLParser).processVideoEmbed,
 	},
 	atom.Span: {
-		"emoji":         (*zulipHTMLParser).processEmoji,
-		"katex-display": (*zulipHTMLParser).processKatex,
-		"user-mention":  (*zulipHTMLParser).processUserMention,
-		"topic-mention": (*zulipHTMLParser).processTopicMention,
+		"emoji":              (*zulipHTMLParser).processEmoji,
+		"katex-display":      (*zulipHTMLParser).processKatex,
+		"user-mention":       (*zulipHTMLParser).processUserMention,
+		"user-group-mention": (*zulipHTMLParser).processUserGroupMention,
+		"topic-mention":      (*zulipHTMLParser).processTopicMention,
 	},
 	atom.Img: {
 		"emoji": (*zulipHTMLParser).processCustomEmoji,
@@ -130,22 +235,15 @@ var handlers = map[atom.Atom]map[string]func(*zulipHTMLParser, *html.Node) (bool
 	atom.Audio: {
 		"": (*zulipHTMLParser).processInlineAudio,
 	},
-}
-
-func (zhp *zulipHTMLParser) processChannelLink(node *html.Node) (bool, error) {
-	return false, nil
-}
-
-func (zhp *zulipHTMLParser) processTopicLink(node *html.Node) (bool, error) {
-	return false, nil
-}
-
-func (zhp *zulipHTMLParser) processMessageLink(node *html.Node) (bool, error) {
-	return false, nil
+	atom.Time: {
+		"": (*zulipHTMLParser).processTime,
+	},
 }
 
 func (zhp *zulipHTMLParser) processCodeBlock(node *html.Node) (bool, error) {
 	codeBlockLanguage, _ := getAttribute(node.Attr, "data-code-language")
+	// Pygments puts line numbers in separate elements, which shouldn't end up in the code.
+	removeChildren(node, "linenos")
 	codeBlock := nodeText(node)
 	var codeAttr []html.Attribute
 	if codeBlockLanguage != "" {
@@ -170,6 +268,10 @@ func (zhp *zulipHTMLParser) processCodeBlock(node *html.Node) (bool, error) {
 	return true, nil
 }
 
+// thumbnailPathPrefix is the path of thumbnails generated by the server.
+// Older servers link directly to the original image or to Camo instead.
+const thumbnailPathPrefix = "/user_uploads/thumbnail/"
+
 func (zhp *zulipHTMLParser) processInlineMedia(node *html.Node) (bool, error) {
 	mediaLink := findChild(node, atom.A, "")
 	if mediaLink == nil {
@@ -188,11 +290,22 @@ func (zhp *zulipHTMLParser) processInlineMedia(node *html.Node) (bool, error) {
 		parts := strings.SplitN(href, "?", 2)
 		title = path.Base(parts[0])
 	}
-	zhp.attachments = append(zhp.attachments, Attachment{
+	attachment := Attachment{
 		URL:      zhp.makeAbsoluteURL(href),
 		MsgType:  msgType,
 		FileName: title,
-	})
+	}
+	if thumbnail := findChild(mediaLink, atom.Img, ""); thumbnail != nil {
+		if src, _ := getAttribute(thumbnail.Attr, "src"); strings.HasPrefix(src, thumbnailPathPrefix) {
+			attachment.ThumbnailURL = zhp.makeAbsoluteURL(src)
+		}
+		attachment.MimeType, _ = getAttribute(thumbnail.Attr, "data-original-content-type")
+		dimensions, _ := getAttribute(thumbnail.Attr, "data-original-dimensions")
+		width, height, _ := strings.Cut(dimensions, "x")
+		attachment.Width, _ = strconv.Atoi(width)
+		attachment.Height, _ = strconv.Atoi(height)
+	}
+	zhp.attachments = append(zhp.attachments, attachment)
 	node.Parent.RemoveChild(node)
 	return true, nil
 }
@@ -204,6 +317,28 @@ func (zhp *zulipHTMLParser) makeAbsoluteURL(href string) string {
 	return href
 }
 
+// timestampFormat is used for global time widgets. Zulip clients show them
+// in the local timezone of the viewer, which isn't possible in Matrix, so
+// they're rendered in UTC.
+const timestampFormat = "Mon, Jan 2, 2006, 15:04 MST"
+
+func (zhp *zulipHTMLParser) processTime(node *html.Node) (bool, error) {
+	datetime, ok := getAttribute(node.Attr, "datetime")
+	if !ok {
+		return false, nil
+	}
+	ts, err := time.Parse(time.RFC3339, datetime)
+	if err != nil {
+		zerolog.Ctx(zhp.ctx).Debug().Err(err).Str("datetime", datetime).Msg("Failed to parse time widget")
+		return false, nil
+	}
+	*node = rebuildNode(node, &html.Node{
+		Type: html.TextNode,
+		Data: ts.UTC().Format(timestampFormat),
+	})
+	return true, nil
+}
+
 func (zhp *zulipHTMLParser) processInlineAudio(node *html.Node) (bool, error) {
 	href, ok := getAttribute(node.Attr, "src")
 	if !ok {
@@ -225,7 +360,7 @@ func (zhp *zulipHTMLParser) processSpoilerBlock(node *html.Node) (bool, error) {
 	if header == nil || content == nil {
 		return false, nil
 	}
-	reason := nodeText(header)
+	reason := strings.TrimSpace(nodeText(header))
 	*node = rebuildNode(node, &html.Node{
 		Type:       html.ElementNode,
 		DataAtom:   atom.Span,
@@ -286,19 +421,12 @@ func (zhp *zulipHTMLParser) processUserMention(node *html.Node) (bool, error) {
 			Data: "@room",
 		})
 		zhp.mentions.Room = true
+	} else if zhp.br == nil {
+		zhp.replaceWithText(node)
 	} else if userID, err := strconv.Atoi(userIDStr); err == nil {
-		var ghost *bridgev2.Ghost
-		var userLogin *bridgev2.UserLogin
-		ghost, err = zhp.br.GetGhostByID(zhp.ctx, zid.MakeUserID(userID))
-		if err != nil {
-			return false, fmt.Errorf("failed to get ghost of mentioned user %d: %w", userID, err)
-		}
-		mxid := ghost.Intent.GetMXID()
-		userLogin, err = zhp.br.GetExistingUserLoginByID(zhp.ctx, zid.MakeUserLoginID(userID))
+		mxid, err := zhp.userMXID(userID)
 		if err != nil {
-			return false, fmt.Errorf("failed to get user login of mentioned user %d: %w", userID, err)
-		} else if userLogin != nil {
-			mxid = userLogin.UserMXID
+			return false, err
 		}
 
 		if !slices.Contains(getClass(node.Attr), "silent") {
@@ -315,7 +443,3 @@ func (zhp *zulipHTMLParser) processUserMention(node *html.Node) (bool, error) {
 	}
 	return true, nil
 }
-
-func (zhp *zulipHTMLParser) processTopicMention(node *html.Node) (bool, error) {
-	return false, nil
-}
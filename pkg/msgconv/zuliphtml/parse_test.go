@@ -0,0 +1,93 @@
+package zuliphtml_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-zulip/pkg/msgconv/zuliphtml"
+)
+
+// corpusEntry is a message as rendered by a Zulip server and the expected conversion result.
+type corpusEntry struct {
+	Name         string                  `json:"name"`
+	ZulipHTML    string                  `json:"zulip_html"`
+	MatrixHTML   string                  `json:"matrix_html"`
+	Attachments  []zuliphtml.Attachment  `json:"attachments"`
+	LinkPreviews []zuliphtml.LinkPreview `json:"link_previews"`
+	RoomMention  bool                    `json:"room_mention"`
+	MeMessage    bool                    `json:"me_message"`
+}
+
+func TestParseCorpus(t *testing.T) {
+	data, err := os.ReadFile("testdata/corpus.json")
+	require.NoError(t, err)
+
+	var corpus []corpusEntry
+	require.NoError(t, json.Unmarshal(data, &corpus))
+
+	for _, entry := range corpus {
+		t.Run(entry.Name, func(t *testing.T) {
+			result, err := zuliphtml.Parse(context.Background(), zuliphtml.Params{
+				BaseURL:   "https://zulip.example.com",
+				MeMessage: entry.MeMessage,
+			}, entry.ZulipHTML)
+			require.NoError(t, err)
+
+			assert.Equal(t, entry.MatrixHTML, result.HTML)
+			assert.Equal(t, entry.Attachments, result.Attachments)
+			assert.Equal(t, entry.LinkPreviews, result.LinkPreviews)
+			assert.Equal(t, entry.RoomMention, result.Mentions.Room)
+			assert.Nil(t, result.ReplyTo)
+		})
+	}
+}
+
+// countingResolver counts lookups and doesn't return any users.
+type countingResolver struct {
+	groupCalls int
+	topicCalls int
+}
+
+func (cr *countingResolver) GetUserGroupMembers(ctx context.Context, groupID int) ([]int, error) {
+	cr.groupCalls++
+	return nil, nil
+}
+
+func (cr *countingResolver) GetTopicParticipants(ctx context.Context, streamID int, topic string) ([]int, error) {
+	cr.topicCalls++
+	return nil, nil
+}
+
+func TestParseSilentMentions(t *testing.T) {
+	tests := []struct {
+		name       string
+		html       string
+		groupCalls int
+		topicCalls int
+	}{
+		{"topic", `<p><span class="topic-mention">@topic</span> hi</p>`, 0, 1},
+		{"silent topic", `<p><span class="topic-mention silent">topic</span> hi</p>`, 0, 0},
+		{"group", `<p><span class="user-group-mention" data-user-group-id="3">@backend</span> hi</p>`, 1, 0},
+		{"silent group", `<p><span class="user-group-mention silent" data-user-group-id="3">backend</span> hi</p>`, 0, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resolver := &countingResolver{}
+			result, err := zuliphtml.Parse(context.Background(), zuliphtml.Params{
+				BaseURL:  "https://zulip.example.com",
+				StreamID: 5,
+				Topic:    "Deploy",
+				Resolver: resolver,
+			}, test.html)
+			require.NoError(t, err)
+			assert.Equal(t, test.groupCalls, resolver.groupCalls)
+			assert.Equal(t, test.topicCalls, resolver.topicCalls)
+			assert.Empty(t, result.Mentions.UserIDs)
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package zuliphtml
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"go.mau.fi/mautrix-zulip/pkg/zid"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/narrow"
+)
+
+// processQuoteReply turns a leading quote-and-reply block into a reply.
+//
+// Zulip has no native replies. Instead, its clients insert
+// `@_**Name|id** [said](.../near/<message id>):` followed by a quote block,
+// which is rendered as a paragraph with a silent mention and a message link,
+// followed by a blockquote. If the quoted message is bridged into the same
+// portal, both are removed and the message is marked as a reply instead.
+func (zhp *zulipHTMLParser) processQuoteReply(root *html.Node) error {
+	if zhp.br == nil {
+		return nil
+	}
+	header := root.FirstChild
+	if header != nil && header.Type != html.ElementNode {
+		header = nextElementSibling(header)
+	}
+	if header == nil || header.DataAtom != atom.P {
+		return nil
+	}
+	quote := nextElementSibling(header)
+	if quote == nil || quote.DataAtom != atom.Blockquote {
+		return nil
+	}
+	messageID, ok := parseQuoteReplyHeader(header)
+	if !ok {
+		return nil
+	}
+	msg, err := zhp.br.DB.Message.GetFirstPartByID(zhp.ctx, zhp.receiver, zid.MakeMessageID(zhp.realm, messageID))
+	if err != nil {
+		return err
+	} else if msg == nil || (zhp.portal.ID != "" && msg.Room != zhp.portal) {
+		return nil
+	}
+	zhp.replyTo = &msg.ID
+	for _, node := range []*html.Node{header, quote} {
+		next := node.NextSibling
+		root.RemoveChild(node)
+		// Drop the newline between the quote and the actual reply too
+		if next != nil && next.Type == html.TextNode && strings.TrimSpace(next.Data) == "" {
+			root.RemoveChild(next)
+		}
+	}
+	return nil
+}
+
+// parseQuoteReplyHeader checks if the paragraph is a `@_**Name|id** [said](link):`
+// header and returns the ID of the quoted message.
+func parseQuoteReplyHeader(header *html.Node) (int, bool) {
+	var children []*html.Node
+	for child := header.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.TextNode && strings.TrimSpace(child.Data) == "" {
+			continue
+		}
+		children = append(children, child)
+	}
+	if len(children) != 3 {
+		return 0, false
+	}
+	mention, link, colon := children[0], children[1], children[2]
+	if mention.DataAtom != atom.Span || !slices.Contains(getClass(mention.Attr), "user-mention") ||
+		link.DataAtom != atom.A ||
+		colon.Type != html.TextNode || strings.TrimSpace(colon.Data) != ":" {
+		return 0, false
+	}
+	href, ok := getAttribute(link.Attr, "href")
+	if !ok {
+		return 0, false
+	}
+	filter, err := narrow.ParseURL(href)
+	if err != nil {
+		return 0, false
+	}
+	near, ok := filter.Get(narrow.Near)
+	if !ok {
+		return 0, false
+	}
+	messageID, err := strconv.Atoi(near.(string))
+	return messageID, err == nil
+}
@@ -0,0 +1,76 @@
+//go:build cgo
+
+package zuliphtml_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maunium.net/go/mautrix/bridgev2/database"
+	"maunium.net/go/mautrix/bridgev2/networkid"
+
+	"go.mau.fi/mautrix-zulip/pkg/msgconv/internal/bridgetest"
+	"go.mau.fi/mautrix-zulip/pkg/msgconv/zuliphtml"
+	"go.mau.fi/mautrix-zulip/pkg/zid"
+)
+
+func TestParseQuoteReply(t *testing.T) {
+	br := bridgetest.New(t)
+	realm := zid.Realm("0123abcd")
+	portal := networkid.PortalKey{ID: zid.MakeChannelPortalID(realm, 9)}
+	otherPortal := networkid.PortalKey{ID: zid.MakeChannelPortalID(realm, 10)}
+	bridgetest.AddMessage(t, br, &database.Message{
+		ID:       zid.MakeMessageID(realm, 123),
+		MXID:     "$quoted",
+		Room:     portal,
+		SenderID: zid.MakeUserID(realm, 8),
+	})
+	bridgetest.AddMessage(t, br, &database.Message{
+		ID:       zid.MakeMessageID(realm, 124),
+		MXID:     "$elsewhere",
+		Room:     otherPortal,
+		SenderID: zid.MakeUserID(realm, 8),
+	})
+	quoteReply := func(messageID string) string {
+		return `<p><span class="user-mention silent" data-user-id="8">Iago</span> ` +
+			`<a href="https://zulip.example.com/#narrow/channel/9-Verona/topic/test/near/` + messageID + `">said</a>:</p>` +
+			"\n<blockquote>\n<p>quoted text</p>\n</blockquote>\n<p>My reply</p>"
+	}
+
+	tests := []struct {
+		name    string
+		html    string
+		replyTo *networkid.MessageID
+	}{
+		{"bridged in same portal", quoteReply("123"), ptrTo(zid.MakeMessageID(realm, 123))},
+		{"bridged in other portal", quoteReply("124"), nil},
+		{"not bridged", quoteReply("125"), nil},
+		{"no quote", `<p>My reply</p>`, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := zuliphtml.Parse(context.Background(), zuliphtml.Params{
+				Bridge:   br,
+				Receiver: zid.MakeUserLoginID(realm, 10),
+				BaseURL:  "https://zulip.example.com",
+				StreamID: 9,
+				Topic:    "test",
+				Portal:   portal,
+			}, test.html)
+			require.NoError(t, err)
+			assert.Equal(t, test.replyTo, result.ReplyTo)
+			if test.replyTo != nil {
+				assert.Equal(t, "<p>My reply</p>", result.HTML)
+				assert.Empty(t, result.Mentions.UserIDs)
+			} else if test.html != `<p>My reply</p>` {
+				assert.Contains(t, result.HTML, "quoted text")
+			}
+		})
+	}
+}
+
+func ptrTo[T any](val T) *T {
+	return &val
+}
@@ -100,3 +100,31 @@ func findChild(node *html.Node, tag atom.Atom, class string) *html.Node {
 	}
 	return nil
 }
+
+// removeChildren removes all descendants of the node that have the given class.
+func removeChildren(node *html.Node, class string) {
+	for child := node.FirstChild; child != nil; {
+		next := child.NextSibling
+		if child.Type == html.ElementNode {
+			if slices.Contains(getClass(child.Attr), class) {
+				node.RemoveChild(child)
+			} else {
+				removeChildren(child, class)
+			}
+		}
+		child = next
+	}
+}
+
+// nextElementSibling returns the next sibling element, skipping whitespace-only text nodes.
+// Nil is returned if there's non-whitespace text before the next element.
+func nextElementSibling(node *html.Node) *html.Node {
+	for sibling := node.NextSibling; sibling != nil; sibling = sibling.NextSibling {
+		if sibling.Type == html.ElementNode {
+			return sibling
+		} else if sibling.Type == html.TextNode && strings.TrimSpace(sibling.Data) != "" {
+			return nil
+		}
+	}
+	return nil
+}
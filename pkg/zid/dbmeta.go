@@ -1,5 +1,9 @@
 package zid
 
+import (
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+)
+
 type UserLoginMetadata struct {
 	URL   string `json:"url"`
 	Email string `json:"email"`
@@ -7,4 +11,38 @@ type UserLoginMetadata struct {
 
 	QueueID     string `json:"queue_id,omitempty"`
 	LastEventID int    `json:"last_event_id,omitempty"`
+
+	// RealmName and RealmIcon are the last known name and icon of the realm,
+	// used to notice when the realm space needs to be updated.
+	RealmName string `json:"realm_name,omitempty"`
+	RealmIcon string `json:"realm_icon,omitempty"`
+
+	MaxFileUploadSizeMiB int                   `json:"max_file_upload_size_mib,omitempty"`
+	ServerFeatures       *zulip.ServerFeatures `json:"server_features,omitempty"`
+
+	// SyncedAlertWords are the alert words as of the last sync with the push
+	// rules of the user's double puppet, used to tell which side changed.
+	SyncedAlertWords []string `json:"synced_alert_words,omitempty"`
+}
+
+type PortalMetadata struct {
+	// DefaultTopic is the topic that messages sent outside threads in a
+	// channel room go to. It's empty for the "general chat" topic.
+	DefaultTopic string `json:"default_topic,omitempty"`
+	// StickyTopic makes messages sent outside threads go to LastTopic, the
+	// topic that a message was last sent to from Matrix, instead.
+	StickyTopic bool   `json:"sticky_topic,omitempty"`
+	LastTopic   string `json:"last_topic,omitempty"`
+	// TopicName is the display name of the topic of a topic portal, as the
+	// portal ID only has the lowercased name.
+	TopicName string `json:"topic_name,omitempty"`
+}
+
+type MessageMetadata struct {
+	// ContentHash is the SHA-256 hash of the raw content of the last edit sent
+	// from Matrix, used to ignore the echo of the edit.
+	ContentHash []byte `json:"content_hash,omitempty"`
+	// ThreadTopic is the topic that a Matrix thread started on this message
+	// was bridged to, which is set when the thread isn't on a topic root.
+	ThreadTopic string `json:"thread_topic,omitempty"`
 }
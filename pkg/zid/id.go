@@ -1,7 +1,11 @@
 package zid
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"net/url"
 	"slices"
 	"strconv"
 	"strings"
@@ -10,54 +14,154 @@ import (
 	"maunium.net/go/mautrix/bridgev2/networkid"
 )
 
-func MakeMessageID(id int) networkid.MessageID {
-	return networkid.MessageID(strconv.Itoa(id))
+// Realm is a short hash of the host of a Zulip server. Zulip IDs are only
+// unique within a server, so every ID made by this package is prefixed with
+// the realm to keep users, channels and messages of different servers apart.
+type Realm string
+
+// realmLength is the number of hex characters in a realm.
+const realmLength = 8
+
+// MakeRealm returns the realm of the Zulip server at the given URL.
+func MakeRealm(serverURL string) Realm {
+	host := serverURL
+	if parsed, err := url.Parse(serverURL); err == nil && parsed.Host != "" {
+		host = parsed.Host
+	}
+	hash := sha256.Sum256([]byte(strings.ToLower(host)))
+	return Realm(hex.EncodeToString(hash[:realmLength/2]))
+}
+
+func (r Realm) prefix(id string) string {
+	return string(r) + "." + id
+}
+
+// PrefixLegacyID adds the realm to an ID made before IDs were namespaced by realm.
+func (r Realm) PrefixLegacyID(id string) string {
+	return r.prefix(id)
+}
+
+// splitRealm splits an ID into the realm and the rest of the ID.
+// The realm is empty if the ID doesn't have a valid realm prefix.
+func splitRealm(id string) (Realm, string) {
+	realm, rest, ok := strings.Cut(id, ".")
+	if !ok || len(realm) != realmLength {
+		return "", id
+	} else if _, err := hex.DecodeString(realm); err != nil {
+		return "", id
+	}
+	return Realm(realm), rest
+}
+
+func MakeMessageID(realm Realm, id int) networkid.MessageID {
+	return networkid.MessageID(realm.prefix(strconv.Itoa(id)))
 }
 
-func MakeTopicMessageID(topicName string) networkid.MessageID {
-	return networkid.MessageID("topic:" + topicName)
+func MakeTopicMessageID(realm Realm, topicName string) networkid.MessageID {
+	return networkid.MessageID(realm.prefix("topic:" + topicName))
 }
 
-func ParseMessageID(id networkid.MessageID) (string, int) {
-	if strings.HasPrefix(string(id), "topic:") {
-		return strings.TrimPrefix(string(id), "topic:"), 0
+// ParseMessageID returns the topic name of a topic root message ID or the
+// Zulip message ID of a normal message ID.
+func ParseMessageID(id networkid.MessageID) (realm Realm, topic string, messageID int) {
+	realm, rest := splitRealm(string(id))
+	if topic, ok := strings.CutPrefix(rest, "topic:"); ok {
+		return realm, topic, 0
 	}
-	n, _ := strconv.Atoi(string(id))
-	return "", n
+	messageID, _ = strconv.Atoi(rest)
+	return realm, "", messageID
+}
+
+func ParseUserLoginID(id networkid.UserLoginID) (Realm, int) {
+	realm, rest := splitRealm(string(id))
+	n, _ := strconv.Atoi(rest)
+	return realm, n
+}
+
+func MakeUserLoginID(realm Realm, id int) networkid.UserLoginID {
+	return networkid.UserLoginID(realm.prefix(strconv.Itoa(id)))
 }
 
-func ParseUserLoginID(id networkid.UserLoginID) int {
-	n, _ := strconv.Atoi(string(id))
-	return n
+func ParseUserID(id networkid.UserID) (Realm, int) {
+	realm, rest := splitRealm(string(id))
+	n, _ := strconv.Atoi(rest)
+	return realm, n
 }
 
-func MakeUserLoginID(id int) networkid.UserLoginID {
-	return networkid.UserLoginID(strconv.Itoa(id))
+func MakeUserID(realm Realm, id int) networkid.UserID {
+	return networkid.UserID(realm.prefix(strconv.Itoa(id)))
 }
 
-func ParseUserID(id networkid.UserID) int {
-	n, _ := strconv.Atoi(string(id))
-	return n
+func MakeChannelPortalID(realm Realm, streamID int) networkid.PortalID {
+	return networkid.PortalID(realm.prefix(fmt.Sprintf("stream:%d", streamID)))
 }
 
-func MakeUserID(id int) networkid.UserID {
-	return networkid.UserID(strconv.Itoa(id))
+// MakeTopicPortalID makes the ID of a portal for a single topic, used when
+// topics are bridged as separate rooms instead of threads. Topic names are
+// case-insensitive, so the ID has the lowercased name and the display name is
+// stored in the portal metadata.
+func MakeTopicPortalID(realm Realm, streamID int, topic string) networkid.PortalID {
+	return networkid.PortalID(realm.prefix(fmt.Sprintf("topic:%d:%s", streamID, strings.ToLower(topic))))
 }
 
-func MakeChannelPortalID(streamID int) networkid.PortalID {
-	return networkid.PortalID(fmt.Sprintf("stream:%d", streamID))
+// ParseTopicPortalID returns the stream ID and lowercased topic name from a
+// topic portal ID. ok is false for all other portal IDs.
+func ParseTopicPortalID(portalID networkid.PortalID) (realm Realm, streamID int, topic string, ok bool) {
+	realm, rest := splitRealm(string(portalID))
+	rest, ok = strings.CutPrefix(rest, "topic:")
+	if !ok {
+		return
+	}
+	rawStreamID, topic, ok := strings.Cut(rest, ":")
+	if !ok {
+		return
+	}
+	streamID, err := strconv.Atoi(rawStreamID)
+	if err != nil {
+		return "", 0, "", false
+	}
+	return realm, streamID, topic, true
+}
+
+// MakeRealmSpacePortalID makes the ID of the space portal that contains all
+// portals of a realm.
+func MakeRealmSpacePortalID(realm Realm) networkid.PortalID {
+	return networkid.PortalID(realm.prefix("realm"))
+}
+
+// MakeDMSpacePortalID makes the ID of the space portal that contains the DMs
+// of a single user in a realm, when DMs are grouped separately.
+func MakeDMSpacePortalID(realm Realm, userID int) networkid.PortalID {
+	return networkid.PortalID(realm.prefix(fmt.Sprintf("dms:%d", userID)))
+}
+
+// IsSpacePortalID returns whether the portal ID is a realm or DM space.
+func IsSpacePortalID(portalID networkid.PortalID) (realm, dms bool) {
+	_, rest := splitRealm(string(portalID))
+	return rest == "realm", strings.HasPrefix(rest, "dms:")
 }
 
-func MakeDMPortalID(users []int) networkid.PortalID {
+func MakeDMPortalID(realm Realm, users []int) networkid.PortalID {
 	slices.Sort(users)
 	userStrings := exslices.CastFunc(users, func(from int) string {
 		return strconv.Itoa(from)
 	})
-	return networkid.PortalID(fmt.Sprintf("dm:%s", strings.Join(userStrings, ",")))
+	return networkid.PortalID(realm.prefix(fmt.Sprintf("dm:%s", strings.Join(userStrings, ","))))
 }
 
-func ParsePortalID(portalID networkid.PortalID) (streamID int, userIDs []int, err error) {
-	parts := strings.SplitN(string(portalID), ":", 2)
+// ErrSpacePortalID is returned by ParsePortalID for realm and DM spaces, which aren't chats.
+var ErrSpacePortalID = errors.New("portal is a space")
+
+// ParsePortalID returns the stream ID of channel and topic portals or the
+// other users of DM portals. The realm is also returned for space portals,
+// along with ErrSpacePortalID.
+func ParsePortalID(portalID networkid.PortalID) (realm Realm, streamID int, userIDs []int, err error) {
+	realm, rest := splitRealm(string(portalID))
+	if isRealm, isDMs := IsSpacePortalID(portalID); isRealm || isDMs {
+		err = fmt.Errorf("%w: %s", ErrSpacePortalID, portalID)
+		return
+	}
+	parts := strings.SplitN(rest, ":", 2)
 	if len(parts) != 2 {
 		err = fmt.Errorf("invalid portal ID: %s", portalID)
 		return
@@ -69,6 +173,13 @@ func ParsePortalID(portalID networkid.PortalID) (streamID int, userIDs []int, er
 			err = fmt.Errorf("invalid stream portal ID: %s", portalID)
 			return
 		}
+	case "topic":
+		var ok bool
+		_, streamID, _, ok = ParseTopicPortalID(portalID)
+		if !ok {
+			err = fmt.Errorf("invalid topic portal ID: %s", portalID)
+			return
+		}
 	case "dm":
 		userParts := strings.Split(parts[1], ",")
 		userIDs = make([]int, len(userParts))
@@ -0,0 +1,93 @@
+package zid_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"maunium.net/go/mautrix/bridgev2/networkid"
+
+	"go.mau.fi/mautrix-zulip/pkg/zid"
+)
+
+const testRealm = zid.Realm("0123abcd")
+
+func TestTopicPortalID(t *testing.T) {
+	portalID := zid.MakeTopicPortalID(testRealm, 5, "Deploy: v1.2")
+	assert.Equal(t, "0123abcd.topic:5:deploy: v1.2", string(portalID))
+
+	realm, streamID, topic, ok := zid.ParseTopicPortalID(portalID)
+	assert.True(t, ok)
+	assert.Equal(t, testRealm, realm)
+	assert.Equal(t, 5, streamID)
+	assert.Equal(t, "deploy: v1.2", topic)
+
+	_, streamID, userIDs, err := zid.ParsePortalID(portalID)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, streamID)
+	assert.Nil(t, userIDs)
+
+	_, _, _, ok = zid.ParseTopicPortalID(zid.MakeChannelPortalID(testRealm, 5))
+	assert.False(t, ok)
+}
+
+func TestTopicPortalIDCaseOnlyRename(t *testing.T) {
+	// Renaming a topic to a different case must keep the same portal
+	assert.Equal(t,
+		zid.MakeTopicPortalID(testRealm, 5, "deploy"),
+		zid.MakeTopicPortalID(testRealm, 5, "Deploy"),
+	)
+	assert.NotEqual(t,
+		zid.MakeTopicPortalID(testRealm, 5, "deploy"),
+		zid.MakeTopicPortalID(testRealm, 6, "deploy"),
+	)
+}
+
+func TestSpacePortalIDs(t *testing.T) {
+	realmSpace := zid.MakeRealmSpacePortalID(testRealm)
+	isRealm, isDMs := zid.IsSpacePortalID(realmSpace)
+	assert.True(t, isRealm)
+	assert.False(t, isDMs)
+	realm, streamID, userIDs, err := zid.ParsePortalID(realmSpace)
+	assert.ErrorIs(t, err, zid.ErrSpacePortalID)
+	assert.Equal(t, testRealm, realm)
+	assert.Zero(t, streamID)
+	assert.Nil(t, userIDs)
+
+	dmSpace := zid.MakeDMSpacePortalID(testRealm, 42)
+	isRealm, isDMs = zid.IsSpacePortalID(dmSpace)
+	assert.False(t, isRealm)
+	assert.True(t, isDMs)
+	realm, streamID, userIDs, err = zid.ParsePortalID(dmSpace)
+	assert.ErrorIs(t, err, zid.ErrSpacePortalID)
+	assert.Equal(t, testRealm, realm)
+	assert.Zero(t, streamID)
+	assert.Nil(t, userIDs)
+
+	for _, portalID := range []string{
+		string(zid.MakeChannelPortalID(testRealm, 5)),
+		string(zid.MakeTopicPortalID(testRealm, 5, "realm")),
+		string(zid.MakeDMPortalID(testRealm, []int{3, 1})),
+	} {
+		isRealm, isDMs = zid.IsSpacePortalID(networkid.PortalID(portalID))
+		assert.False(t, isRealm, portalID)
+		assert.False(t, isDMs, portalID)
+	}
+}
+
+func TestParsePortalID(t *testing.T) {
+	realm, streamID, userIDs, err := zid.ParsePortalID(zid.MakeChannelPortalID(testRealm, 5))
+	assert.NoError(t, err)
+	assert.Equal(t, testRealm, realm)
+	assert.Equal(t, 5, streamID)
+	assert.Nil(t, userIDs)
+
+	realm, streamID, userIDs, err = zid.ParsePortalID(zid.MakeDMPortalID(testRealm, []int{3, 1}))
+	assert.NoError(t, err)
+	assert.Equal(t, testRealm, realm)
+	assert.Zero(t, streamID)
+	assert.Equal(t, []int{1, 3}, userIDs)
+
+	_, _, _, err = zid.ParsePortalID("0123abcd.unknown:1")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, zid.ErrSpacePortalID)
+}
@@ -0,0 +1,41 @@
+package zid
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+
+	"maunium.net/go/mautrix/bridgev2/networkid"
+)
+
+const mediaIDTypeUpload = 1
+
+var ErrInvalidMediaID = errors.New("invalid media ID")
+
+// MakeUploadMediaID makes a direct media ID for a file uploaded to Zulip. It
+// contains the login whose credentials are used to download the file and the
+// path of the file without the /user_uploads/ prefix.
+func MakeUploadMediaID(loginID networkid.UserLoginID, uploadPath string) networkid.MediaID {
+	uploadPath = strings.TrimPrefix(uploadPath, "/user_uploads/")
+	mediaID := make([]byte, 0, 1+binary.MaxVarintLen64+len(loginID)+len(uploadPath))
+	mediaID = append(mediaID, mediaIDTypeUpload)
+	mediaID = binary.AppendUvarint(mediaID, uint64(len(loginID)))
+	mediaID = append(mediaID, loginID...)
+	mediaID = append(mediaID, uploadPath...)
+	return mediaID
+}
+
+// ParseUploadMediaID parses a media ID made with MakeUploadMediaID.
+func ParseUploadMediaID(mediaID networkid.MediaID) (loginID networkid.UserLoginID, uploadPath string, err error) {
+	reader := bytes.NewReader(mediaID)
+	if idType, _ := reader.ReadByte(); idType != mediaIDTypeUpload {
+		return "", "", ErrInvalidMediaID
+	}
+	loginIDLength, err := binary.ReadUvarint(reader)
+	if err != nil || loginIDLength > uint64(reader.Len()) {
+		return "", "", ErrInvalidMediaID
+	}
+	rest := mediaID[len(mediaID)-reader.Len():]
+	return networkid.UserLoginID(rest[:loginIDLength]), string(rest[loginIDLength:]), nil
+}
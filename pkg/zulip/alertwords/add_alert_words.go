@@ -0,0 +1,18 @@
+package alertwords
+
+import (
+	"context"
+	"net/http"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+)
+
+// AddAlertWords adds alert words for the user. Words that already exist are ignored.
+func (svc *Service) AddAlertWords(ctx context.Context, words []string) (*AlertWordsResponse, error) {
+	const (
+		method = http.MethodPost
+		path   = "/api/v1/users/me/alert_words"
+	)
+
+	return zulip.Do[AlertWordsResponse](ctx, svc.client, method, path, &alertWordsOptions{AlertWords: words})
+}
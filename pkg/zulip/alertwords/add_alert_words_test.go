@@ -0,0 +1,37 @@
+package alertwords_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip/alertwords"
+)
+
+func TestAddAlertWords(t *testing.T) {
+	client := createMockClient(`{
+    "alert_words": [
+        "foo",
+        "bar",
+        "natural"
+    ],
+    "msg": "",
+    "result": "success"
+}`)
+
+	alertWordsSvc := alertwords.NewService(client)
+
+	resp, err := alertWordsSvc.AddAlertWords(context.Background(), []string{"foo", "bar"})
+	require.NoError(t, err)
+	assert.Equal(t, "success", resp.Result())
+	assert.Equal(t, []string{"foo", "bar", "natural"}, resp.AlertWords)
+
+	assert.Equal(t, http.MethodPost, client.(*mockClient).method)
+	assert.Equal(t, "/api/v1/users/me/alert_words", client.(*mockClient).path)
+	assert.Equal(t, map[string]any{
+		"alert_words": `["foo","bar"]`,
+	}, client.(*mockClient).paramsSent)
+}
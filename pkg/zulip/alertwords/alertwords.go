@@ -0,0 +1,51 @@
+// Package alertwords provides functionality for managing the user's Zulip
+// alert words, which notify the user of messages containing them like mentions.
+//
+// Implemented features:
+//   - Get all alert words
+//   - Add alert words
+//   - Remove alert words
+//
+// See https://zulip.com/api/ for the complete API documentation.
+package alertwords
+
+import (
+	"encoding/json"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+)
+
+type Service struct {
+	client zulip.RESTClient
+}
+
+func NewService(c zulip.RESTClient) *Service {
+	return &Service{client: c}
+}
+
+// AlertWordsResponse is the response of all alert word endpoints, which
+// contains the user's alert words after the change.
+type AlertWordsResponse struct {
+	zulip.APIResponseBase
+	alertWordsData
+}
+
+type alertWordsData struct {
+	AlertWords []string `json:"alert_words"`
+}
+
+func (a *AlertWordsResponse) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &a.APIResponseBase); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(b, &a.alertWordsData); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type alertWordsOptions struct {
+	AlertWords []string `param:"alert_words"`
+}
@@ -0,0 +1,18 @@
+package alertwords
+
+import (
+	"context"
+	"net/http"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+)
+
+// GetAlertWords returns all alert words of the user.
+func (svc *Service) GetAlertWords(ctx context.Context) (*AlertWordsResponse, error) {
+	const (
+		method = http.MethodGet
+		path   = "/api/v1/users/me/alert_words"
+	)
+
+	return zulip.Do[AlertWordsResponse](ctx, svc.client, method, path, nil)
+}
@@ -0,0 +1,34 @@
+package alertwords_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip/alertwords"
+)
+
+func TestGetAlertWords(t *testing.T) {
+	client := createMockClient(`{
+    "alert_words": [
+        "natural",
+        "illustrious"
+    ],
+    "msg": "",
+    "result": "success"
+}`)
+
+	alertWordsSvc := alertwords.NewService(client)
+
+	resp, err := alertWordsSvc.GetAlertWords(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "success", resp.Result())
+	assert.Equal(t, []string{"natural", "illustrious"}, resp.AlertWords)
+
+	assert.Equal(t, http.MethodGet, client.(*mockClient).method)
+	assert.Equal(t, "/api/v1/users/me/alert_words", client.(*mockClient).path)
+	assert.Empty(t, client.(*mockClient).paramsSent)
+}
@@ -0,0 +1,18 @@
+package alertwords
+
+import (
+	"context"
+	"net/http"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+)
+
+// RemoveAlertWords removes alert words of the user. Words that don't exist are ignored.
+func (svc *Service) RemoveAlertWords(ctx context.Context, words []string) (*AlertWordsResponse, error) {
+	const (
+		method = http.MethodDelete
+		path   = "/api/v1/users/me/alert_words"
+	)
+
+	return zulip.Do[AlertWordsResponse](ctx, svc.client, method, path, &alertWordsOptions{AlertWords: words})
+}
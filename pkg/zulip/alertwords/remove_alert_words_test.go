@@ -0,0 +1,35 @@
+package alertwords_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip/alertwords"
+)
+
+func TestRemoveAlertWords(t *testing.T) {
+	client := createMockClient(`{
+    "alert_words": [
+        "natural"
+    ],
+    "msg": "",
+    "result": "success"
+}`)
+
+	alertWordsSvc := alertwords.NewService(client)
+
+	resp, err := alertWordsSvc.RemoveAlertWords(context.Background(), []string{"foo"})
+	require.NoError(t, err)
+	assert.Equal(t, "success", resp.Result())
+	assert.Equal(t, []string{"natural"}, resp.AlertWords)
+
+	assert.Equal(t, http.MethodDelete, client.(*mockClient).method)
+	assert.Equal(t, "/api/v1/users/me/alert_words", client.(*mockClient).path)
+	assert.Equal(t, map[string]any{
+		"alert_words": `["foo"]`,
+	}, client.(*mockClient).paramsSent)
+}
@@ -187,7 +187,11 @@ func (a ErrorResp) Error() string {
 	return fmt.Sprintf("HTTP %d / %s: %s", a.Inner.HTTPCode(), a.Inner.Code(), a.Inner.Msg())
 }
 
-const ErrBadEventQueueID = "BAD_EVENT_QUEUE_ID"
+const (
+	ErrBadRequest      = "BAD_REQUEST"
+	ErrBadEventQueueID = "BAD_EVENT_QUEUE_ID"
+	ErrRateLimitHit    = "RATE_LIMIT_HIT"
+)
 
 func IsCode(err error, code string) bool {
 	if err == nil {
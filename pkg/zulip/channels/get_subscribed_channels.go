@@ -46,18 +46,14 @@ func (g *GetSubscribedChannelsResponse) UnmarshalJSON(b []byte) error {
 }
 
 type getSubscribedChannelsOptions struct {
-	includeSubscribers struct {
-		fieldName string
-		value     *bool
-	}
+	IncludeSubscribers *bool `param:"include_subscribers"`
 }
 
 type GetSubscribedChannelsOption func(*getSubscribedChannelsOptions)
 
 func IncludeSubscribersList(includeSubscribers bool) GetSubscribedChannelsOption {
 	return func(o *getSubscribedChannelsOptions) {
-		o.includeSubscribers.fieldName = "include_subscribers"
-		o.includeSubscribers.value = &includeSubscribers
+		o.IncludeSubscribers = &includeSubscribers
 	}
 }
 
@@ -67,21 +63,10 @@ func (svc *Service) GetSubscribedChannels(ctx context.Context, options ...GetSub
 		path   = "/api/v1/users/me/subscriptions"
 	)
 
-	msg := map[string]any{}
-
 	opts := getSubscribedChannelsOptions{}
 	for _, opt := range options {
 		opt(&opts)
 	}
 
-	if opts.includeSubscribers.value != nil {
-		msg[opts.includeSubscribers.fieldName] = *opts.includeSubscribers.value
-	}
-
-	resp := GetSubscribedChannelsResponse{}
-	if err := svc.client.DoRequest(ctx, method, path, msg, &resp); err != nil {
-		return nil, err
-	}
-
-	return &resp, nil
+	return zulip.Do[GetSubscribedChannelsResponse](ctx, svc.client, method, path, &opts)
 }
@@ -33,11 +33,50 @@ func (s *SubscribeToChannelResponse) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
-type subscribeToChannelOptions struct{}
+type subscribeToChannelOptions struct {
+	Subscriptions              []SubscribeTo `param:"subscriptions"`
+	Principals                 any           `param:"principals"`
+	InviteOnly                 *bool         `param:"invite_only"`
+	HistoryPublicToSubscribers *bool         `param:"history_public_to_subscribers"`
+	Announce                   *bool         `param:"announce"`
+}
 
 // SubscribeToChannelOption is the type of the options for subscribing to a channel.
 type SubscribeToChannelOption func(*subscribeToChannelOptions)
 
+// SubscribePrincipals is the list of users to subscribe to the channels.
+// A list of user IDs (preferred) or Zulip API email addresses. If not provided,
+// then the requesting user/bot is subscribed.
+func SubscribePrincipals[T []int | []string](users T) SubscribeToChannelOption {
+	return func(args *subscribeToChannelOptions) {
+		args.Principals = users
+	}
+}
+
+// InviteOnly sets whether channels created by the request are private.
+// It has no effect on channels that already exist.
+func InviteOnly(value bool) SubscribeToChannelOption {
+	return func(args *subscribeToChannelOptions) {
+		args.InviteOnly = &value
+	}
+}
+
+// HistoryPublicToSubscribers sets whether new subscribers of a private channel
+// created by the request can see messages sent before they joined.
+func HistoryPublicToSubscribers(value bool) SubscribeToChannelOption {
+	return func(args *subscribeToChannelOptions) {
+		args.HistoryPublicToSubscribers = &value
+	}
+}
+
+// Announce sets whether the creation of new channels is announced to the
+// organization.
+func Announce(value bool) SubscribeToChannelOption {
+	return func(args *subscribeToChannelOptions) {
+		args.Announce = &value
+	}
+}
+
 // SubscribeTo is the type of the channel to subscribe to.
 // If the channel does not exist, it will be created with the given description.
 type SubscribeTo struct {
@@ -52,24 +91,12 @@ func (svc *Service) SubscribeToChannel(ctx context.Context, list []SubscribeTo,
 		path   = "/api/v1/users/me/subscriptions"
 	)
 
-	channelJSONList, err := json.Marshal(list)
-	if err != nil {
-		return nil, err
+	opts := subscribeToChannelOptions{
+		Subscriptions: list,
 	}
-
-	msg := map[string]any{
-		"subscriptions": string(channelJSONList),
-	}
-
-	opts := subscribeToChannelOptions{}
 	for _, opt := range options {
 		opt(&opts)
 	}
 
-	resp := SubscribeToChannelResponse{}
-	if err := svc.client.DoRequest(ctx, method, path, msg, &resp); err != nil {
-		return nil, err
-	}
-
-	return &resp, nil
+	return zulip.Do[SubscribeToChannelResponse](ctx, svc.client, method, path, &opts)
 }
@@ -49,3 +49,41 @@ func TestSubscribeToChannel(t *testing.T) {
 	// validate the parameters sent are correct
 	assert.Equal(t, msg, client.(*mockClient).paramsSent)
 }
+
+func TestSubscribeToChannelCreateOptions(t *testing.T) {
+	client := createMockClient(`{
+    "already_subscribed": {},
+    "msg": "",
+    "result": "success",
+    "subscribed": {
+        "1": [
+            "new-channel"
+        ],
+        "2": [
+            "new-channel"
+        ]
+    }
+}`)
+
+	channelSvc := channels.NewService(client)
+
+	resp, err := channelSvc.SubscribeToChannel(context.Background(),
+		[]channels.SubscribeTo{{Name: "new-channel"}},
+		channels.SubscribePrincipals([]int{1, 2}),
+		channels.InviteOnly(true),
+		channels.HistoryPublicToSubscribers(false),
+		channels.Announce(false),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "success", resp.Result())
+	assert.Len(t, resp.Subscribed, 2)
+
+	// validate the parameters sent are correct
+	assert.Equal(t, map[string]any{
+		"subscriptions":                 `[{"name":"new-channel"}]`,
+		"principals":                    "[1,2]",
+		"invite_only":                   true,
+		"history_public_to_subscribers": false,
+		"announce":                      false,
+	}, client.(*mockClient).paramsSent)
+}
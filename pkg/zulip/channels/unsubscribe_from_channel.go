@@ -34,10 +34,8 @@ func (s *UnsubscribeFromChannelResponse) UnmarshalJSON(b []byte) error {
 }
 
 type unsubscribeFromChannelOptions struct {
-	principals struct {
-		fieldName string
-		value     any
-	}
+	Subscriptions []string `param:"subscriptions"`
+	Principals    any      `param:"principals"`
 }
 
 // UnsubscribeFromChannelOption is the type of the options for unsubscribing from a channel.
@@ -49,8 +47,7 @@ type UnsubscribeFromChannelOption func(*unsubscribeFromChannelOptions)
 // then the requesting user/bot is unsubscribed.
 func Principals[T []int | []string](users T) UnsubscribeFromChannelOption {
 	return func(args *unsubscribeFromChannelOptions) {
-		args.principals.fieldName = "principals"
-		args.principals.value = users
+		args.Principals = users
 	}
 }
 
@@ -61,29 +58,12 @@ func (svc *Service) UnsubscribeFromChannel(ctx context.Context, subscriptions []
 		path   = "/api/v1/users/me/subscriptions"
 	)
 
-	channelJSONList, err := json.Marshal(subscriptions)
-	if err != nil {
-		return nil, err
-	}
-
-	msg := map[string]any{
-		"subscriptions": string(channelJSONList),
+	opts := unsubscribeFromChannelOptions{
+		Subscriptions: subscriptions,
 	}
-
-	opts := unsubscribeFromChannelOptions{}
 	for _, opt := range options {
 		opt(&opts)
 	}
 
-	if opts.principals.value != nil {
-		jsonVal, _ := json.Marshal(opts.principals.value)
-		msg[opts.principals.fieldName] = string(jsonVal)
-	}
-
-	resp := UnsubscribeFromChannelResponse{}
-	if err := svc.client.DoRequest(ctx, method, path, msg, &resp); err != nil {
-		return nil, err
-	}
-
-	return &resp, nil
+	return zulip.Do[UnsubscribeFromChannelResponse](ctx, svc.client, method, path, &opts)
 }
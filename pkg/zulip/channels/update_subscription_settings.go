@@ -0,0 +1,71 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+)
+
+// UpdateSubscriptionSettingsResponse is the response of updating subscription settings.
+type UpdateSubscriptionSettingsResponse struct {
+	zulip.APIResponseBase
+	updateSubscriptionSettingsResponseData
+}
+
+type updateSubscriptionSettingsResponseData struct {
+	SubscriptionData []SubscriptionSetting `json:"subscription_data"`
+}
+
+func (u *UpdateSubscriptionSettingsResponse) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &u.APIResponseBase); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(b, &u.updateSubscriptionSettingsResponseData); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SubscriptionProperty is a personal setting of a channel subscription.
+type SubscriptionProperty string
+
+const (
+	SubscriptionPropertyColor                  SubscriptionProperty = "color"
+	SubscriptionPropertyIsMuted                SubscriptionProperty = "is_muted"
+	SubscriptionPropertyPinToTop               SubscriptionProperty = "pin_to_top"
+	SubscriptionPropertyDesktopNotifications   SubscriptionProperty = "desktop_notifications"
+	SubscriptionPropertyAudibleNotifications   SubscriptionProperty = "audible_notifications"
+	SubscriptionPropertyPushNotifications      SubscriptionProperty = "push_notifications"
+	SubscriptionPropertyEmailNotifications     SubscriptionProperty = "email_notifications"
+	SubscriptionPropertyWildcardMentionsNotify SubscriptionProperty = "wildcard_mentions_notify"
+)
+
+// SubscriptionSetting is a new value for a property of a subscribed channel.
+type SubscriptionSetting struct {
+	StreamID int                  `json:"stream_id"`
+	Property SubscriptionProperty `json:"property"`
+	Value    any                  `json:"value"`
+}
+
+type updateSubscriptionSettingsOptions struct {
+	SubscriptionData []SubscriptionSetting `param:"subscription_data"`
+}
+
+// UpdateSubscriptionSettings changes personal settings of subscribed channels,
+// like muting or pinning them.
+func (svc *Service) UpdateSubscriptionSettings(ctx context.Context, settings []SubscriptionSetting) (*UpdateSubscriptionSettingsResponse, error) {
+	const (
+		method = http.MethodPost
+		path   = "/api/v1/users/me/subscriptions/properties"
+	)
+
+	opts := updateSubscriptionSettingsOptions{
+		SubscriptionData: settings,
+	}
+
+	return zulip.Do[UpdateSubscriptionSettingsResponse](ctx, svc.client, method, path, &opts)
+}
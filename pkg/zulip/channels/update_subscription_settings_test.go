@@ -0,0 +1,49 @@
+package channels_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip/channels"
+)
+
+func TestUpdateSubscriptionSettings(t *testing.T) {
+	client := createMockClient(`{
+    "msg": "",
+    "result": "success",
+    "subscription_data": [
+        {
+            "property": "pin_to_top",
+            "stream_id": 1,
+            "value": true
+        },
+        {
+            "property": "is_muted",
+            "stream_id": 3,
+            "value": false
+        }
+    ]
+}`)
+
+	channelSvc := channels.NewService(client)
+
+	resp, err := channelSvc.UpdateSubscriptionSettings(context.Background(), []channels.SubscriptionSetting{
+		{StreamID: 1, Property: channels.SubscriptionPropertyPinToTop, Value: true},
+		{StreamID: 3, Property: channels.SubscriptionPropertyIsMuted, Value: false},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "success", resp.Result())
+	require.Len(t, resp.SubscriptionData, 2)
+	assert.Equal(t, channels.SubscriptionPropertyIsMuted, resp.SubscriptionData[1].Property)
+	assert.Equal(t, false, resp.SubscriptionData[1].Value)
+
+	assert.Equal(t, http.MethodPost, client.(*mockClient).method)
+	assert.Equal(t, "/api/v1/users/me/subscriptions/properties", client.(*mockClient).path)
+	assert.Equal(t, map[string]any{
+		"subscription_data": `[{"stream_id":1,"property":"pin_to_top","value":true},{"stream_id":3,"property":"is_muted","value":false}]`,
+	}, client.(*mockClient).paramsSent)
+}
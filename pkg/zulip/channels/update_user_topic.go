@@ -0,0 +1,41 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+)
+
+// UpdateUserTopicResponse is the response of updating the personal preferences of a topic.
+type UpdateUserTopicResponse struct {
+	zulip.APIResponseBase
+}
+
+func (u *UpdateUserTopicResponse) UnmarshalJSON(b []byte) error {
+	return json.Unmarshal(b, &u.APIResponseBase)
+}
+
+type updateUserTopicOptions struct {
+	StreamID         int                    `param:"stream_id"`
+	Topic            string                 `param:"topic"`
+	VisibilityPolicy zulip.VisibilityPolicy `param:"visibility_policy"`
+}
+
+// UpdateUserTopic changes whether the user has muted, unmuted or followed a topic.
+// The topic doesn't need to exist yet.
+func (svc *Service) UpdateUserTopic(ctx context.Context, streamID int, topic string, policy zulip.VisibilityPolicy) (*UpdateUserTopicResponse, error) {
+	const (
+		method = http.MethodPost
+		path   = "/api/v1/user_topics"
+	)
+
+	opts := updateUserTopicOptions{
+		StreamID:         streamID,
+		Topic:            topic,
+		VisibilityPolicy: policy,
+	}
+
+	return zulip.Do[UpdateUserTopicResponse](ctx, svc.client, method, path, &opts)
+}
@@ -0,0 +1,34 @@
+package channels_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/channels"
+)
+
+func TestUpdateUserTopic(t *testing.T) {
+	client := createMockClient(`{
+    "msg": "",
+    "result": "success"
+}`)
+
+	channelSvc := channels.NewService(client)
+
+	resp, err := channelSvc.UpdateUserTopic(context.Background(), 1, "dinner", zulip.VisibilityPolicyFollowed)
+	require.NoError(t, err)
+	assert.Equal(t, "success", resp.Result())
+
+	assert.Equal(t, http.MethodPost, client.(*mockClient).method)
+	assert.Equal(t, "/api/v1/user_topics", client.(*mockClient).path)
+	assert.Equal(t, map[string]any{
+		"stream_id":         1,
+		"topic":             "dinner",
+		"visibility_policy": zulip.VisibilityPolicyFollowed,
+	}, client.(*mockClient).paramsSent)
+}
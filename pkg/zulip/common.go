@@ -17,3 +17,17 @@ const (
 	MemberRole        OrganizationRoleLevel = 400
 	GuestRole         OrganizationRoleLevel = 600
 )
+
+// VisibilityPolicy is how a user has configured the visibility of a topic.
+type VisibilityPolicy int
+
+const (
+	// VisibilityPolicyInherit follows the mute setting of the channel.
+	VisibilityPolicyInherit VisibilityPolicy = 0
+	// VisibilityPolicyMuted mutes the topic.
+	VisibilityPolicyMuted VisibilityPolicy = 1
+	// VisibilityPolicyUnmuted unmutes the topic in a muted channel.
+	VisibilityPolicyUnmuted VisibilityPolicy = 2
+	// VisibilityPolicyFollowed follows the topic, which notifies about all messages in it.
+	VisibilityPolicyFollowed VisibilityPolicy = 3
+)
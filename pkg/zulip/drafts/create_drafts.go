@@ -0,0 +1,45 @@
+package drafts
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+)
+
+type CreateDraftsResponse struct {
+	zulip.APIResponseBase
+	createDraftsData
+}
+
+type createDraftsData struct {
+	// IDs are the IDs of the new drafts, in the same order as they were given.
+	IDs []int `json:"ids"`
+}
+
+func (c *CreateDraftsResponse) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &c.APIResponseBase); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(b, &c.createDraftsData); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type createDraftsOptions struct {
+	Drafts []Draft `param:"drafts"`
+}
+
+// CreateDrafts creates one or more drafts.
+func (svc *Service) CreateDrafts(ctx context.Context, drafts []Draft) (*CreateDraftsResponse, error) {
+	const (
+		method = http.MethodPost
+		path   = "/api/v1/drafts"
+	)
+
+	return zulip.Do[CreateDraftsResponse](ctx, svc.client, method, path, &createDraftsOptions{Drafts: drafts})
+}
@@ -0,0 +1,39 @@
+package drafts_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip/drafts"
+)
+
+func TestCreateDrafts(t *testing.T) {
+	client := createMockClient(`{
+    "ids": [
+        17,
+        18
+    ],
+    "msg": "",
+    "result": "success"
+}`)
+
+	draftsSvc := drafts.NewService(client)
+
+	resp, err := draftsSvc.CreateDrafts(context.Background(), []drafts.Draft{
+		{Type: drafts.TypeDirect, To: []int{9, 10}, Content: "Hi"},
+		{Type: drafts.TypeNone, To: []int{}, Content: "Note to self"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "success", resp.Result())
+	assert.Equal(t, []int{17, 18}, resp.IDs)
+
+	assert.Equal(t, http.MethodPost, client.(*mockClient).method)
+	assert.Equal(t, "/api/v1/drafts", client.(*mockClient).path)
+	assert.Equal(t, map[string]any{
+		"drafts": `[{"type":"private","to":[9,10],"topic":"","content":"Hi"},{"type":"","to":[],"topic":"","content":"Note to self"}]`,
+	}, client.(*mockClient).paramsSent)
+}
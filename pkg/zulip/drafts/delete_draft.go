@@ -0,0 +1,24 @@
+package drafts
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+)
+
+type DeleteDraftResponse struct {
+	zulip.APIResponseBase
+}
+
+func (svc *Service) DeleteDraft(ctx context.Context, id int) (*DeleteDraftResponse, error) {
+	const (
+		method = http.MethodDelete
+		path   = "/api/v1/drafts"
+	)
+
+	deletePath := fmt.Sprintf("%s/%d", path, id)
+
+	return zulip.Do[DeleteDraftResponse](ctx, svc.client, method, deletePath, nil)
+}
@@ -0,0 +1,28 @@
+package drafts_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip/drafts"
+)
+
+func TestDeleteDraft(t *testing.T) {
+	client := createMockClient(`{
+    "msg": "",
+    "result": "success"
+}`)
+
+	draftsSvc := drafts.NewService(client)
+
+	resp, err := draftsSvc.DeleteDraft(context.Background(), 17)
+	require.NoError(t, err)
+	assert.True(t, resp.IsSuccess())
+
+	assert.Equal(t, http.MethodDelete, client.(*mockClient).method)
+	assert.Equal(t, "/api/v1/drafts/17", client.(*mockClient).path)
+}
@@ -0,0 +1,46 @@
+// Package drafts provides functionality for managing Zulip drafts, which are
+// synced between the user's clients.
+//
+// Implemented features:
+//   - Get drafts
+//   - Create drafts
+//   - Edit a draft
+//   - Delete a draft
+//
+// See https://zulip.com/api/ for the complete API documentation.
+package drafts
+
+import "go.mau.fi/mautrix-zulip/pkg/zulip"
+
+type Service struct {
+	client zulip.RESTClient
+}
+
+func NewService(c zulip.RESTClient) *Service {
+	return &Service{client: c}
+}
+
+// Type is the type of conversation a draft is for.
+type Type string
+
+const (
+	// TypeNone is a draft that isn't addressed to anyone yet.
+	TypeNone    Type = ""
+	TypeChannel Type = "stream"
+	TypeDirect  Type = "private"
+)
+
+// Draft is a message that hasn't been sent yet.
+type Draft struct {
+	// ID is set by the server and ignored when creating or editing drafts.
+	ID   int  `json:"id,omitempty"`
+	Type Type `json:"type"`
+	// To contains the channel ID for channel drafts and the user IDs of the
+	// other participants for direct message drafts.
+	To      []int  `json:"to"`
+	Topic   string `json:"topic"`
+	Content string `json:"content"`
+	// Timestamp is the Unix timestamp of the last edit. The server uses the
+	// current time if it's not set.
+	Timestamp int64 `json:"timestamp,omitempty"`
+}
@@ -0,0 +1,29 @@
+package drafts
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+)
+
+type EditDraftResponse struct {
+	zulip.APIResponseBase
+}
+
+type editDraftOptions struct {
+	Draft Draft `param:"draft"`
+}
+
+// EditDraft replaces the content and recipient of a draft.
+func (svc *Service) EditDraft(ctx context.Context, id int, draft Draft) (*EditDraftResponse, error) {
+	const (
+		method = http.MethodPatch
+		path   = "/api/v1/drafts"
+	)
+
+	patchPath := fmt.Sprintf("%s/%d", path, id)
+
+	return zulip.Do[EditDraftResponse](ctx, svc.client, method, patchPath, &editDraftOptions{Draft: draft})
+}
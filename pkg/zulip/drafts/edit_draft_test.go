@@ -0,0 +1,37 @@
+package drafts_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip/drafts"
+)
+
+func TestEditDraft(t *testing.T) {
+	client := createMockClient(`{
+    "msg": "",
+    "result": "success"
+}`)
+
+	draftsSvc := drafts.NewService(client)
+
+	resp, err := draftsSvc.EditDraft(context.Background(), 17, drafts.Draft{
+		Type:      drafts.TypeChannel,
+		To:        []int{6},
+		Topic:     "Welcome",
+		Content:   "Hello everyone!",
+		Timestamp: 1681846100,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "success", resp.Result())
+
+	assert.Equal(t, http.MethodPatch, client.(*mockClient).method)
+	assert.Equal(t, "/api/v1/drafts/17", client.(*mockClient).path)
+	assert.Equal(t, map[string]any{
+		"draft": `{"type":"stream","to":[6],"topic":"Welcome","content":"Hello everyone!","timestamp":1681846100}`,
+	}, client.(*mockClient).paramsSent)
+}
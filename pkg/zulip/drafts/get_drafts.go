@@ -0,0 +1,41 @@
+package drafts
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+)
+
+type GetDraftsResponse struct {
+	zulip.APIResponseBase
+	getDraftsData
+}
+
+type getDraftsData struct {
+	Count  int     `json:"count"`
+	Drafts []Draft `json:"drafts"`
+}
+
+func (g *GetDraftsResponse) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &g.APIResponseBase); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(b, &g.getDraftsData); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetDrafts returns all drafts of the user, sorted by last edit.
+func (svc *Service) GetDrafts(ctx context.Context) (*GetDraftsResponse, error) {
+	const (
+		method = http.MethodGet
+		path   = "/api/v1/drafts"
+	)
+
+	return zulip.Do[GetDraftsResponse](ctx, svc.client, method, path, nil)
+}
@@ -0,0 +1,52 @@
+package drafts_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip/drafts"
+)
+
+func TestGetDrafts(t *testing.T) {
+	client := createMockClient(`{
+    "count": 1,
+    "drafts": [
+        {
+            "content": "Hello there!",
+            "id": 17,
+            "timestamp": 1681846080,
+            "to": [
+                6
+            ],
+            "topic": "Welcome",
+            "type": "stream"
+        }
+    ],
+    "msg": "",
+    "result": "success"
+}`)
+
+	draftsSvc := drafts.NewService(client)
+
+	resp, err := draftsSvc.GetDrafts(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "success", resp.Result())
+
+	assert.Equal(t, http.MethodGet, client.(*mockClient).method)
+	assert.Equal(t, "/api/v1/drafts", client.(*mockClient).path)
+
+	assert.Equal(t, 1, resp.Count)
+	require.Len(t, resp.Drafts, 1)
+	assert.Equal(t, drafts.Draft{
+		ID:        17,
+		Type:      drafts.TypeChannel,
+		To:        []int{6},
+		Topic:     "Welcome",
+		Content:   "Hello there!",
+		Timestamp: 1681846080,
+	}, resp.Drafts[0])
+}
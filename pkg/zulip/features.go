@@ -0,0 +1,86 @@
+package zulip
+
+// Feature levels at which API changes that the client adapts to were introduced.
+// See https://zulip.com/api/changelog for the full list.
+const (
+	// FeatureLevelTopicParameter is the first feature level, only reported by
+	// Zulip 3.0+. Older servers may predate the "topic" parameter and are sent
+	// the legacy "subject" instead.
+	FeatureLevelTopicParameter             = 1
+	FeatureLevelUserAvatarURLFieldOptional = 18
+	FeatureLevelStreamTypingNotifications  = 58
+	FeatureLevelUserSettingsObject         = 89
+	// FeatureLevelDirectMessageType added "direct" as a message type, replacing "private".
+	FeatureLevelDirectMessageType    = 174
+	FeatureLevelLinkifierURLTemplate = 176
+	// FeatureLevelScheduledMessages added the scheduled messages API.
+	FeatureLevelScheduledMessages  = 179
+	FeatureLevelUserListIncomplete = 232
+	// FeatureLevelChannelMessageType added "channel" as a message type, replacing "stream".
+	FeatureLevelChannelMessageType = 248
+	// FeatureLevelChannelNarrowOperators added the "channel" and "channels" narrow operators.
+	FeatureLevelChannelNarrowOperators   = 250
+	FeatureLevelSimplifiedPresenceEvents = 263
+	FeatureLevelIncludeDeactivatedGroups = 294
+	FeatureLevelArchivedChannels         = 315
+	FeatureLevelEmptyTopicName           = 334
+)
+
+// ServerFeatures describes the version of a Zulip server, as returned by
+// /server_settings and /register.
+type ServerFeatures struct {
+	ZulipVersion      string `json:"zulip_version"`
+	ZulipFeatureLevel int    `json:"zulip_feature_level"`
+	ZulipMergeBase    string `json:"zulip_merge_base,omitempty"`
+}
+
+// SupportsFeature returns whether the server is at the given feature level or newer.
+// Unknown servers (nil features) are assumed to be up to date.
+func (sf *ServerFeatures) SupportsFeature(level int) bool {
+	if sf == nil {
+		return true
+	}
+
+	return sf.ZulipFeatureLevel >= level
+}
+
+// FeatureChecker is implemented by clients that know the feature level of the server they talk to.
+type FeatureChecker interface {
+	SupportsFeature(level int) bool
+}
+
+// SupportsFeature checks the feature level of the server behind the client.
+// Clients that don't implement FeatureChecker are assumed to talk to an up to date server.
+func SupportsFeature(client RESTClient, level int) bool {
+	checker, ok := client.(FeatureChecker)
+	if !ok {
+		return true
+	}
+
+	return checker.SupportsFeature(level)
+}
+
+// WithServerFeatures sets the initially known server features, e.g. ones
+// cached from a previous session. They can be updated later with
+// Client.SetServerFeatures.
+func WithServerFeatures(features *ServerFeatures) ClientOption {
+	return func(o *clientOptions) error {
+		o.serverFeatures = features
+		return nil
+	}
+}
+
+// ServerFeatures returns the known features of the server, or nil if they haven't been set.
+func (c *Client) ServerFeatures() *ServerFeatures {
+	return c.features.Load()
+}
+
+// SetServerFeatures updates the features of the server the client talks to.
+func (c *Client) SetServerFeatures(features *ServerFeatures) {
+	c.features.Store(features)
+}
+
+// SupportsFeature returns whether the server supports the given feature level.
+func (c *Client) SupportsFeature(level int) bool {
+	return c.ServerFeatures().SupportsFeature(level)
+}
@@ -0,0 +1,40 @@
+package zulip_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+)
+
+func TestServerFeatures(t *testing.T) {
+	var unknown *zulip.ServerFeatures
+	assert.True(t, unknown.SupportsFeature(zulip.FeatureLevelEmptyTopicName))
+
+	features := &zulip.ServerFeatures{ZulipVersion: "9.4", ZulipFeatureLevel: 251}
+	assert.True(t, features.SupportsFeature(zulip.FeatureLevelChannelMessageType))
+	assert.True(t, features.SupportsFeature(251))
+	assert.False(t, features.SupportsFeature(zulip.FeatureLevelArchivedChannels))
+}
+
+func TestClientServerFeatures(t *testing.T) {
+	client, err := zulip.NewClient(zulip.Credentials("https://zulip.example.com", "email@test", "apikey"))
+	require.NoError(t, err)
+
+	assert.Nil(t, client.ServerFeatures())
+	assert.True(t, zulip.SupportsFeature(client, zulip.FeatureLevelEmptyTopicName))
+
+	client.SetServerFeatures(&zulip.ServerFeatures{ZulipFeatureLevel: 100})
+	assert.False(t, zulip.SupportsFeature(client, zulip.FeatureLevelChannelMessageType))
+
+	client, err = zulip.NewClient(zulip.Credentials("https://zulip.example.com", "email@test", "apikey"),
+		zulip.WithServerFeatures(&zulip.ServerFeatures{ZulipFeatureLevel: 300}),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, 300, client.ServerFeatures().ZulipFeatureLevel)
+	assert.True(t, client.SupportsFeature(zulip.FeatureLevelChannelMessageType))
+	assert.False(t, client.SupportsFeature(zulip.FeatureLevelEmptyTopicName))
+}
@@ -0,0 +1,151 @@
+package zulip
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"net/textproto"
+	"path/filepath"
+)
+
+// ErrFileTooLarge is returned by DoFileRequest when the file exceeds the size set with WithMaxUploadSize.
+var ErrFileTooLarge = errors.New("file too large")
+
+// multipartFileBody is a multipart/form-data body with a single file part.
+// The part header and closing boundary are rendered upfront, so the body can
+// be streamed without holding the file in memory, and the total length is
+// known whenever the file size is.
+type multipartFileBody struct {
+	contentType   string
+	contentLength int64
+
+	prefix []byte
+	file   io.Reader
+	suffix []byte
+
+	fileSize int64
+	maxSize  int64
+	progress UploadProgressFunc
+}
+
+func newMultipartFileBody(fileName, mimeType string, file io.Reader, fileSize int64, options clientSendRequestOptions) (*multipartFileBody, error) {
+	var buf bytes.Buffer
+
+	writer := multipart.NewWriter(&buf)
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition",
+		fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+			"filename",
+			filepath.Base(fileName)))
+	h.Set("Content-Type", mimeType)
+
+	if _, err := writer.CreatePart(h); err != nil {
+		return nil, fmt.Errorf("cannot create writer from file: %v", err)
+	}
+
+	prefix := bytes.Clone(buf.Bytes())
+	buf.Reset()
+
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("closing writer: %v", err)
+	}
+
+	body := &multipartFileBody{
+		contentType:   writer.FormDataContentType(),
+		contentLength: -1,
+		prefix:        prefix,
+		file:          file,
+		suffix:        bytes.Clone(buf.Bytes()),
+		fileSize:      fileSize,
+		maxSize:       options.maxUploadSize,
+		progress:      options.uploadProgress,
+	}
+	if fileSize >= 0 {
+		body.contentLength = int64(len(body.prefix)) + fileSize + int64(len(body.suffix))
+	}
+
+	return body, nil
+}
+
+func (b *multipartFileBody) writeTo(w io.Writer) error {
+	if _, err := w.Write(b.prefix); err != nil {
+		return err
+	}
+
+	var src io.Reader = b.file
+	if b.maxSize > 0 && b.fileSize < 0 {
+		// Read one byte past the limit to detect oversized files of unknown size.
+		src = io.LimitReader(src, b.maxSize+1)
+	}
+
+	n, err := io.Copy(&progressWriter{w: w, total: b.fileSize, fn: b.progress}, src)
+	if err != nil {
+		return fmt.Errorf("copying file content: %w", err)
+	}
+
+	if b.maxSize > 0 && n > b.maxSize {
+		return fmt.Errorf("%w: exceeds the limit of %d bytes", ErrFileTooLarge, b.maxSize)
+	}
+
+	if b.fileSize >= 0 && n != b.fileSize {
+		return fmt.Errorf("file size changed during upload: expected %d bytes, read %d", b.fileSize, n)
+	}
+
+	_, err = w.Write(b.suffix)
+
+	return err
+}
+
+type progressWriter struct {
+	w     io.Writer
+	sent  int64
+	total int64
+	fn    UploadProgressFunc
+}
+
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	n, err := pw.w.Write(p)
+	pw.sent += int64(n)
+
+	if pw.fn != nil {
+		pw.fn(pw.sent, pw.total)
+	}
+
+	return n, err
+}
+
+// readerSize returns the number of bytes left in the reader, or -1 if it can't be determined cheaply.
+func readerSize(r io.Reader) int64 {
+	switch typed := r.(type) {
+	case interface{ Len() int }:
+		return int64(typed.Len())
+	case io.Seeker:
+		cur, err := typed.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+
+		end, err := typed.Seek(0, io.SeekEnd)
+		if err != nil {
+			return -1
+		}
+
+		if _, err = typed.Seek(cur, io.SeekStart); err != nil {
+			return -1
+		}
+
+		return end - cur
+	case interface{ Stat() (fs.FileInfo, error) }:
+		info, err := typed.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return -1
+		}
+
+		return info.Size()
+	default:
+		return -1
+	}
+}
@@ -23,30 +23,13 @@ const (
 )
 
 type editMessageOptions struct {
-	topic struct {
-		fieldName string
-		value     *string
-	}
-	propagateMode struct {
-		fieldName string
-		value     *PropagateMode
-	}
-	sendNotificationToOldThread struct {
-		fieldName string
-		value     *bool
-	}
-	sendNotificationToNewThread struct {
-		fieldName string
-		value     *bool
-	}
-	content struct {
-		fieldName string
-		value     *string
-	}
-	streamID struct {
-		fieldName string
-		value     *int
-	}
+	Topic                       *string        `param:"topic"`
+	Subject                     *string        `param:"subject"`
+	PropagateMode               *PropagateMode `param:"propagate_mode"`
+	SendNotificationToOldThread *bool          `param:"send_notification_to_old_thread"`
+	SendNotificationToNewThread *bool          `param:"send_notification_to_new_thread"`
+	Content                     *string        `param:"content"`
+	StreamID                    *int           `param:"stream_id"`
 }
 
 type EditMessageOption func(*editMessageOptions) error
@@ -58,8 +41,7 @@ func MoveToTopic(name string) EditMessageOption {
 			return errors.New("topic 'name' is empty")
 		}
 
-		o.topic.fieldName = "topic"
-		o.topic.value = &name
+		o.Topic = &name
 
 		return nil
 	}
@@ -68,8 +50,7 @@ func MoveToTopic(name string) EditMessageOption {
 // SetPropagateMode Which message(s) should be edited
 func SetPropagateMode(change PropagateMode) EditMessageOption {
 	return func(o *editMessageOptions) error {
-		o.propagateMode.fieldName = "propagate_mode"
-		o.propagateMode.value = &change
+		o.PropagateMode = &change
 
 		return nil
 	}
@@ -78,8 +59,7 @@ func SetPropagateMode(change PropagateMode) EditMessageOption {
 // SendNotificationToOldThread Whether to send an automated message to the old topic to notify users where the messages were moved to.
 func SendNotificationToOldThread(yes bool) EditMessageOption {
 	return func(o *editMessageOptions) error {
-		o.sendNotificationToOldThread.fieldName = "send_notification_to_old_thread"
-		o.sendNotificationToOldThread.value = &yes
+		o.SendNotificationToOldThread = &yes
 
 		return nil
 	}
@@ -88,8 +68,7 @@ func SendNotificationToOldThread(yes bool) EditMessageOption {
 // SendNotificationToNewThread Whether to send an automated message to the new topic to notify users where the messages came from.
 func SendNotificationToNewThread(yes bool) EditMessageOption {
 	return func(o *editMessageOptions) error {
-		o.sendNotificationToNewThread.fieldName = "send_notification_to_new_thread"
-		o.sendNotificationToNewThread.value = &yes
+		o.SendNotificationToNewThread = &yes
 
 		return nil
 	}
@@ -98,8 +77,7 @@ func SendNotificationToNewThread(yes bool) EditMessageOption {
 // NewContent The updated content of the target message.
 func NewContent(content string) EditMessageOption {
 	return func(o *editMessageOptions) error {
-		o.content.fieldName = "content"
-		o.content.value = &content
+		o.Content = &content
 
 		return nil
 	}
@@ -108,8 +86,7 @@ func NewContent(content string) EditMessageOption {
 // SetStreamID The channel ID to move the message(s) to, to request moving messages to another channel.
 func SetStreamID(id int) EditMessageOption {
 	return func(o *editMessageOptions) error {
-		o.streamID.fieldName = "stream_id"
-		o.streamID.value = &id
+		o.StreamID = &id
 
 		return nil
 	}
@@ -151,8 +128,6 @@ func (svc *Service) EditMessage(ctx context.Context, id int, options ...EditMess
 
 	patchPath := fmt.Sprintf("%s/%d", path, id)
 
-	msg := map[string]any{}
-
 	opts := editMessageOptions{}
 	for _, opt := range options {
 		if err := opt(&opts); err != nil {
@@ -160,34 +135,7 @@ func (svc *Service) EditMessage(ctx context.Context, id int, options ...EditMess
 		}
 	}
 
-	if opts.topic.value != nil {
-		msg[opts.topic.fieldName] = *opts.topic.value
-	}
-
-	if opts.propagateMode.value != nil {
-		msg[opts.propagateMode.fieldName] = *opts.propagateMode.value
-	}
-
-	if opts.sendNotificationToOldThread.value != nil {
-		msg[opts.sendNotificationToOldThread.fieldName] = *opts.sendNotificationToOldThread.value
-	}
-
-	if opts.sendNotificationToNewThread.value != nil {
-		msg[opts.sendNotificationToNewThread.fieldName] = *opts.sendNotificationToNewThread.value
-	}
-
-	if opts.content.value != nil {
-		msg[opts.content.fieldName] = *opts.content.value
-	}
-
-	if opts.streamID.value != nil {
-		msg[opts.streamID.fieldName] = *opts.streamID.value
-	}
-
-	resp := EditMessageResponse{}
-	if err := svc.client.DoRequest(ctx, method, patchPath, msg, &resp); err != nil {
-		return nil, err
-	}
+	svc.adaptTopic(&opts.Topic, &opts.Subject)
 
-	return &resp, nil
+	return zulip.Do[EditMessageResponse](ctx, svc.client, method, patchPath, &opts)
 }
@@ -32,18 +32,14 @@ func (f *FetchSingleMessageResponse) UnmarshalJSON(b []byte) error {
 }
 
 type fetchSingleMessageOptions struct {
-	applyMarkdown struct {
-		fieldName string
-		value     *bool
-	}
+	ApplyMarkdown *bool `param:"apply_markdown"`
 }
 
 type FetchSingleMessageOption func(*fetchSingleMessageOptions)
 
 func ApplyMarkdownSingleMessage(applyMarkdown bool) FetchSingleMessageOption {
 	return func(o *fetchSingleMessageOptions) {
-		o.applyMarkdown.fieldName = "apply_markdown"
-		o.applyMarkdown.value = &applyMarkdown
+		o.ApplyMarkdown = &applyMarkdown
 	}
 }
 
@@ -55,21 +51,10 @@ func (svc *Service) FetchSingleMessage(ctx context.Context, messageID int, optio
 
 	patchPath := fmt.Sprintf("%s/%d", path, messageID)
 
-	msg := map[string]any{}
-
 	opts := fetchSingleMessageOptions{}
 	for _, opt := range options {
 		opt(&opts)
 	}
 
-	if opts.applyMarkdown.value != nil {
-		msg[opts.applyMarkdown.fieldName] = *opts.applyMarkdown.value
-	}
-
-	resp := FetchSingleMessageResponse{}
-	if err := svc.client.DoRequest(ctx, method, patchPath, msg, &resp); err != nil {
-		return nil, err
-	}
-
-	return &resp, nil
+	return zulip.Do[FetchSingleMessageResponse](ctx, svc.client, method, patchPath, &opts)
 }
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"net/http"
 
 	"go.mau.fi/mautrix-zulip/pkg/zulip"
@@ -113,101 +112,66 @@ func (d *DisplayRecipient) UnmarshalJSON(b []byte) error {
 }
 
 type getMessageOptions struct {
-	anchor struct {
-		fieldName string
-		value     *string
-	}
-	includeAnchor struct {
-		fieldName string
-		value     *bool
-	}
-	numBefore struct {
-		fieldName string
-		value     *int
-	}
-	numAfter struct {
-		fieldName string
-		value     *int
-	}
-	narrow struct {
-		fieldName string
-		value     narrow.Filter
-	}
-	clientGravatar struct {
-		fieldName string
-		value     *bool
-	}
-	applyMarkdown struct {
-		fieldName string
-		value     *bool
-	}
-	messageIDs struct {
-		fieldName string
-		value     []int
-	}
+	Anchor         *string       `param:"anchor"`
+	IncludeAnchor  *bool         `param:"include_anchor"`
+	NumBefore      *int          `param:"num_before"`
+	NumAfter       *int          `param:"num_after"`
+	Narrow         narrow.Filter `param:"narrow,omitempty"`
+	ClientGravatar *bool         `param:"client_gravatar"`
+	ApplyMarkdown  *bool         `param:"apply_markdown"`
+	MessageIDs     []int         `param:"message_ids"`
 
-	// deprecated: useFirstUnreadAnchor Legacy way to specify "anchor": "first_unread" in Zulip 2.1.x and older.
-	// useFirstUnreadAnchor struct {
-	// 	fieldName string
-	// 	value     *bool
-	// }
+	// deprecated: UseFirstUnreadAnchor Legacy way to specify "anchor": "first_unread" in Zulip 2.1.x and older.
+	// UseFirstUnreadAnchor *bool `param:"use_first_unread_anchor"`
 }
 
 type GetMessageOption func(*getMessageOptions)
 
 func Anchor(anchor string) GetMessageOption {
 	return func(o *getMessageOptions) {
-		o.anchor.fieldName = "anchor"
-		o.anchor.value = &anchor
+		o.Anchor = &anchor
 	}
 }
 
 func IncludeAnchor(includeAnchor bool) GetMessageOption {
 	return func(o *getMessageOptions) {
-		o.includeAnchor.fieldName = "include_anchor"
-		o.includeAnchor.value = &includeAnchor
+		o.IncludeAnchor = &includeAnchor
 	}
 }
 
 func NumBefore(numBefore int) GetMessageOption {
 	return func(o *getMessageOptions) {
-		o.numBefore.fieldName = "num_before"
-		o.numBefore.value = &numBefore
+		o.NumBefore = &numBefore
 	}
 }
 
 func NumAfter(numAfter int) GetMessageOption {
 	return func(o *getMessageOptions) {
-		o.numAfter.fieldName = "num_after"
-		o.numAfter.value = &numAfter
+		o.NumAfter = &numAfter
 	}
 }
 
 func NarrowMessage(narrow narrow.Filter) GetMessageOption {
 	return func(o *getMessageOptions) {
-		o.narrow.fieldName = "narrow"
-		o.narrow.value = narrow
+		o.Narrow = narrow
 	}
 }
 
 func ClientGravatarMessage(clientGravatar bool) GetMessageOption {
 	return func(o *getMessageOptions) {
-		o.clientGravatar.fieldName = "client_gravatar"
-		o.clientGravatar.value = &clientGravatar
+		o.ClientGravatar = &clientGravatar
 	}
 }
 
 func ApplyMarkdownMessage(applyMarkdown bool) GetMessageOption {
 	return func(o *getMessageOptions) {
-		o.applyMarkdown.fieldName = "apply_markdown"
-		o.applyMarkdown.value = &applyMarkdown
+		o.ApplyMarkdown = &applyMarkdown
 	}
 }
 
 func MessageIDs(messageIDs []int) GetMessageOption {
 	return func(o *getMessageOptions) {
-		o.messageIDs.fieldName = "message_ids"
-		o.messageIDs.value = messageIDs
+		o.MessageIDs = messageIDs
 	}
 }
 
@@ -217,54 +181,12 @@ func (svc *Service) GetMessages(ctx context.Context, options ...GetMessageOption
 		path   = "/api/v1/messages"
 	)
 
-	msg := map[string]any{}
-
 	opts := getMessageOptions{}
 	for _, opt := range options {
 		opt(&opts)
 	}
 
-	if opts.anchor.value != nil {
-		msg[opts.anchor.fieldName] = *opts.anchor.value
-	}
-
-	if opts.includeAnchor.value != nil {
-		msg[opts.includeAnchor.fieldName] = *opts.includeAnchor.value
-	}
-
-	if opts.numBefore.value != nil {
-		msg[opts.numBefore.fieldName] = *opts.numBefore.value
-	}
-
-	if opts.numAfter.value != nil {
-		msg[opts.numAfter.fieldName] = *opts.numAfter.value
-	}
-
-	if len(opts.narrow.value) > 0 {
-		narrowJSON, err := opts.narrow.value.MarshalJSON()
-		if err != nil {
-			return nil, fmt.Errorf("marshaling narrow: %w", err)
-		}
-
-		msg[opts.narrow.fieldName] = string(narrowJSON)
-	}
-
-	if opts.clientGravatar.value != nil {
-		msg[opts.clientGravatar.fieldName] = *opts.clientGravatar.value
-	}
-
-	if opts.applyMarkdown.value != nil {
-		msg[opts.applyMarkdown.fieldName] = *opts.applyMarkdown.value
-	}
-
-	if opts.messageIDs.value != nil {
-		msg[opts.messageIDs.fieldName] = opts.messageIDs.value
-	}
-
-	resp := GetMessagesResponse{}
-	if err := svc.client.DoRequest(ctx, method, path, msg, &resp); err != nil {
-		return nil, err
-	}
+	opts.Narrow = svc.adaptNarrow(opts.Narrow)
 
-	return &resp, nil
+	return zulip.Do[GetMessagesResponse](ctx, svc.client, method, path, &opts)
 }
@@ -98,7 +98,7 @@ func TestGetMessages(t *testing.T) {
 		"narrow":          `[{"operator":"channel","operand":"Verona","negated":false},{"operator":"sender","operand":"iago@zulip.com","negated":false}]`,
 		"client_gravatar": true,
 		"apply_markdown":  true,
-		"message_ids":     []int{16, 21},
+		"message_ids":     "[16,21]",
 	}
 
 	resp, err := messagesSvc.GetMessages(context.Background(),
@@ -137,3 +137,20 @@ func TestGetMessages(t *testing.T) {
 	assert.Equal(t, "/api/v1/messages", client.(*mockClient).path)
 	assert.Equal(t, msg, client.(*mockClient).paramsSent)
 }
+
+func TestGetMessagesLegacyNarrow(t *testing.T) {
+	client := createLegacyMockClient(`{"result": "success", "msg": "", "messages": []}`, 200)
+
+	messagesSvc := messages.NewService(client)
+
+	_, err := messagesSvc.GetMessages(context.Background(),
+		messages.NarrowMessage(narrow.NewFilter().
+			Add(narrow.New(narrow.Channel, "Verona")).
+			Add(narrow.New(narrow.Topic, "test"))),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		`[{"operator":"stream","operand":"Verona","negated":false},{"operator":"topic","operand":"test","negated":false}]`,
+		client.(*mockClient).paramsSent["narrow"])
+}
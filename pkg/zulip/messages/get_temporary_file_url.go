@@ -0,0 +1,48 @@
+package messages
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+)
+
+// UserUploadsPrefix is the path prefix of files uploaded to the server.
+const UserUploadsPrefix = "/user_uploads/"
+
+type GetTemporaryFileURLResponse struct {
+	zulip.APIResponseBase
+	getTemporaryFileURLResponseData
+}
+
+type getTemporaryFileURLResponseData struct {
+	// URL is a path on the server that can be used to download the file
+	// without authentication for 60 seconds.
+	URL string `json:"url"`
+}
+
+func (g *GetTemporaryFileURLResponse) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &g.APIResponseBase); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(b, &g.getTemporaryFileURLResponseData); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetTemporaryFileURL returns a temporary URL that allows downloading an
+// uploaded file without authentication. The upload path can be given with or
+// without the /user_uploads/ prefix, e.g. "2/ab/cdEfGh/image.png".
+func (svc *Service) GetTemporaryFileURL(ctx context.Context, uploadPath string) (*GetTemporaryFileURLResponse, error) {
+	const (
+		method = http.MethodGet
+		path   = "/api/v1/user_uploads/"
+	)
+
+	return zulip.Do[GetTemporaryFileURLResponse](ctx, svc.client, method, path+strings.TrimPrefix(uploadPath, UserUploadsPrefix), nil)
+}
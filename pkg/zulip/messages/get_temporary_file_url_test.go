@@ -0,0 +1,29 @@
+package messages_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip/messages"
+)
+
+func TestGetTemporaryFileURL(t *testing.T) {
+	client := createMockClient(`{
+    "msg": "",
+    "result": "success",
+    "url": "/user_uploads/temporary/322F32632F39765378464E4C63306D3961396F4970705A4D74424565/zulip.txt"
+}`)
+
+	messagesSvc := messages.NewService(client)
+
+	resp, err := messagesSvc.GetTemporaryFileURL(context.Background(), "/user_uploads/2/4e/m2A3MSqFnWRLUf9SaPzQ0Up_/zulip.txt")
+	require.NoError(t, err)
+	assert.True(t, resp.IsSuccess())
+	assert.Equal(t, "/user_uploads/temporary/322F32632F39765378464E4C63306D3961396F4970705A4D74424565/zulip.txt", resp.URL)
+
+	// validate the parameters sent are correct
+	assert.Equal(t, "/api/v1/user_uploads/2/4e/m2A3MSqFnWRLUf9SaPzQ0Up_/zulip.txt", client.(*mockClient).path)
+}
@@ -17,7 +17,10 @@
 // See https://zulip.com/api/ for the complete API documentation.
 package messages
 
-import "go.mau.fi/mautrix-zulip/pkg/zulip"
+import (
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/narrow"
+)
 
 type Service struct {
 	client zulip.RESTClient
@@ -26,3 +29,21 @@ type Service struct {
 func NewService(c zulip.RESTClient) *Service {
 	return &Service{client: c}
 }
+
+// adaptNarrow renames narrow operators that the server doesn't know yet.
+func (svc *Service) adaptNarrow(filter narrow.Filter) narrow.Filter {
+	if filter == nil || zulip.SupportsFeature(svc.client, zulip.FeatureLevelChannelNarrowOperators) {
+		return filter
+	}
+
+	return filter.WithLegacyOperators()
+}
+
+// adaptTopic moves the topic to the legacy "subject" parameter for servers that predate "topic".
+func (svc *Service) adaptTopic(topic, subject **string) {
+	if *topic == nil || zulip.SupportsFeature(svc.client, zulip.FeatureLevelTopicParameter) {
+		return
+	}
+
+	*subject, *topic = *topic, nil
+}
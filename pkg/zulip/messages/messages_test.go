@@ -15,6 +15,11 @@ type mockClient struct {
 	method     string
 	path       string
 	paramsSent map[string]any // sort of spy for testing input parameters
+	features   *zulip.ServerFeatures
+}
+
+func (mc *mockClient) SupportsFeature(level int) bool {
+	return mc.features.SupportsFeature(level)
 }
 
 func (mc *mockClient) DoRequest(ctx context.Context, method, path string, data map[string]any, response zulip.APIResponse, opts ...zulip.DoRequestOption) error {
@@ -42,3 +47,11 @@ func createMockClient(response string) zulip.RESTClient {
 		response: response,
 	}
 }
+
+// createLegacyMockClient creates a mockClient that reports the given server feature level
+func createLegacyMockClient(response string, featureLevel int) zulip.RESTClient {
+	return &mockClient{
+		response: response,
+		features: &zulip.ServerFeatures{ZulipFeatureLevel: featureLevel},
+	}
+}
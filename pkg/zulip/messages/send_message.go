@@ -16,7 +16,7 @@ import (
 const (
 	// In Zulip 9.0 (feature level 248), "channel" was added as an additional value for this parameter to request a channel message.
 	toChannel string = "channel"
-	// toStream  string = "stream"
+	toStream  string = "stream"
 	// Direct messages are also known as private messages.
 	toDirect string = "direct"
 	// ToPrivate
@@ -26,20 +26,19 @@ const (
 	// messages, clients are encouraged to use to the modern convention with
 	// servers that support it, because support for "private" will eventually
 	// be removed.
-	// toPrivate string = "private"
+	toPrivate string = "private"
 )
 
 type sendMessageOptions struct {
-	topic struct {
-		fieldName string
-		value     *string
-	}
-	// queueID      string
-	// localID      string
-	readBySender struct {
-		fieldName string
-		value     *bool
-	}
+	To      any    `param:"to"`
+	Type    string `param:"type"`
+	Content string `param:"content"`
+
+	Topic   *string `param:"topic"`
+	Subject *string `param:"subject"`
+	// QueueID      string `param:"queue_id"`
+	// LocalID      string `param:"local_id"`
+	ReadBySender *bool `param:"read_by_sender"`
 }
 
 type SendMessageOption func(*sendMessageOptions) error
@@ -52,8 +51,7 @@ func ToTopic(name string) SendMessageOption {
 			return errors.New("topic 'name' is empty")
 		}
 
-		o.topic.fieldName = "topic"
-		o.topic.value = &name
+		o.Topic = &name
 
 		return nil
 	}
@@ -63,8 +61,7 @@ func ToTopic(name string) SendMessageOption {
 // unspecified, the server uses a heuristic based on the client name.
 func ReadBySender(asRead bool) SendMessageOption {
 	return func(o *sendMessageOptions) error {
-		o.readBySender.fieldName = "read_by_sender"
-		o.readBySender.value = &asRead
+		o.ReadBySender = &asRead
 
 		return nil
 	}
@@ -106,52 +103,38 @@ func (svc *Service) SendMessage(ctx context.Context, to recipient.Recipient, con
 		path   = "/api/v1/messages"
 	)
 
-	var (
-		toRecipient   any
-		recipientType string
-	)
-
-	switch t := to.(type) {
+	var recipientType string
+	switch to.(type) {
 	case recipient.Direct:
-		v, err := json.Marshal(t.To())
-		if err != nil {
-			return nil, err
-		}
-
-		toRecipient = string(v)
 		recipientType = toDirect
+		if !zulip.SupportsFeature(svc.client, zulip.FeatureLevelDirectMessageType) {
+			recipientType = toPrivate
+		}
 	case recipient.Channel:
-		toRecipient = to.To()
 		recipientType = toChannel
+		if !zulip.SupportsFeature(svc.client, zulip.FeatureLevelChannelMessageType) {
+			recipientType = toStream
+		}
 	default:
 		return nil, fmt.Errorf("unsupported recipient type: %T", to)
 	}
 
-	msg := map[string]any{
-		"to":      toRecipient,
-		"type":    recipientType,
-		"content": content,
+	opts := sendMessageOptions{
+		To:      to.To(),
+		Type:    recipientType,
+		Content: content,
 	}
-
-	opts := sendMessageOptions{}
 	for _, opt := range options {
 		if err := opt(&opts); err != nil {
 			return nil, fmt.Errorf("applying option: %w", err)
 		}
 	}
 
-	if opts.topic.value != nil && *opts.topic.value != "" {
-		msg[opts.topic.fieldName] = *opts.topic.value
+	if opts.Topic != nil && *opts.Topic == "" {
+		opts.Topic = nil
 	}
 
-	if opts.readBySender.value != nil {
-		msg[opts.readBySender.fieldName] = *opts.readBySender.value
-	}
-
-	resp := SendMessageResponse{}
-	if err := svc.client.DoRequest(ctx, method, path, msg, &resp); err != nil {
-		return nil, err
-	}
+	svc.adaptTopic(&opts.Topic, &opts.Subject)
 
-	return &resp, nil
+	return zulip.Do[SendMessageResponse](ctx, svc.client, method, path, &opts)
 }
@@ -160,3 +160,41 @@ func TestSendMessageToUserNames(t *testing.T) {
 	assert.Equal(t, "/api/v1/messages", client.(*mockClient).path)
 	assert.Equal(t, msg, client.(*mockClient).paramsSent)
 }
+
+func TestSendMessageLegacyServer(t *testing.T) {
+	client := createLegacyMockClient(`{"id": 42, "msg": "", "result": "success"}`, 0)
+
+	messagesSvc := messages.NewService(client)
+
+	_, err := messagesSvc.SendMessageToChannelTopic(context.Background(), recipient.ToChannel(1), "topic", "the message")
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]any{
+		"to":      recipient.ToChannel(1),
+		"subject": "topic",
+		"type":    "stream",
+		"content": "the message",
+	}, client.(*mockClient).paramsSent)
+
+	_, err = messagesSvc.SendMessageToUsers(context.Background(), recipient.ToUser(1), "the message")
+	require.NoError(t, err)
+
+	assert.Equal(t, "private", client.(*mockClient).paramsSent["type"])
+}
+
+func TestSendMessagePre9Server(t *testing.T) {
+	client := createLegacyMockClient(`{"id": 42, "msg": "", "result": "success"}`, 200)
+
+	messagesSvc := messages.NewService(client)
+
+	_, err := messagesSvc.SendMessageToChannelTopic(context.Background(), recipient.ToChannel(1), "topic", "the message")
+	require.NoError(t, err)
+
+	assert.Equal(t, "stream", client.(*mockClient).paramsSent["type"])
+	assert.Equal(t, "topic", client.(*mockClient).paramsSent["topic"])
+
+	_, err = messagesSvc.SendMessageToUsers(context.Background(), recipient.ToUser(1), "the message")
+	require.NoError(t, err)
+
+	assert.Equal(t, "direct", client.(*mockClient).paramsSent["type"])
+}
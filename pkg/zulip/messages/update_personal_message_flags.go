@@ -47,8 +47,11 @@ const (
 	FlagTopicWildcardMentioned  Flag = "topic_wildcard_mentioned"
 	FlagHasAlertWord            Flag = "has_alert_word"
 	FlagHistorical              Flag = "historical"
-	// FlagWilcardMentioned deprecated
-	// FlagWilcardMentioned Flag = "wildcard_mentioned"
+	// FlagWildcardMentioned
+	// Deprecated: In Zulip 8.0 (feature level 224), it was replaced by
+	// FlagStreamWildcardMentioned and FlagTopicWildcardMentioned. Older
+	// servers only set this flag on messages.
+	FlagWildcardMentioned Flag = "wildcard_mentioned"
 )
 
 func (svc *Service) UpdatePersonalMessageFlags(ctx context.Context, messageIDs []int, op Operation, flag Flag) (*UpdatePersonalMessageFlags, error) {
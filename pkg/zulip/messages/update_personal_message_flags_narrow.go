@@ -36,18 +36,20 @@ func (g *UpdatePersonalMessageFlagsNarrow) UnmarshalJSON(b []byte) error {
 }
 
 type updatePersonalMessageFlagsNarrowOptions struct {
-	IncludeAnchor struct {
-		fieldName string
-		value     bool
-	}
+	Anchor        string        `param:"anchor"`
+	NumBefore     int           `param:"num_before"`
+	NumAfter      int           `param:"num_after"`
+	Narrow        narrow.Filter `param:"narrow"`
+	Op            Operation     `param:"op"`
+	Flag          Flag          `param:"flag"`
+	IncludeAnchor bool          `param:"include_anchor,omitempty"`
 }
 
 type UpdatePersonalMessageFlagsNarrowOption func(*updatePersonalMessageFlagsNarrowOptions)
 
 func UpdatePersonalMessageFlagsNarrowIncludeAnchor() UpdatePersonalMessageFlagsNarrowOption {
 	return func(o *updatePersonalMessageFlagsNarrowOptions) {
-		o.IncludeAnchor.fieldName = "include_anchor"
-		o.IncludeAnchor.value = true
+		o.IncludeAnchor = true
 	}
 }
 
@@ -66,33 +68,26 @@ func (svc *Service) UpdatePersonalMessageFlagsNarrow(
 		path   = "/api/v1/messages/flags/narrow"
 	)
 
-	narrowJSON, err := json.Marshal(narrow)
-	if err != nil {
-		return nil, err
-	}
-
-	msg := map[string]any{
-		"anchor":     anchor,
-		"num_before": numBefore,
-		"num_after":  numAfter,
-		"narrow":     string(narrowJSON),
-		"op":         op,
-		"flag":       flag,
+	options := updatePersonalMessageFlagsNarrowOptions{
+		Anchor:    anchor,
+		NumBefore: numBefore,
+		NumAfter:  numAfter,
+		Narrow:    svc.adaptNarrow(nonNilFilter(narrow)),
+		Op:        op,
+		Flag:      flag,
 	}
-
-	options := updatePersonalMessageFlagsNarrowOptions{}
 	for _, opt := range opts {
 		opt(&options)
 	}
 
-	if options.IncludeAnchor.value {
-		msg[options.IncludeAnchor.fieldName] = options.IncludeAnchor.value
-	}
+	return zulip.Do[UpdatePersonalMessageFlagsNarrow](ctx, svc.client, method, path, &options)
+}
 
-	resp := UpdatePersonalMessageFlagsNarrow{}
-	if err := svc.client.DoRequest(ctx, method, path, msg, &resp); err != nil {
-		return nil, err
+// nonNilFilter makes sure an empty narrow is still sent, as it matches all messages.
+func nonNilFilter(filter narrow.Filter) narrow.Filter {
+	if filter == nil {
+		return narrow.NewFilter()
 	}
 
-	return &resp, nil
+	return filter
 }
@@ -35,7 +35,56 @@ func (u *UploadFileResponse) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
-func (svc *Service) UploadFile(ctx context.Context, filePath string) (*UploadFileResponse, error) {
+type uploadFileOptions struct {
+	maxFileSizeMiB int
+	size           int64
+	progress       zulip.UploadProgressFunc
+}
+
+type UploadFileOption func(*uploadFileOptions)
+
+// MaxFileSizeMiB rejects files larger than the given size before uploading
+// them. Use the realm's max_file_upload_size_mib setting to avoid sending
+// files the server will refuse anyway.
+func MaxFileSizeMiB(size int) UploadFileOption {
+	return func(o *uploadFileOptions) {
+		o.maxFileSizeMiB = size
+	}
+}
+
+// FileSize sets the size of the file for readers whose size can't be
+// determined automatically, which allows sending a Content-Length header.
+func FileSize(size int64) UploadFileOption {
+	return func(o *uploadFileOptions) {
+		o.size = size
+	}
+}
+
+// UploadProgress sets a callback that is called as the file is being sent.
+func UploadProgress(fn zulip.UploadProgressFunc) UploadFileOption {
+	return func(o *uploadFileOptions) {
+		o.progress = fn
+	}
+}
+
+func (opts *uploadFileOptions) requestOptions() []zulip.DoRequestOption {
+	var reqOpts []zulip.DoRequestOption
+	if opts.maxFileSizeMiB > 0 {
+		reqOpts = append(reqOpts, zulip.WithMaxUploadSize(int64(opts.maxFileSizeMiB)*1024*1024))
+	}
+
+	if opts.size > 0 {
+		reqOpts = append(reqOpts, zulip.WithUploadSize(opts.size))
+	}
+
+	if opts.progress != nil {
+		reqOpts = append(reqOpts, zulip.WithUploadProgress(opts.progress))
+	}
+
+	return reqOpts
+}
+
+func (svc *Service) UploadFile(ctx context.Context, filePath string, options ...UploadFileOption) (*UploadFileResponse, error) {
 	const (
 		method = http.MethodPost
 		path   = "/api/v1/user_uploads"
@@ -48,26 +97,38 @@ func (svc *Service) UploadFile(ctx context.Context, filePath string) (*UploadFil
 
 	defer func() { _ = file.Close() }()
 
+	opts := uploadFileOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
 	resp := UploadFileResponse{}
-	if err := svc.client.DoFileRequest(ctx, method, path, file.Name(), file, &resp); err != nil {
+	if err := svc.client.DoFileRequest(ctx, method, path, file.Name(), file, &resp, opts.requestOptions()...); err != nil {
 		return nil, err
 	}
 
 	return &resp, nil
 }
 
-func (svc *Service) UploadFileFromBytes(ctx context.Context, fileName string, fileBytes []byte) (*UploadFileResponse, error) {
-	return svc.UploadFileFromReader(ctx, fileName, bytes.NewReader(fileBytes))
+func (svc *Service) UploadFileFromBytes(ctx context.Context, fileName string, fileBytes []byte, options ...UploadFileOption) (*UploadFileResponse, error) {
+	return svc.UploadFileFromReader(ctx, fileName, bytes.NewReader(fileBytes), options...)
 }
 
-func (svc *Service) UploadFileFromReader(ctx context.Context, fileName string, fileReader io.Reader) (*UploadFileResponse, error) {
+// UploadFileFromReader uploads the contents of fileReader. The reader is
+// streamed to the server, so it's not read into memory all at once.
+func (svc *Service) UploadFileFromReader(ctx context.Context, fileName string, fileReader io.Reader, options ...UploadFileOption) (*UploadFileResponse, error) {
 	const (
 		method = http.MethodPost
 		path   = "/api/v1/user_uploads"
 	)
 
+	opts := uploadFileOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
 	resp := UploadFileResponse{}
-	if err := svc.client.DoFileRequest(ctx, method, path, fileName, fileReader, &resp); err != nil {
+	if err := svc.client.DoFileRequest(ctx, method, path, fileName, fileReader, &resp, opts.requestOptions()...); err != nil {
 		return nil, err
 	}
 
@@ -91,6 +91,28 @@ func (f Filter) Add(narrow Narrow) Filter {
 	return append(f, narrow)
 }
 
+// legacyOperators maps operators to the names used by servers older than
+// Zulip 9.0 (feature level 250).
+var legacyOperators = map[Operator]Operator{
+	Channel:  Stream,
+	Channels: Streams,
+}
+
+// WithLegacyOperators returns a copy of the filter with operators renamed to
+// the legacy names understood by older servers.
+func (f Filter) WithLegacyOperators() Filter {
+	out := make(Filter, len(f))
+	for i, item := range f {
+		if legacy, ok := legacyOperators[item.Operator]; ok {
+			item.Operator = legacy
+		}
+
+		out[i] = item
+	}
+
+	return out
+}
+
 // String returns a string representation of the Filter
 func (f *Filter) String() string {
 	ns := make([]string, len(*f))
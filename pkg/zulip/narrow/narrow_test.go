@@ -122,3 +122,20 @@ func TestNarrowerBasic(t *testing.T) {
 	require.NoError(t, err)
 	assert.JSONEq(t, expectedNarrowersJSON, string(currentNarrowersJSON))
 }
+
+func TestWithLegacyOperators(t *testing.T) {
+	filter := NewFilter().
+		Add(New(Channel, "Verona")).
+		Add(NewNegated(Channels, "public")).
+		Add(New(Topic, "test"))
+
+	legacy := filter.WithLegacyOperators()
+
+	assert.Equal(t, Filter{
+		New(Stream, "Verona"),
+		NewNegated(Streams, "public"),
+		New(Topic, "test"),
+	}, legacy)
+	// The original filter is left as is
+	assert.Equal(t, Channel, filter[0].Operator)
+}
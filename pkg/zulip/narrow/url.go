@@ -0,0 +1,154 @@
+package narrow
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Legacy operator names that can still appear in old web app URLs.
+const (
+	legacySubject Operator = "subject"
+	legacyPMWith  Operator = "pm-with"
+)
+
+// urlOperatorAliases maps legacy operator names found in older links to the current ones.
+var urlOperatorAliases = map[Operator]Operator{
+	Stream:        Channel,
+	Streams:       Channels,
+	legacySubject: Topic,
+	legacyPMWith:  Dm,
+}
+
+var ErrNotNarrowURL = errors.New("not a narrow URL")
+
+// ParseURL parses a Zulip web app link such as
+// https://chat.zulip.org/#narrow/channel/9-Verona/topic/test.20topic/near/123
+// into a filter. Only the fragment is looked at, so relative links like
+// /#narrow/... and bare fragments work too. Operands are decoded, legacy
+// operator names (stream, subject, pm-with) are normalized and a leading "-"
+// on an operator negates it.
+func ParseURL(link string) (Filter, error) {
+	_, fragment, _ := strings.Cut(link, "#")
+	rest, ok := strings.CutPrefix(fragment, "narrow/")
+	if !ok {
+		return nil, ErrNotNarrowURL
+	}
+
+	parts := strings.Split(strings.TrimSuffix(rest, "/"), "/")
+	if len(parts)%2 != 0 {
+		return nil, fmt.Errorf("odd number of narrow URL components in %q", fragment)
+	}
+
+	filter := make(Filter, 0, len(parts)/2)
+	for i := 0; i < len(parts); i += 2 {
+		rawOperator, negated := strings.CutPrefix(parts[i], "-")
+
+		operator := Operator(rawOperator)
+		if alias, ok := urlOperatorAliases[operator]; ok {
+			operator = alias
+		}
+
+		operand, err := DecodeHashComponent(parts[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("decoding %s operand: %w", operator, err)
+		}
+
+		filter = append(filter, newNarrow(operator, operand, negated))
+	}
+
+	return filter, nil
+}
+
+// Get returns the operand of the first non-negated item with the given operator.
+func (f Filter) Get(operator Operator) (Operand, bool) {
+	for _, item := range f {
+		if item.Operator == operator && !item.Negated {
+			return item.Operand, true
+		}
+	}
+
+	return nil, false
+}
+
+// ParseChannelOperand extracts the channel ID from a URL channel operand like "9-Verona".
+func ParseChannelOperand(operand Operand) (int, bool) {
+	str, ok := operand.(string)
+	if !ok {
+		return 0, false
+	}
+
+	idPart, _, _ := strings.Cut(str, "-")
+
+	id, err := strconv.Atoi(idPart)
+
+	return id, err == nil
+}
+
+// ChannelSlug formats a channel URL operand like "9-Verona", the same way as
+// the Zulip web app: the name is included for readability, but only the ID matters.
+func ChannelSlug(channelID int, channelName string) string {
+	return strconv.Itoa(channelID) + "-" + EncodeHashComponent(strings.ReplaceAll(channelName, " ", "-"))
+}
+
+// DMSlug formats a direct message URL operand like "8,9-group". The user IDs
+// should include every participant of the conversation.
+func DMSlug(userIDs []int) string {
+	sorted := slices.Clone(userIDs)
+	slices.Sort(sorted)
+	ids := make([]string, len(sorted))
+	for i, userID := range sorted {
+		ids[i] = strconv.Itoa(userID)
+	}
+	suffix := "dm"
+	if len(sorted) >= 3 {
+		suffix = "group"
+	}
+	return strings.Join(ids, ",") + "-" + suffix
+}
+
+// ChannelMessageURL returns the link to a message in a channel topic, as used
+// by the "Copy link to message" and "Quote and reply" features of the web app.
+func ChannelMessageURL(baseURL string, channelID int, channelName, topic string, messageID int) string {
+	return fmt.Sprintf(
+		"%s/#narrow/channel/%s/topic/%s/near/%d",
+		strings.TrimSuffix(baseURL, "/"), ChannelSlug(channelID, channelName), EncodeHashComponent(topic), messageID,
+	)
+}
+
+// DMMessageURL returns the link to a direct message.
+func DMMessageURL(baseURL string, userIDs []int, messageID int) string {
+	return fmt.Sprintf("%s/#narrow/dm/%s/near/%d", strings.TrimSuffix(baseURL, "/"), DMSlug(userIDs), messageID)
+}
+
+// EncodeHashComponent encodes a string for use in a narrow URL, the same way
+// as the Zulip web app: it's encoded like JavaScript's encodeURIComponent,
+// then "%" is replaced by "." (and literal dots and parentheses are escaped),
+// so that the result doesn't get mangled by other software re-encoding the URL.
+func EncodeHashComponent(s string) string {
+	const hex = "0123456789ABCDEF"
+
+	var buf strings.Builder
+	for i := range len(s) {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			strings.IndexByte("-_!~*'", c) >= 0:
+			buf.WriteByte(c)
+		default:
+			buf.WriteByte('.')
+			buf.WriteByte(hex[c>>4])
+			buf.WriteByte(hex[c&15])
+		}
+	}
+
+	return buf.String()
+}
+
+// DecodeHashComponent reverses EncodeHashComponent.
+func DecodeHashComponent(s string) (string, error) {
+	return url.PathUnescape(strings.ReplaceAll(s, ".", "%"))
+}
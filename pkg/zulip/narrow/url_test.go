@@ -0,0 +1,107 @@
+package narrow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseURL(t *testing.T) {
+	cases := []struct {
+		name     string
+		link     string
+		expected Filter
+	}{
+		{
+			name:     "channel",
+			link:     "/#narrow/channel/9-Verona",
+			expected: Filter{New(Channel, "9-Verona")},
+		},
+		{
+			name:     "legacy stream and subject",
+			link:     "https://chat.zulip.org/#narrow/stream/9-Verona/subject/test",
+			expected: Filter{New(Channel, "9-Verona"), New(Topic, "test")},
+		},
+		{
+			name:     "encoded topic",
+			link:     "#narrow/channel/9-Verona/topic/test.20topic.20.2E.2Ecom.3F/near/123",
+			expected: Filter{New(Channel, "9-Verona"), New(Topic, "test topic ..com?"), New(Near, "123")},
+		},
+		{
+			name:     "unicode topic",
+			link:     "/#narrow/channel/9-Verona/topic/.F0.9F.8E.89.20party",
+			expected: Filter{New(Channel, "9-Verona"), New(Topic, "🎉 party")},
+		},
+		{
+			name:     "negated and trailing slash",
+			link:     "/#narrow/-is/starred/",
+			expected: Filter{NewNegated(Is, "starred")},
+		},
+		{
+			name:     "direct message",
+			link:     "/#narrow/pm-with/8,9-group/near/42",
+			expected: Filter{New(Dm, "8,9-group"), New(Near, "42")},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := ParseURL(tt.link)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, filter)
+		})
+	}
+}
+
+func TestParseURLErrors(t *testing.T) {
+	_, err := ParseURL("https://example.com/")
+	require.ErrorIs(t, err, ErrNotNarrowURL)
+
+	_, err = ParseURL("/#narrow/channel")
+	require.Error(t, err)
+}
+
+func TestHashComponent(t *testing.T) {
+	cases := map[string]string{
+		"test topic":   "test.20topic",
+		"v1.2 (beta)":  "v1.2E2.20.28beta.29",
+		"a/b?c=d&e":    "a.2Fb.3Fc.3Dd.26e",
+		"🎉":            ".F0.9F.8E.89",
+		"don't-panic!": "don't-panic!",
+	}
+
+	for decoded, encoded := range cases {
+		assert.Equal(t, encoded, EncodeHashComponent(decoded))
+
+		result, err := DecodeHashComponent(encoded)
+		require.NoError(t, err)
+		assert.Equal(t, decoded, result)
+	}
+}
+
+func TestParseChannelOperand(t *testing.T) {
+	id, ok := ParseChannelOperand("9-Verona")
+	assert.True(t, ok)
+	assert.Equal(t, 9, id)
+
+	id, ok = ParseChannelOperand("42")
+	assert.True(t, ok)
+	assert.Equal(t, 42, id)
+
+	_, ok = ParseChannelOperand("Verona")
+	assert.False(t, ok)
+}
+
+func TestMessageURLs(t *testing.T) {
+	assert.Equal(t,
+		"https://chat.zulip.org/#narrow/channel/9-design-team/topic/new.20logo/near/123",
+		ChannelMessageURL("https://chat.zulip.org/", 9, "design team", "new logo", 123),
+	)
+	assert.Equal(t, "https://chat.zulip.org/#narrow/dm/5,8-dm/near/42", DMMessageURL("https://chat.zulip.org", []int{8, 5}, 42))
+	assert.Equal(t, "3,5,8-group", DMSlug([]int{8, 3, 5}))
+
+	filter, err := ParseURL(ChannelMessageURL("https://chat.zulip.org", 9, "Verona", "v1.0 (final)", 7))
+	require.NoError(t, err)
+	assert.Equal(t, Filter{New(Channel, "9-Verona"), New(Topic, "v1.0 (final)"), New(Near, "7")}, filter)
+}
@@ -0,0 +1,62 @@
+package org
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+)
+
+type GetServerSettingsResponse struct {
+	zulip.APIResponseBase
+	getServerSettingsData
+}
+
+type getServerSettingsData struct {
+	AuthenticationMethods       map[string]bool `json:"authentication_methods"`
+	ZulipFeatureLevel           int             `json:"zulip_feature_level"`
+	ZulipVersion                string          `json:"zulip_version"`
+	ZulipMergeBase              string          `json:"zulip_merge_base"`
+	PushNotificationsEnabled    bool            `json:"push_notifications_enabled"`
+	IsIncompatible              bool            `json:"is_incompatible"`
+	EmailAuthEnabled            bool            `json:"email_auth_enabled"`
+	RequireEmailFormatUsernames bool            `json:"require_email_format_usernames"`
+	RealmURL                    string          `json:"realm_url"`
+	RealmName                   string          `json:"realm_name"`
+	RealmIcon                   string          `json:"realm_icon"`
+	RealmDescription            string          `json:"realm_description"`
+	RealmWebPublicAccessEnabled bool            `json:"realm_web_public_access_enabled"`
+}
+
+func (g *GetServerSettingsResponse) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &g.APIResponseBase); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(b, &g.getServerSettingsData); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ServerFeatures returns the version information of the server for feature level checks.
+func (g *GetServerSettingsResponse) ServerFeatures() *zulip.ServerFeatures {
+	return &zulip.ServerFeatures{
+		ZulipVersion:      g.ZulipVersion,
+		ZulipFeatureLevel: g.ZulipFeatureLevel,
+		ZulipMergeBase:    g.ZulipMergeBase,
+	}
+}
+
+// GetServerSettings fetches global settings for the server and the
+// organization. It doesn't require authentication.
+func (svc *Service) GetServerSettings(ctx context.Context) (*GetServerSettingsResponse, error) {
+	const (
+		method = http.MethodGet
+		path   = "/api/v1/server_settings"
+	)
+
+	return zulip.Do[GetServerSettingsResponse](ctx, svc.client, method, path, nil)
+}
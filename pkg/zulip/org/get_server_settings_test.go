@@ -0,0 +1,59 @@
+package org_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/org"
+)
+
+func TestGetServerSettings(t *testing.T) {
+	client := createMockClient(`{
+		"authentication_methods": {
+			"password": true,
+			"dev": true
+		},
+		"email_auth_enabled": true,
+		"is_incompatible": false,
+		"msg": "",
+		"push_notifications_enabled": false,
+		"realm_description": "<p>The Zulip development environment default organization.</p>",
+		"realm_icon": "https://secure.gravatar.com/avatar/62429d594b6ffc712f54aee976a18b44?d=identicon",
+		"realm_name": "Zulip Dev",
+		"realm_url": "http://localhost:9991",
+		"realm_web_public_access_enabled": false,
+		"require_email_format_usernames": true,
+		"result": "success",
+		"zulip_feature_level": 250,
+		"zulip_merge_base": "9.0-dev-1234-g1234567890",
+		"zulip_version": "9.0-dev-1234-g1234567890"
+	}`)
+
+	service := org.NewService(client)
+
+	resp, err := service.GetServerSettings(context.Background())
+	require.NoError(t, err)
+
+	mc := client.(*mockClient)
+	assert.Equal(t, http.MethodGet, mc.method)
+	assert.Equal(t, "/api/v1/server_settings", mc.path)
+
+	assert.Equal(t, "Zulip Dev", resp.RealmName)
+	assert.Equal(t, "http://localhost:9991", resp.RealmURL)
+	assert.True(t, resp.AuthenticationMethods["password"])
+	assert.Equal(t, &zulip.ServerFeatures{
+		ZulipVersion:      "9.0-dev-1234-g1234567890",
+		ZulipFeatureLevel: 250,
+		ZulipMergeBase:    "9.0-dev-1234-g1234567890",
+	}, resp.ServerFeatures())
+
+	features := resp.ServerFeatures()
+	assert.True(t, features.SupportsFeature(zulip.FeatureLevelChannelMessageType))
+	assert.True(t, features.SupportsFeature(zulip.FeatureLevelChannelNarrowOperators))
+	assert.False(t, features.SupportsFeature(zulip.FeatureLevelEmptyTopicName))
+}
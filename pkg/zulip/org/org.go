@@ -1,6 +1,7 @@
 // Package org provides functionality for managing Zulip server and organization settings.
 //
 // Implemented features:
+//   - Get server settings
 //   - Upload custom emoji (from file path, bytes, or reader)
 //
 // See https://zulip.com/api/ for the complete API documentation.
@@ -0,0 +1,169 @@
+package zulip
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// EncodeParams converts a struct with `param` tags into request parameters
+// for RESTClient.DoRequest.
+//
+// The tag format is `param:"name[,omitempty]"`. Nil pointers, slices, maps
+// and interfaces are always skipped, omitempty also skips other zero values.
+// Embedded structs are flattened. Scalar values are kept as is, anything else
+// (slices, maps, structs and json.Marshaler implementations) is JSON-encoded,
+// which is what Zulip expects for non-scalar parameters.
+func EncodeParams(params any) (map[string]any, error) {
+	val := reflect.ValueOf(params)
+	for val.Kind() == reflect.Pointer {
+		if val.IsNil() {
+			return map[string]any{}, nil
+		}
+
+		val = val.Elem()
+	}
+
+	if val.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("params must be a struct, got %T", params)
+	}
+
+	data := map[string]any{}
+	if err := encodeParamsStruct(val, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func encodeParamsStruct(val reflect.Value, data map[string]any) error {
+	typ := val.Type()
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		fieldVal := val.Field(i)
+
+		tag, hasTag := field.Tag.Lookup("param")
+		if !hasTag {
+			if field.Anonymous && fieldVal.Kind() == reflect.Struct {
+				if err := encodeParamsStruct(fieldVal, data); err != nil {
+					return err
+				}
+			}
+
+			continue
+		}
+
+		name, flags, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+
+		if isNilValue(fieldVal) || (flags == "omitempty" && fieldVal.IsZero()) {
+			continue
+		}
+
+		for fieldVal.Kind() == reflect.Pointer || fieldVal.Kind() == reflect.Interface {
+			fieldVal = fieldVal.Elem()
+		}
+
+		value, err := normalizeParam(fieldVal.Interface())
+		if err != nil {
+			return fmt.Errorf("encoding param %s: %w", name, err)
+		}
+
+		data[name] = value
+	}
+
+	return nil
+}
+
+func isNilValue(val reflect.Value) bool {
+	switch val.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+		return val.IsNil()
+	default:
+		return false
+	}
+}
+
+func isScalarKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// normalizeParam keeps scalar values as is and JSON-encodes everything else.
+func normalizeParam(value any) (any, error) {
+	if value == nil || isScalarKind(reflect.TypeOf(value).Kind()) {
+		return value, nil
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(encoded), nil
+}
+
+// EncodeParamValue formats a single parameter value for a form-encoded
+// request body or query string. Strings are sent verbatim, other scalars use
+// their Go formatting and anything else is JSON-encoded.
+func EncodeParamValue(value any) (string, error) {
+	normalized, err := normalizeParam(value)
+	if err != nil {
+		return "", err
+	}
+
+	switch typed := normalized.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return typed, nil
+	case bool:
+		return strconv.FormatBool(typed), nil
+	default:
+		return fmt.Sprintf("%v", typed), nil
+	}
+}
+
+// Do sends a request and decodes the response into a new T.
+//
+// params can be nil, a map[string]any or a struct with `param` tags (see EncodeParams).
+func Do[T any, PT interface {
+	*T
+	APIResponse
+}](ctx context.Context, client RESTClient, method, path string, params any, opts ...DoRequestOption) (*T, error) {
+	var (
+		data map[string]any
+		err  error
+	)
+
+	switch typed := params.(type) {
+	case nil:
+		data = map[string]any{}
+	case map[string]any:
+		data = typed
+	default:
+		data, err = EncodeParams(params)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp := PT(new(T))
+	if err := client.DoRequest(ctx, method, path, data, resp, opts...); err != nil {
+		return nil, err
+	}
+
+	return (*T)(resp), nil
+}
@@ -0,0 +1,120 @@
+package zulip_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+)
+
+type embeddedParams struct {
+	Flag *bool `param:"flag"`
+}
+
+type testParams struct {
+	embeddedParams
+	Name     string            `param:"name"`
+	Count    int               `param:"count,omitempty"`
+	Enabled  *bool             `param:"enabled"`
+	IDs      []int             `param:"ids"`
+	Extra    map[string]string `param:"extra"`
+	Any      any               `param:"any"`
+	Ignored  string            `param:"-"`
+	Internal string
+}
+
+func TestEncodeParams(t *testing.T) {
+	enabled := false
+	flag := true
+
+	data, err := zulip.EncodeParams(&testParams{
+		embeddedParams: embeddedParams{Flag: &flag},
+		Name:           "test",
+		Enabled:        &enabled,
+		IDs:            []int{1, 2},
+		Extra:          map[string]string{"key": "value"},
+		Any:            []string{"a"},
+		Ignored:        "ignored",
+		Internal:       "internal",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]any{
+		"flag":    true,
+		"name":    "test",
+		"enabled": false,
+		"ids":     "[1,2]",
+		"extra":   `{"key":"value"}`,
+		"any":     `["a"]`,
+	}, data)
+}
+
+func TestEncodeParamsSkipsNilAndEmpty(t *testing.T) {
+	data, err := zulip.EncodeParams(testParams{})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]any{"name": ""}, data)
+
+	data, err = zulip.EncodeParams((*testParams)(nil))
+	require.NoError(t, err)
+	assert.Empty(t, data)
+}
+
+func TestEncodeParamsRejectsNonStruct(t *testing.T) {
+	_, err := zulip.EncodeParams([]int{1})
+	assert.Error(t, err)
+}
+
+func TestEncodeParamValue(t *testing.T) {
+	tests := []struct {
+		value    any
+		expected string
+	}{
+		{"text", "text"},
+		{42, "42"},
+		{true, "true"},
+		{1.5, "1.5"},
+		{[]int{1, 2}, "[1,2]"},
+		{map[string]int{"a": 1}, `{"a":1}`},
+		{nil, ""},
+	}
+
+	for _, tt := range tests {
+		encoded, err := zulip.EncodeParamValue(tt.value)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, encoded)
+	}
+}
+
+func TestDo(t *testing.T) {
+	var form url.Values
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		form, _ = url.ParseQuery(string(body))
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"result": "success", "msg": "done"}`))
+	}))
+	defer mockServer.Close()
+
+	client, err := zulip.NewClient(zulip.Credentials(mockServer.URL, "email@test", "apikey"))
+	require.NoError(t, err)
+
+	resp, err := zulip.Do[zulip.APIResponseBase](context.Background(), client, http.MethodPost, "/api/v1/test", &testParams{
+		Name: "test",
+		IDs:  []int{3},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, resp.IsSuccess())
+	assert.Equal(t, "done", resp.Msg())
+	assert.Equal(t, url.Values{"name": {"test"}, "ids": {"[3]"}}, form)
+}
@@ -0,0 +1,210 @@
+package zulip
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimitConfig configures the client-side rate limit handling enabled by WithRateLimiting.
+type RateLimitConfig struct {
+	// MaxRetries is the number of times a request is retried after the
+	// server responds with HTTP 429. Zero means rate limited requests fail
+	// immediately.
+	MaxRetries int
+	// MaxWait is the longest the client will wait before a single attempt.
+	// If the server asks for a longer wait, the rate limit error is returned
+	// instead. Zero means no cap.
+	MaxWait time.Duration
+	// ThrottleThreshold enables proactive throttling: once the
+	// X-RateLimit-Remaining of a bucket drops to this value or below, the
+	// remaining requests are spread evenly until X-RateLimit-Reset. Zero
+	// disables proactive throttling.
+	ThrottleThreshold int
+}
+
+// DefaultRateLimitConfig is a reasonable configuration for long-running clients.
+var DefaultRateLimitConfig = RateLimitConfig{
+	MaxRetries:        3,
+	MaxWait:           2 * time.Minute,
+	ThrottleThreshold: 5,
+}
+
+// WithRateLimiting enables tracking of the X-RateLimit-* headers per endpoint
+// bucket and automatic retries of requests that hit the rate limit.
+func WithRateLimiting(config RateLimitConfig) ClientOption {
+	return func(o *clientOptions) error {
+		if config.MaxRetries < 0 || config.MaxWait < 0 || config.ThrottleThreshold < 0 {
+			return fmt.Errorf("invalid rate limit config: %+v", config)
+		}
+
+		o.rateLimit = &config
+
+		return nil
+	}
+}
+
+type rateLimitBucket struct {
+	remaining int
+	reset     time.Time
+	// blockedUntil is set when the server responded with HTTP 429.
+	blockedUntil time.Time
+}
+
+type rateLimiter struct {
+	config  RateLimitConfig
+	lock    sync.Mutex
+	buckets map[string]*rateLimitBucket
+	now     func() time.Time
+}
+
+func newRateLimiter(config RateLimitConfig) *rateLimiter {
+	return &rateLimiter{
+		config:  config,
+		buckets: make(map[string]*rateLimitBucket),
+		now:     time.Now,
+	}
+}
+
+// rateLimitBucketKey groups requests by method and path with numeric path
+// segments (message IDs, user IDs, etc.) collapsed.
+func rateLimitBucketKey(method, path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if _, err := strconv.Atoi(segment); err == nil {
+			segments[i] = ":id"
+		}
+	}
+
+	return method + " " + strings.Join(segments, "/")
+}
+
+// delay returns how long a request to the given bucket should wait before being sent.
+func (rl *rateLimiter) delay(bucket string) time.Duration {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+
+	b, ok := rl.buckets[bucket]
+	if !ok {
+		return 0
+	}
+
+	now := rl.now()
+	if b.blockedUntil.After(now) {
+		return b.blockedUntil.Sub(now)
+	}
+
+	if rl.config.ThrottleThreshold <= 0 || b.remaining > rl.config.ThrottleThreshold || !b.reset.After(now) {
+		return 0
+	}
+
+	untilReset := b.reset.Sub(now)
+	if b.remaining <= 0 {
+		return untilReset
+	}
+
+	// Spread the remaining requests evenly over the time left in the window.
+	// The counter is decremented locally so that concurrent callers don't all
+	// get the same delay before the next response updates the bucket.
+	wait := untilReset / time.Duration(b.remaining+1)
+	b.remaining--
+
+	return wait
+}
+
+func (rl *rateLimiter) wait(ctx context.Context, bucket string) error {
+	if rl == nil {
+		return nil
+	}
+
+	d := rl.delay(bucket)
+	if d <= 0 {
+		return nil
+	}
+
+	if rl.config.MaxWait > 0 && d > rl.config.MaxWait {
+		d = rl.config.MaxWait
+	}
+
+	return sleepContext(ctx, d)
+}
+
+// update records the rate limit state reported by a response.
+func (rl *rateLimiter) update(bucket string, statusCode int, headers http.Header, retryAfter time.Duration) {
+	if rl == nil {
+		return
+	}
+
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+
+	b, ok := rl.buckets[bucket]
+	if !ok {
+		b = &rateLimitBucket{remaining: -1}
+		rl.buckets[bucket] = b
+	}
+
+	now := rl.now()
+	if remaining, err := strconv.Atoi(headers.Get(XRateLimitRemaining)); err == nil {
+		b.remaining = remaining
+	}
+
+	if reset, err := strconv.ParseFloat(headers.Get(XRateLimitReset), 64); err == nil {
+		sec, frac := math.Modf(reset)
+		b.reset = time.Unix(int64(sec), int64(frac*float64(time.Second)))
+	}
+
+	if statusCode == http.StatusTooManyRequests {
+		b.blockedUntil = now.Add(retryAfter)
+	}
+
+	if b.remaining < 0 && !b.reset.After(now) && !b.blockedUntil.After(now) {
+		delete(rl.buckets, bucket)
+	}
+}
+
+// shouldRetry returns whether a request that was rejected with HTTP 429
+// should be retried after waiting for retryAfter.
+func (rl *rateLimiter) shouldRetry(attempt int, retryAfter time.Duration) bool {
+	if rl == nil || attempt >= rl.config.MaxRetries {
+		return false
+	}
+
+	return rl.config.MaxWait <= 0 || retryAfter <= rl.config.MaxWait
+}
+
+// parseRetryAfter extracts the wait time from the Retry-After header or the
+// retry-after field that Zulip includes in RATE_LIMIT_HIT error bodies.
+func parseRetryAfter(response APIResponse, headers http.Header) time.Duration {
+	seconds, err := strconv.ParseFloat(headers.Get("Retry-After"), 64)
+	if err != nil {
+		if withFields, ok := response.(interface{ FieldValue(string) (any, error) }); ok {
+			if v, err := withFields.FieldValue("retry-after"); err == nil {
+				seconds, _ = v.(float64)
+			}
+		}
+	}
+
+	if seconds <= 0 {
+		return time.Second
+	}
+
+	return time.Duration(seconds * float64(time.Second))
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,185 @@
+package zulip_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+)
+
+const rateLimitHitBody = `{"code": "RATE_LIMIT_HIT", "msg": "API usage exceeded rate limit", "result": "error", "retry-after": 0.05}`
+
+func newRateLimitTestClient(t *testing.T, handler http.HandlerFunc, config zulip.RateLimitConfig) *zulip.Client {
+	t.Helper()
+
+	mockServer := httptest.NewServer(handler)
+	t.Cleanup(mockServer.Close)
+
+	client, err := zulip.NewClient(zulip.Credentials(mockServer.URL, "email@test", "apikey"),
+		zulip.WithRateLimiting(config),
+	)
+	require.NoError(t, err)
+
+	return client
+}
+
+func TestRateLimitRetryAfterBody(t *testing.T) {
+	var calls atomic.Int32
+
+	client := newRateLimitTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(rateLimitHitBody))
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"result": "success"}`))
+	}, zulip.RateLimitConfig{MaxRetries: 2})
+
+	var resp zulip.APIResponseBase
+
+	start := time.Now()
+	err := client.DoRequest(context.Background(), http.MethodPost, "/api/v1/messages", map[string]any{"key": "value"}, &resp)
+	require.NoError(t, err)
+
+	assert.True(t, resp.IsSuccess())
+	assert.EqualValues(t, 2, calls.Load())
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestRateLimitRetryAfterHeader(t *testing.T) {
+	var calls atomic.Int32
+
+	client := newRateLimitTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0.05")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"code": "RATE_LIMIT_HIT", "msg": "API usage exceeded rate limit", "result": "error"}`))
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"result": "success"}`))
+	}, zulip.RateLimitConfig{MaxRetries: 1})
+
+	var resp zulip.APIResponseBase
+
+	err := client.DoRequest(context.Background(), http.MethodGet, "/api/v1/users/8", nil, &resp)
+	require.NoError(t, err)
+
+	assert.True(t, resp.IsSuccess())
+	assert.EqualValues(t, 2, calls.Load())
+}
+
+func TestRateLimitMaxRetriesExceeded(t *testing.T) {
+	var calls atomic.Int32
+
+	client := newRateLimitTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(rateLimitHitBody))
+	}, zulip.RateLimitConfig{MaxRetries: 2})
+
+	var resp zulip.APIResponseBase
+
+	err := client.DoRequest(context.Background(), http.MethodPost, "/api/v1/messages", nil, &resp)
+	require.Error(t, err)
+
+	assert.True(t, zulip.IsCode(err, zulip.ErrRateLimitHit))
+	assert.EqualValues(t, 3, calls.Load())
+}
+
+func TestRateLimitMaxWaitExceeded(t *testing.T) {
+	var calls atomic.Int32
+
+	client := newRateLimitTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(rateLimitHitBody))
+	}, zulip.RateLimitConfig{MaxRetries: 5, MaxWait: time.Second})
+
+	var resp zulip.APIResponseBase
+
+	err := client.DoRequest(context.Background(), http.MethodPost, "/api/v1/messages", nil, &resp)
+	require.Error(t, err)
+
+	assert.True(t, zulip.IsCode(err, zulip.ErrRateLimitHit))
+	assert.EqualValues(t, 1, calls.Load())
+}
+
+func TestRateLimitWithoutOptionDoesNotRetry(t *testing.T) {
+	var calls atomic.Int32
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(rateLimitHitBody))
+	}))
+	defer mockServer.Close()
+
+	client, err := zulip.NewClient(zulip.Credentials(mockServer.URL, "email@test", "apikey"))
+	require.NoError(t, err)
+
+	var resp zulip.APIResponseBase
+
+	err = client.DoRequest(context.Background(), http.MethodPost, "/api/v1/messages", nil, &resp)
+	assert.True(t, zulip.IsCode(err, zulip.ErrRateLimitHit))
+	assert.EqualValues(t, 1, calls.Load())
+}
+
+func TestRateLimitProactiveThrottle(t *testing.T) {
+	reset := time.Now().Add(300 * time.Millisecond)
+
+	client := newRateLimitTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(zulip.XRateLimitLimit, "200")
+		w.Header().Set(zulip.XRateLimitRemaining, "0")
+		w.Header().Set(zulip.XRateLimitReset, strconv.FormatFloat(float64(reset.UnixMilli())/1000, 'f', 3, 64))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"result": "success"}`))
+	}, zulip.RateLimitConfig{ThrottleThreshold: 1})
+
+	var resp zulip.APIResponseBase
+
+	err := client.DoRequest(context.Background(), http.MethodGet, "/api/v1/users/1", nil, &resp)
+	require.NoError(t, err)
+	assert.Equal(t, "0", resp.XRateLimitRemaining())
+
+	// Requests in other buckets aren't delayed.
+	start := time.Now()
+	err = client.DoRequest(context.Background(), http.MethodGet, "/api/v1/messages", nil, &resp)
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 200*time.Millisecond)
+
+	// The same endpoint with a different ID shares the bucket and waits for the reset.
+	err = client.DoRequest(context.Background(), http.MethodGet, "/api/v1/users/2", nil, &resp)
+	require.NoError(t, err)
+	assert.False(t, time.Now().Before(reset.Add(-10*time.Millisecond)))
+}
+
+func TestRateLimitWaitRespectsContext(t *testing.T) {
+	client := newRateLimitTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "10")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(rateLimitHitBody))
+	}, zulip.RateLimitConfig{MaxRetries: 1})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var resp zulip.APIResponseBase
+
+	err := client.DoRequest(ctx, http.MethodPost, "/api/v1/messages", nil, &resp)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -15,6 +15,17 @@ type Message struct {
 	LocalID string      `json:"local_message_id,omitempty"`
 }
 
+// UnmarshalJSON copies the flags of the event into the message, so the
+// message data can be converted without the event around it.
+func (e *Message) UnmarshalJSON(b []byte) error {
+	type message Message
+	if err := json.Unmarshal(b, (*message)(e)); err != nil {
+		return err
+	}
+	e.Message.Flags = e.Flags
+	return nil
+}
+
 type MessageData struct {
 	ID               int              `json:"id"`
 	Type             string           `json:"type"`
@@ -35,6 +46,10 @@ type MessageData struct {
 	Submessages      []Submessage     `json:"submessages"`
 	Timestamp        int              `json:"timestamp"`
 	TopicLinks       []TopicLinks     `json:"topic_links"`
+
+	// Flags are the personal flags of the receiving user. They're next to the
+	// message in message events and are copied here when the event is parsed.
+	Flags []string `json:"flags,omitempty"`
 }
 
 type DisplayRecipient struct {
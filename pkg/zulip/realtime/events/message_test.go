@@ -57,3 +57,27 @@ func TestMessage(t *testing.T) {
 	assert.Empty(t, v.Message.Reactions)
 	assert.Equal(t, 23, v.Message.RecipientID)
 }
+
+func TestMessageFlags(t *testing.T) {
+	eventExample := `{
+    "flags": ["read", "mentioned"],
+    "id": 2,
+    "message": {
+        "content": "<p>@<strong>Hamlet</strong></p>",
+        "display_recipient": "Denmark",
+        "id": 32,
+        "stream_id": 1,
+        "subject": "test",
+        "type": "stream"
+    },
+    "type": "message"
+}`
+
+	v := events.Message{}
+	err := json.Unmarshal([]byte(eventExample), &v)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"read", "mentioned"}, v.Flags)
+	assert.Equal(t, v.Flags, v.Message.Flags)
+	assert.Equal(t, 32, v.Message.ID)
+}
@@ -0,0 +1,5 @@
+package events
+
+// RealmType is the event type for changes to realm (organization) settings.
+// It's mostly useful in fetch_event_types to get the realm settings when registering a queue.
+const RealmType EventType = "realm"
@@ -10,13 +10,16 @@ type RealmEmoji struct {
 }
 
 type RealmEmojiData struct {
-	RealmEmoji map[string]struct {
-		AuthorID    int    `json:"author_id"`
-		Deactivated bool   `json:"deactivated"`
-		ID          string `json:"id"`
-		Name        string `json:"name"`
-		SourceURL   string `json:"source_url"`
-	} `json:"realm_emoji"`
+	RealmEmoji map[string]RealmEmojiItem `json:"realm_emoji"`
+}
+
+type RealmEmojiItem struct {
+	AuthorID    int    `json:"author_id"`
+	Deactivated bool   `json:"deactivated"`
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	SourceURL   string `json:"source_url"`
+	StillURL    string `json:"still_url,omitempty"`
 }
 
 func (e *RealmEmoji) EventID() int {
@@ -1,14 +1,56 @@
 package events
 
-import "go.mau.fi/mautrix-zulip/pkg/zulip"
+import (
+	"encoding/json"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+)
 
 const RealmUserType EventType = "realm_user"
 
+const (
+	RealmUserOpAdd    = "add"
+	RealmUserOpRemove = "remove"
+	RealmUserOpUpdate = "update"
+)
+
 type RealmUser struct {
 	ID     int       `json:"id"`
 	Op     string    `json:"op"`
 	Type   EventType `json:"type"`
 	Person Person    `json:"person"`
+
+	// rawPerson is kept because update events only contain the changed fields.
+	rawPerson json.RawMessage
+}
+
+func (e *RealmUser) UnmarshalJSON(b []byte) error {
+	type realmUser RealmUser
+
+	raw := struct {
+		*realmUser
+		RawPerson json.RawMessage `json:"person"`
+	}{realmUser: (*realmUser)(e)}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	e.rawPerson = raw.RawPerson
+	if len(raw.RawPerson) > 0 {
+		return json.Unmarshal(raw.RawPerson, &e.Person)
+	}
+
+	return nil
+}
+
+// ApplyTo updates the given person with the fields present in the event.
+// For update events, fields that weren't changed are left as is.
+func (e *RealmUser) ApplyTo(person *Person) error {
+	if len(e.rawPerson) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(e.rawPerson, person)
 }
 
 type Person struct {
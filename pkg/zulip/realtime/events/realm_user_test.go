@@ -49,3 +49,29 @@ func TestRealmUser(t *testing.T) {
 	assert.Empty(t, v.Person.DeliveryEmail)
 	assert.Equal(t, 38, v.Person.UserID)
 }
+
+func TestRealmUserUpdate(t *testing.T) {
+	eventExample := `{
+    "type": "realm_user",
+    "op": "update",
+    "person": {
+        "full_name": "New name",
+        "user_id": 38
+    },
+    "id": 0
+}`
+
+	v := events.RealmUser{}
+	err := json.Unmarshal([]byte(eventExample), &v)
+	require.NoError(t, err)
+
+	assert.Equal(t, events.RealmUserOpUpdate, v.EventOp())
+	assert.Equal(t, 38, v.Person.UserID)
+
+	person := events.Person{UserID: 38, FullName: "Old name", Email: "foo@zulip.com", IsActive: true}
+	require.NoError(t, v.ApplyTo(&person))
+
+	assert.Equal(t, "New name", person.FullName)
+	assert.Equal(t, "foo@zulip.com", person.Email)
+	assert.True(t, person.IsActive)
+}
@@ -0,0 +1,39 @@
+package events
+
+import (
+	"go.mau.fi/mautrix-zulip/pkg/zulip/scheduledmessages"
+)
+
+const ScheduledMessagesType EventType = "scheduled_messages"
+
+const (
+	ScheduledMessagesOpAdd    = "add"
+	ScheduledMessagesOpUpdate = "update"
+	ScheduledMessagesOpRemove = "remove"
+)
+
+type ScheduledMessages struct {
+	ID   int       `json:"id"`
+	Type EventType `json:"type"`
+	Op   string    `json:"op"`
+
+	// ScheduledMessages is set for add.
+	ScheduledMessages []scheduledmessages.ScheduledMessage `json:"scheduled_messages,omitempty"`
+	// ScheduledMessage is set for update and contains the full new state of the message.
+	ScheduledMessage *scheduledmessages.ScheduledMessage `json:"scheduled_message,omitempty"`
+	// ScheduledMessageID is set for remove, which happens both when a message
+	// is sent and when it's deleted.
+	ScheduledMessageID int `json:"scheduled_message_id,omitempty"`
+}
+
+func (e *ScheduledMessages) EventID() int {
+	return e.ID
+}
+
+func (e *ScheduledMessages) EventType() EventType {
+	return e.Type
+}
+
+func (e *ScheduledMessages) EventOp() string {
+	return e.Op
+}
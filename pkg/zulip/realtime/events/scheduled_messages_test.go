@@ -0,0 +1,84 @@
+package events_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip/realtime/events"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/scheduledmessages"
+)
+
+func TestScheduledMessagesAdd(t *testing.T) {
+	eventExample := `{
+    "type": "scheduled_messages",
+    "op": "add",
+    "scheduled_messages": [
+        {
+            "scheduled_message_id": 17,
+            "type": "private",
+            "to": [6],
+            "content": "Hello there!",
+            "rendered_content": "<p>Hello there!</p>",
+            "scheduled_delivery_timestamp": 1681662420,
+            "failed": false
+        }
+    ],
+    "id": 0
+}`
+
+	v := events.ScheduledMessages{}
+	err := json.Unmarshal([]byte(eventExample), &v)
+	require.NoError(t, err)
+
+	assert.Equal(t, events.ScheduledMessagesType, v.EventType())
+	assert.Equal(t, events.ScheduledMessagesOpAdd, v.EventOp())
+	require.Len(t, v.ScheduledMessages, 1)
+	assert.Equal(t, 17, v.ScheduledMessages[0].ScheduledMessageID)
+	assert.Equal(t, scheduledmessages.To{UserIDs: []int{6}}, v.ScheduledMessages[0].To)
+}
+
+func TestScheduledMessagesUpdate(t *testing.T) {
+	eventExample := `{
+    "type": "scheduled_messages",
+    "op": "update",
+    "scheduled_message": {
+        "scheduled_message_id": 17,
+        "type": "stream",
+        "to": 6,
+        "topic": "Welcome",
+        "content": "Hello there!",
+        "rendered_content": "<p>Hello there!</p>",
+        "scheduled_delivery_timestamp": 1681662420,
+        "failed": true
+    },
+    "id": 0
+}`
+
+	v := events.ScheduledMessages{}
+	err := json.Unmarshal([]byte(eventExample), &v)
+	require.NoError(t, err)
+
+	assert.Equal(t, events.ScheduledMessagesOpUpdate, v.EventOp())
+	require.NotNil(t, v.ScheduledMessage)
+	assert.Equal(t, scheduledmessages.To{StreamID: 6}, v.ScheduledMessage.To)
+	assert.True(t, v.ScheduledMessage.Failed)
+}
+
+func TestScheduledMessagesRemove(t *testing.T) {
+	eventExample := `{
+    "type": "scheduled_messages",
+    "op": "remove",
+    "scheduled_message_id": 17,
+    "id": 0
+}`
+
+	v := events.ScheduledMessages{}
+	err := json.Unmarshal([]byte(eventExample), &v)
+	require.NoError(t, err)
+
+	assert.Equal(t, events.ScheduledMessagesOpRemove, v.EventOp())
+	assert.Equal(t, 17, v.ScheduledMessageID)
+}
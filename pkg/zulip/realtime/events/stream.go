@@ -0,0 +1,50 @@
+package events
+
+import (
+	"go.mau.fi/mautrix-zulip/pkg/zulip/channels"
+)
+
+const StreamType EventType = "stream"
+
+const (
+	StreamOpCreate = "create"
+	StreamOpDelete = "delete"
+	StreamOpUpdate = "update"
+)
+
+type Stream struct {
+	ID   int       `json:"id"`
+	Op   string    `json:"op"`
+	Type EventType `json:"type"`
+	StreamData
+}
+
+type StreamData struct {
+	// Streams is set for create and delete.
+	Streams []channels.ChannelInfo `json:"streams,omitempty"`
+
+	// StreamID, Name, Property and Value are set for update. Name is the name
+	// of the channel before the update.
+	StreamID int    `json:"stream_id,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Property string `json:"property,omitempty"`
+	Value    any    `json:"value,omitempty"`
+}
+
+func (e *Stream) EventID() int {
+	return e.ID
+}
+
+func (e *Stream) EventType() EventType {
+	return e.Type
+}
+
+func (e *Stream) EventOp() string {
+	return e.Op
+}
+
+// ApplyTo sets the changed property of an update event on the target,
+// which can be a channels.ChannelInfo or a channels.SubscribedChannel.
+func (e *Stream) ApplyTo(target any) error {
+	return applyProperty(target, e.Property, e.Value)
+}
@@ -0,0 +1,38 @@
+package events_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip/channels"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/realtime/events"
+)
+
+func TestStreamUpdate(t *testing.T) {
+	eventExample := `{
+    "type": "stream",
+    "op": "update",
+    "property": "name",
+    "value": "renamed",
+    "stream_id": 11,
+    "name": "test",
+    "id": 0
+}`
+
+	v := events.Stream{}
+	err := json.Unmarshal([]byte(eventExample), &v)
+	require.NoError(t, err)
+
+	assert.Equal(t, events.StreamType, v.EventType())
+	assert.Equal(t, events.StreamOpUpdate, v.EventOp())
+	assert.Equal(t, "test", v.Name)
+
+	sub := channels.SubscribedChannel{StreamID: 11, Name: "test", Description: "desc"}
+	require.NoError(t, v.ApplyTo(&sub))
+
+	assert.Equal(t, "renamed", sub.Name)
+	assert.Equal(t, "desc", sub.Description)
+}
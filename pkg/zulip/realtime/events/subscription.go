@@ -0,0 +1,53 @@
+package events
+
+import (
+	"go.mau.fi/mautrix-zulip/pkg/zulip/channels"
+)
+
+const SubscriptionType EventType = "subscription"
+
+const (
+	SubscriptionOpAdd        = "add"
+	SubscriptionOpRemove     = "remove"
+	SubscriptionOpUpdate     = "update"
+	SubscriptionOpPeerAdd    = "peer_add"
+	SubscriptionOpPeerRemove = "peer_remove"
+)
+
+type Subscription struct {
+	ID   int       `json:"id"`
+	Op   string    `json:"op"`
+	Type EventType `json:"type"`
+	SubscriptionData
+}
+
+type SubscriptionData struct {
+	// Subscriptions is set for add and remove. Remove only includes the stream ID and name.
+	Subscriptions []channels.SubscribedChannel `json:"subscriptions,omitempty"`
+
+	// StreamID, Property and Value are set for update.
+	StreamID int    `json:"stream_id,omitempty"`
+	Property string `json:"property,omitempty"`
+	Value    any    `json:"value,omitempty"`
+
+	// StreamIDs and UserIDs are set for peer_add and peer_remove.
+	StreamIDs []int `json:"stream_ids,omitempty"`
+	UserIDs   []int `json:"user_ids,omitempty"`
+}
+
+func (e *Subscription) EventID() int {
+	return e.ID
+}
+
+func (e *Subscription) EventType() EventType {
+	return e.Type
+}
+
+func (e *Subscription) EventOp() string {
+	return e.Op
+}
+
+// ApplyTo sets the changed property of an update event on the subscription.
+func (e *Subscription) ApplyTo(sub *channels.SubscribedChannel) error {
+	return applyProperty(sub, e.Property, e.Value)
+}
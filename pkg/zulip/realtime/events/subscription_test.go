@@ -0,0 +1,81 @@
+package events_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip/channels"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/realtime/events"
+)
+
+func TestSubscriptionAdd(t *testing.T) {
+	eventExample := `{
+    "type": "subscription",
+    "op": "add",
+    "subscriptions": [
+        {
+            "name": "test",
+            "stream_id": 12,
+            "description": "A test channel",
+            "invite_only": false,
+            "color": "#76ce90",
+            "is_muted": false,
+            "pin_to_top": false,
+            "subscribers": [10, 11]
+        }
+    ],
+    "id": 0
+}`
+
+	v := events.Subscription{}
+	err := json.Unmarshal([]byte(eventExample), &v)
+	require.NoError(t, err)
+
+	assert.Equal(t, events.SubscriptionType, v.EventType())
+	assert.Equal(t, events.SubscriptionOpAdd, v.EventOp())
+	require.Len(t, v.Subscriptions, 1)
+	assert.Equal(t, 12, v.Subscriptions[0].StreamID)
+	assert.Equal(t, []int{10, 11}, v.Subscriptions[0].Subscribers)
+}
+
+func TestSubscriptionUpdate(t *testing.T) {
+	eventExample := `{
+    "type": "subscription",
+    "op": "update",
+    "property": "pin_to_top",
+    "value": true,
+    "stream_id": 11,
+    "id": 0
+}`
+
+	v := events.Subscription{}
+	err := json.Unmarshal([]byte(eventExample), &v)
+	require.NoError(t, err)
+
+	sub := channels.SubscribedChannel{StreamID: 11, Name: "test"}
+	require.NoError(t, v.ApplyTo(&sub))
+
+	assert.True(t, sub.PinToTop)
+	assert.Equal(t, "test", sub.Name)
+}
+
+func TestSubscriptionPeerAdd(t *testing.T) {
+	eventExample := `{
+    "type": "subscription",
+    "op": "peer_add",
+    "stream_ids": [9, 12],
+    "user_ids": [12],
+    "id": 0
+}`
+
+	v := events.Subscription{}
+	err := json.Unmarshal([]byte(eventExample), &v)
+	require.NoError(t, err)
+
+	assert.Equal(t, events.SubscriptionOpPeerAdd, v.EventOp())
+	assert.Equal(t, []int{9, 12}, v.StreamIDs)
+	assert.Equal(t, []int{12}, v.UserIDs)
+}
@@ -0,0 +1,32 @@
+package events
+
+const UpdateMessageFlagsType EventType = "update_message_flags"
+
+const (
+	UpdateMessageFlagsOpAdd    = "add"
+	UpdateMessageFlagsOpRemove = "remove"
+)
+
+type UpdateMessageFlags struct {
+	ID   int       `json:"id"`
+	Type EventType `json:"type"`
+	Op   string    `json:"op"`
+	// Flag is one of the flags in the messages package, e.g. "starred" or "read".
+	Flag     string `json:"flag"`
+	Messages []int  `json:"messages"`
+	// All is only set when all messages were marked as read, in which case
+	// Messages is empty.
+	All bool `json:"all"`
+}
+
+func (e *UpdateMessageFlags) EventID() int {
+	return e.ID
+}
+
+func (e *UpdateMessageFlags) EventType() EventType {
+	return e.Type
+}
+
+func (e *UpdateMessageFlags) EventOp() string {
+	return e.Op
+}
@@ -0,0 +1,37 @@
+package events_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip/realtime/events"
+)
+
+func TestUpdateMessageFlags(t *testing.T) {
+	eventExample := `{
+    "type": "update_message_flags",
+    "op": "add",
+    "operation": "add",
+    "flag": "starred",
+    "messages": [
+        63
+    ],
+    "all": false,
+    "id": 0
+}`
+
+	v := events.UpdateMessageFlags{}
+	err := json.Unmarshal([]byte(eventExample), &v)
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, v.EventID())
+	assert.Equal(t, events.UpdateMessageFlagsType, v.EventType())
+	assert.Equal(t, events.UpdateMessageFlagsOpAdd, v.EventOp())
+
+	assert.Equal(t, "starred", v.Flag)
+	assert.Equal(t, []int{63}, v.Messages)
+	assert.False(t, v.All)
+}
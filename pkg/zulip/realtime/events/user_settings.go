@@ -0,0 +1,70 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const UserSettingsType EventType = "user_settings"
+
+// UserSettings are the settings of the current user, as returned in the
+// initial state of a queue registration.
+type UserSettings struct {
+	TwentyFourHourTime             bool   `json:"twenty_four_hour_time"`
+	DefaultLanguage                string `json:"default_language"`
+	Timezone                       string `json:"timezone"`
+	Emojiset                       string `json:"emojiset"`
+	EmailAddressVisibility         int    `json:"email_address_visibility"`
+	DisplayEmojiReactionUsers      bool   `json:"display_emoji_reaction_users"`
+	EnterSends                     bool   `json:"enter_sends"`
+	SendPrivateTypingNotifications bool   `json:"send_private_typing_notifications"`
+	SendStreamTypingNotifications  bool   `json:"send_stream_typing_notifications"`
+	SendReadReceipts               bool   `json:"send_read_receipts"`
+	PresenceEnabled                bool   `json:"presence_enabled"`
+	EnableOfflinePushNotifications bool   `json:"enable_offline_push_notifications"`
+	EnableStreamPushNotifications  bool   `json:"enable_stream_push_notifications"`
+	PMContentInDesktopNotification bool   `json:"pm_content_in_desktop_notifications"`
+	WildcardMentionsNotify         bool   `json:"wildcard_mentions_notify"`
+	AutomaticallyFollowTopics      int    `json:"automatically_follow_topics_policy"`
+	AutomaticallyUnmuteTopics      int    `json:"automatically_unmute_topics_in_muted_streams_policy"`
+}
+
+type UserSettingsEvent struct {
+	ID       int       `json:"id"`
+	Op       string    `json:"op"`
+	Type     EventType `json:"type"`
+	Property string    `json:"property"`
+	Value    any       `json:"value"`
+}
+
+func (e *UserSettingsEvent) EventID() int {
+	return e.ID
+}
+
+func (e *UserSettingsEvent) EventType() EventType {
+	return e.Type
+}
+
+func (e *UserSettingsEvent) EventOp() string {
+	return e.Op
+}
+
+// ApplyTo sets the changed property on the settings.
+func (e *UserSettingsEvent) ApplyTo(settings *UserSettings) error {
+	return applyProperty(settings, e.Property, e.Value)
+}
+
+// applyProperty sets a single JSON property on target, which must be a pointer
+// to a struct. Unknown properties are ignored.
+func applyProperty(target any, property string, value any) error {
+	if property == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(map[string]any{property: value})
+	if err != nil {
+		return fmt.Errorf("marshaling property %s: %w", property, err)
+	}
+
+	return json.Unmarshal(data, target)
+}
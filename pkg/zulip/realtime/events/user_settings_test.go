@@ -0,0 +1,39 @@
+package events_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip/realtime/events"
+)
+
+func TestUserSettingsEvent(t *testing.T) {
+	eventExample := `{
+    "type": "user_settings",
+    "op": "update",
+    "property": "default_language",
+    "value": "de",
+    "language_name": "Deutsch",
+    "id": 0
+}`
+
+	v := events.UserSettingsEvent{}
+	err := json.Unmarshal([]byte(eventExample), &v)
+	require.NoError(t, err)
+
+	assert.Equal(t, events.UserSettingsType, v.EventType())
+	assert.Equal(t, "update", v.EventOp())
+
+	settings := events.UserSettings{DefaultLanguage: "en", EnterSends: true}
+	require.NoError(t, v.ApplyTo(&settings))
+
+	assert.Equal(t, "de", settings.DefaultLanguage)
+	assert.True(t, settings.EnterSends)
+
+	// Unknown properties are ignored
+	v.Property = "some_new_setting"
+	require.NoError(t, v.ApplyTo(&settings))
+}
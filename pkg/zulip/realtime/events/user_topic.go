@@ -1,5 +1,9 @@
 package events
 
+import (
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+)
+
 const UserTopicType EventType = "user_topic"
 
 type UserTopic struct {
@@ -9,10 +13,10 @@ type UserTopic struct {
 }
 
 type UserTopicData struct {
-	StreamID         int    `json:"stream_id"`
-	TopicName        string `json:"topic_name"`
-	LastUpdated      int    `json:"last_updated"`
-	VisibilityPolicy string `json:"visibility_policy"`
+	StreamID         int                    `json:"stream_id"`
+	TopicName        string                 `json:"topic_name"`
+	LastUpdated      int                    `json:"last_updated"`
+	VisibilityPolicy zulip.VisibilityPolicy `json:"visibility_policy"`
 }
 
 func (e *UserTopic) EventID() int {
@@ -0,0 +1,32 @@
+package events_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/realtime/events"
+)
+
+func TestUserTopic(t *testing.T) {
+	eventExample := `{
+    "type": "user_topic",
+    "stream_id": 1,
+    "topic_name": "foo",
+    "last_updated": 1594825442,
+    "visibility_policy": 1,
+    "id": 0
+}`
+
+	v := events.UserTopic{}
+	err := json.Unmarshal([]byte(eventExample), &v)
+	require.NoError(t, err)
+
+	assert.Equal(t, events.UserTopicType, v.EventType())
+	assert.Equal(t, 1, v.StreamID)
+	assert.Equal(t, "foo", v.TopicName)
+	assert.Equal(t, zulip.VisibilityPolicyMuted, v.VisibilityPolicy)
+}
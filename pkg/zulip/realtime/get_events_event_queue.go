@@ -71,12 +71,24 @@ func (g *GetEventsEventQueueResponse) UnmarshalJSON(data []byte) error {
 			ev = &events.UpdateMessage{}
 		case events.DeleteMessageType:
 			ev = &events.DeleteMessage{}
+		case events.UpdateMessageFlagsType:
+			ev = &events.UpdateMessageFlags{}
+		case events.ScheduledMessagesType:
+			ev = &events.ScheduledMessages{}
 		case events.UserTopicType:
 			ev = &events.UserTopic{}
 		case events.UserStatusType:
 			ev = &events.UserStatus{}
 		case events.ReactionType:
 			ev = &events.Reaction{}
+		case events.SubscriptionType:
+			ev = &events.Subscription{}
+		case events.StreamType:
+			ev = &events.Stream{}
+		case events.CustomProfileFieldsType:
+			ev = &events.CustomProfileFields{}
+		case events.UserSettingsType:
+			ev = &events.UserSettingsEvent{}
 		default:
 			ev = &events.Unknown{}
 		}
@@ -7,8 +7,10 @@ import (
 	"net/http"
 
 	"go.mau.fi/mautrix-zulip/pkg/zulip"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/channels"
 	"go.mau.fi/mautrix-zulip/pkg/zulip/narrow"
 	"go.mau.fi/mautrix-zulip/pkg/zulip/realtime/events"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/scheduledmessages"
 )
 
 type RegisterEventQueueResponse struct {
@@ -23,6 +25,31 @@ type registerEventQueueData struct {
 	LastEventID       int    `json:"last_event_id"`
 	QueueID           string `json:"queue_id"`
 	ZulipVersion      string `json:"zulip_version"`
+
+	// MaxFileUploadSizeMiB is only present if realm is in fetch_event_types.
+	MaxFileUploadSizeMiB int `json:"max_file_upload_size_mib"`
+
+	// The initial state of the realm, each field is only present if the
+	// corresponding event type is in fetch_event_types.
+	RealmUsers          []events.Person                      `json:"realm_users"`            // realm_user
+	RealmNonActiveUsers []events.Person                      `json:"realm_non_active_users"` // realm_user
+	CrossRealmBots      []events.Person                      `json:"cross_realm_bots"`       // realm_user
+	Subscriptions       []channels.SubscribedChannel         `json:"subscriptions"`          // subscription
+	RealmEmoji          map[string]events.RealmEmojiItem     `json:"realm_emoji"`            // realm_emoji
+	CustomProfileFields []events.CustomProfileField          `json:"custom_profile_fields"`  // custom_profile_fields
+	UserSettings        *events.UserSettings                 `json:"user_settings"`          // user_settings
+	UserTopics          []events.UserTopicData               `json:"user_topics"`            // user_topic
+	ScheduledMessages   []scheduledmessages.ScheduledMessage `json:"scheduled_messages"`     // scheduled_messages
+	AlertWords          []string                             `json:"alert_words"`            // alert_words
+}
+
+// ServerFeatures returns the version information of the server for feature level checks.
+func (r *RegisterEventQueueResponse) ServerFeatures() *zulip.ServerFeatures {
+	return &zulip.ServerFeatures{
+		ZulipVersion:      r.ZulipVersion,
+		ZulipFeatureLevel: r.ZulipFeatureLevel,
+		ZulipMergeBase:    r.ZulipMergeBase,
+	}
 }
 
 func (r *RegisterEventQueueResponse) UnmarshalJSON(b []byte) error {
@@ -53,72 +80,88 @@ const (
 	SimplifiedPresenceEvents   ClientCapability = "simplified_presence_events"
 )
 
+// clientCapabilityFeatureLevels is the feature level each client capability
+// was added in. Capabilities the server doesn't know about aren't sent.
+var clientCapabilityFeatureLevels = map[ClientCapability]int{
+	UserAvatarURLFieldOptional: zulip.FeatureLevelUserAvatarURLFieldOptional,
+	StreamTypingNotifications:  zulip.FeatureLevelStreamTypingNotifications,
+	UserSettingsObject:         zulip.FeatureLevelUserSettingsObject,
+	LinkifierURLTemplate:       zulip.FeatureLevelLinkifierURLTemplate,
+	UserListIncomplete:         zulip.FeatureLevelUserListIncomplete,
+	IncludeDeactivatedGroups:   zulip.FeatureLevelIncludeDeactivatedGroups,
+	ArchivedChannels:           zulip.FeatureLevelArchivedChannels,
+	EmptyTopicName:             zulip.FeatureLevelEmptyTopicName,
+	SimplifiedPresenceEvents:   zulip.FeatureLevelSimplifiedPresenceEvents,
+}
+
 type registerEventQueueOptions struct {
-	applyMarkdown            bool
-	clientGravatar           *bool
-	includeSubscribers       bool
-	slimPresence             bool
-	presenceHistoryLimitDays *int
-	eventTypes               []events.EventType
-	allPublicStreams         bool
-	clientCapabilities       map[ClientCapability]bool
-	fetchEventTypes          []events.EventType
-	narrow                   narrow.Filter
+	ApplyMarkdown            bool                      `param:"apply_markdown,omitempty"`
+	ClientGravatar           *bool                     `param:"client_gravatar"`
+	IncludeSubscribers       bool                      `param:"include_subscribers,omitempty"`
+	SlimPresence             bool                      `param:"slim_presence,omitempty"`
+	PresenceHistoryLimitDays *int                      `param:"presence_history_limit_days"`
+	EventTypes               []events.EventType        `param:"event_types,omitempty"`
+	AllPublicStreams         bool                      `param:"all_public_streams,omitempty"`
+	ClientCapabilities       map[ClientCapability]bool `param:"client_capabilities,omitempty"`
+	FetchEventTypes          []events.EventType        `param:"fetch_event_types,omitempty"`
+
+	// narrow uses the legacy [operator, operand] format, see narrow.Filter.MarshalEvent.
+	narrow narrow.Filter
 }
 
 type RegisterEventQueueOption func(*registerEventQueueOptions)
 
 func ApplyMarkdown(apply bool) RegisterEventQueueOption {
 	return func(ro *registerEventQueueOptions) {
-		ro.applyMarkdown = apply
+		ro.ApplyMarkdown = apply
 	}
 }
 
 func ClientGravatarEvent(clientGravatar bool) RegisterEventQueueOption {
 	return func(ro *registerEventQueueOptions) {
-		ro.clientGravatar = &clientGravatar
+		ro.ClientGravatar = &clientGravatar
 	}
 }
 
 func IncludeSubscribers(includeSubscribers bool) RegisterEventQueueOption {
 	return func(ro *registerEventQueueOptions) {
-		ro.includeSubscribers = includeSubscribers
+		ro.IncludeSubscribers = includeSubscribers
 	}
 }
 
 func SlimPresence(slimPresence bool) RegisterEventQueueOption {
 	return func(ro *registerEventQueueOptions) {
-		ro.slimPresence = slimPresence
+		ro.SlimPresence = slimPresence
 	}
 }
 
 func PresenceHistoryLimitDays(presenceHistoryLimitDays int) RegisterEventQueueOption {
 	return func(ro *registerEventQueueOptions) {
-		ro.presenceHistoryLimitDays = &presenceHistoryLimitDays
+		ro.PresenceHistoryLimitDays = &presenceHistoryLimitDays
 	}
 }
 
 func EventTypes(eventTypes ...events.EventType) RegisterEventQueueOption {
 	return func(ro *registerEventQueueOptions) {
-		ro.eventTypes = eventTypes
+		ro.EventTypes = eventTypes
 	}
 }
 
 func AllPublicStreams(allPublicStreams bool) RegisterEventQueueOption {
 	return func(ro *registerEventQueueOptions) {
-		ro.allPublicStreams = allPublicStreams
+		ro.AllPublicStreams = allPublicStreams
 	}
 }
 
 func ClientCapabilities(clientCapabilities map[ClientCapability]bool) RegisterEventQueueOption {
 	return func(ro *registerEventQueueOptions) {
-		ro.clientCapabilities = clientCapabilities
+		ro.ClientCapabilities = clientCapabilities
 	}
 }
 
 func FetchEventTypes(fetchEventTypes []events.EventType) RegisterEventQueueOption {
 	return func(ro *registerEventQueueOptions) {
-		ro.fetchEventTypes = fetchEventTypes
+		ro.FetchEventTypes = fetchEventTypes
 	}
 }
 
@@ -134,75 +177,25 @@ func (svc *Service) RegisterEventQueue(ctx context.Context, options ...RegisterE
 		path   = "/api/v1/register"
 	)
 
-	// default options
-	opts := registerEventQueueOptions{
-		applyMarkdown:            false,
-		clientGravatar:           nil,
-		includeSubscribers:       false,
-		slimPresence:             false,
-		presenceHistoryLimitDays: nil,
-		eventTypes:               []events.EventType{},
-		allPublicStreams:         false,
-		clientCapabilities:       map[ClientCapability]bool{},
-		fetchEventTypes:          []events.EventType{},
-		narrow:                   narrow.NewFilter(),
-	}
-
+	opts := registerEventQueueOptions{}
 	for _, opt := range options {
 		opt(&opts)
 	}
 
-	msg := map[string]any{}
-
-	if opts.applyMarkdown {
-		msg["apply_markdown"] = opts.applyMarkdown
-	}
-
-	if opts.clientGravatar != nil {
-		msg["client_gravatar"] = *opts.clientGravatar
-	}
-
-	if opts.includeSubscribers {
-		msg["include_subscribers"] = opts.includeSubscribers
-	}
-
-	if opts.slimPresence {
-		msg["slim_presence"] = opts.slimPresence
-	}
-
-	if opts.presenceHistoryLimitDays != nil {
-		msg["presence_history_limit_days"] = opts.presenceHistoryLimitDays
-	}
-
-	if len(opts.eventTypes) > 0 {
-		eventTypes, err := json.Marshal(opts.eventTypes)
-		if err != nil {
-			return nil, fmt.Errorf("marshaling event types: %w", err)
-		}
-
-		msg["event_types"] = string(eventTypes)
-	}
-
-	if opts.allPublicStreams {
-		msg["all_public_streams"] = opts.allPublicStreams
-	}
-
-	if len(opts.clientCapabilities) > 0 {
-		clientCapabilities, err := json.Marshal(opts.clientCapabilities)
-		if err != nil {
-			return nil, fmt.Errorf("marshaling client capabilities: %w", err)
+	capabilities := make(map[ClientCapability]bool, len(opts.ClientCapabilities))
+	for capability, enabled := range opts.ClientCapabilities {
+		if level, ok := clientCapabilityFeatureLevels[capability]; ok && !zulip.SupportsFeature(svc.client, level) {
+			continue
 		}
 
-		msg["client_capabilities"] = string(clientCapabilities)
+		capabilities[capability] = enabled
 	}
 
-	if len(opts.fetchEventTypes) > 0 {
-		fetchEventTypes, err := json.Marshal(opts.fetchEventTypes)
-		if err != nil {
-			return nil, fmt.Errorf("marshaling fetch event types: %w", err)
-		}
+	opts.ClientCapabilities = capabilities
 
-		msg["fetch_event_types"] = string(fetchEventTypes)
+	msg, err := zulip.EncodeParams(&opts)
+	if err != nil {
+		return nil, err
 	}
 
 	if len(opts.narrow) > 0 {
@@ -214,10 +207,5 @@ func (svc *Service) RegisterEventQueue(ctx context.Context, options ...RegisterE
 		msg["narrow"] = string(narrowJSON)
 	}
 
-	resp := RegisterEventQueueResponse{}
-	if err := svc.client.DoRequest(ctx, method, path, msg, &resp); err != nil {
-		return nil, err
-	}
-
-	return &resp, nil
+	return zulip.Do[RegisterEventQueueResponse](ctx, svc.client, method, path, msg)
 }
@@ -1,7 +1,6 @@
 package zulip
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -9,12 +8,11 @@ import (
 	"io"
 	"log/slog"
 	"mime"
-	"mime/multipart"
 	"net/http"
-	"net/textproto"
 	"net/url"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -33,17 +31,24 @@ type Client struct {
 	userAPIKey string
 	httpClient *http.Client
 	logger     *slog.Logger
+
+	rateLimiter *rateLimiter
+	features    atomic.Pointer[ServerFeatures]
 }
 
 const (
-	RESTClientDefaultTimeout  = 5 * time.Second
-	RESTClientLongPollTimeout = 10 * time.Minute
+	RESTClientDefaultTimeout    = 5 * time.Second
+	RESTClientLongPollTimeout   = 10 * time.Minute
+	RESTClientFileUploadTimeout = 30 * time.Minute
 )
 
 type clientOptions struct {
 	httpClient *http.Client
 	userAgent  string
 	logger     *slog.Logger
+	rateLimit  *RateLimitConfig
+
+	serverFeatures *ServerFeatures
 }
 
 type ClientOption func(*clientOptions) error
@@ -96,18 +101,28 @@ func NewClient(credentials CredentialsProvider, options ...ClientOption) (*Clien
 		}
 	}
 
-	return &Client{
+	client := &Client{
 		baseURL:    creds.Site,
 		userEmail:  creds.Email,
 		userAPIKey: creds.APIKey,
 		userAgent:  opts.userAgent,
 		httpClient: opts.httpClient,
 		logger:     opts.logger,
-	}, nil
+	}
+	if opts.rateLimit != nil {
+		client.rateLimiter = newRateLimiter(*opts.rateLimit)
+	}
+
+	client.features.Store(opts.serverFeatures)
+
+	return client, nil
 }
 
 type clientSendRequestOptions struct {
-	timeout time.Duration
+	timeout        time.Duration
+	uploadSize     int64
+	maxUploadSize  int64
+	uploadProgress UploadProgressFunc
 }
 
 type DoRequestOption func(*clientSendRequestOptions)
@@ -118,6 +133,33 @@ func WithTimeout(duration time.Duration) DoRequestOption {
 	}
 }
 
+// UploadProgressFunc is called periodically during file uploads with the
+// number of file bytes sent so far. total is -1 if the size is unknown.
+type UploadProgressFunc func(sent, total int64)
+
+// WithUploadProgress sets a callback for tracking the progress of file uploads.
+func WithUploadProgress(fn UploadProgressFunc) DoRequestOption {
+	return func(o *clientSendRequestOptions) {
+		o.uploadProgress = fn
+	}
+}
+
+// WithUploadSize sets the size of the uploaded file for readers whose size
+// can't be determined automatically. Only used by DoFileRequest.
+func WithUploadSize(size int64) DoRequestOption {
+	return func(o *clientSendRequestOptions) {
+		o.uploadSize = size
+	}
+}
+
+// WithMaxUploadSize makes DoFileRequest fail with ErrFileTooLarge instead of
+// sending files larger than the given number of bytes.
+func WithMaxUploadSize(size int64) DoRequestOption {
+	return func(o *clientSendRequestOptions) {
+		o.maxUploadSize = size
+	}
+}
+
 // DoRequest is the main function to send requests to Zulip's API.
 func (c *Client) DoRequest(ctx context.Context, method, path string, data map[string]any, response APIResponse, opts ...DoRequestOption) error {
 	options := clientSendRequestOptions{
@@ -129,18 +171,58 @@ func (c *Client) DoRequest(ctx context.Context, method, path string, data map[st
 
 	formData := url.Values{}
 	for k, v := range data {
-		formData.Set(k, fmt.Sprintf("%v", v))
+		encoded, err := EncodeParamValue(v)
+		if err != nil {
+			return fmt.Errorf("encoding parameter %s: %w", k, err)
+		}
+
+		formData.Set(k, encoded)
 	}
 
 	formDataEncoded := formData.Encode()
 
-	var body io.Reader
-	if method != http.MethodGet {
-		body = strings.NewReader(formDataEncoded)
+	fullURLPath := c.baseURL + path
+	if method == http.MethodGet && len(data) > 0 {
+		fullURLPath += "?" + formDataEncoded
 	}
 
-	fullURLPath := c.baseURL + path
+	bucket := rateLimitBucketKey(method, path)
+	for attempt := 0; ; attempt++ {
+		if err := c.rateLimiter.wait(ctx, bucket); err != nil {
+			return fmt.Errorf("waiting for rate limit: %w", err)
+		}
+
+		var body io.Reader
+		if method != http.MethodGet {
+			body = strings.NewReader(formDataEncoded)
+		}
+
+		statusCode, headers, err := c.sendRequest(ctx, method, fullURLPath, formDataEncoded, body, response, options)
+		if statusCode == http.StatusTooManyRequests && c.rateLimiter != nil {
+			retryAfter := parseRetryAfter(response, headers)
+			c.rateLimiter.update(bucket, statusCode, headers, retryAfter)
+			if c.rateLimiter.shouldRetry(attempt, retryAfter) {
+				c.logger.WarnContext(ctx, "Rate limit hit, retrying request",
+					slog.String("method", method),
+					slog.String("path", path),
+					slog.Duration("retry_after", retryAfter),
+					slog.Int("attempt", attempt+1))
+
+				continue
+			}
+		} else if headers != nil {
+			c.rateLimiter.update(bucket, statusCode, headers, 0)
+		}
+
+		return err
+	}
+}
 
+// sendRequest sends a single form-encoded request and decodes the response.
+// The returned status code and headers are zero if no response was received.
+func (c *Client) sendRequest(
+	ctx context.Context, method, fullURLPath, formDataEncoded string, body io.Reader, response APIResponse, options clientSendRequestOptions,
+) (int, http.Header, error) {
 	requestID := uuid.New().String()
 	reqLog := c.logger.With(slog.String("request_id", requestID))
 
@@ -149,16 +231,12 @@ func (c *Client) DoRequest(ctx context.Context, method, path string, data map[st
 		slog.String("url", fullURLPath),
 		slog.String("data", formDataEncoded))
 
-	if method == http.MethodGet && len(data) > 0 {
-		fullURLPath += "?" + formDataEncoded
-	}
-
 	reqCtx, reqCancel := context.WithTimeout(ctx, options.timeout)
 	defer reqCancel()
 
 	req, err := http.NewRequestWithContext(reqCtx, method, fullURLPath, body)
 	if err != nil {
-		return fmt.Errorf("creating send request: %w", err)
+		return 0, nil, fmt.Errorf("creating send request: %w", err)
 	}
 
 	req.Header.Set("User-Agent", c.userAgent)
@@ -168,13 +246,13 @@ func (c *Client) DoRequest(ctx context.Context, method, path string, data map[st
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("send request: %w", err)
+		return 0, nil, fmt.Errorf("send request: %w", err)
 	}
 
 	defer func() { _ = resp.Body.Close() }()
 
 	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return fmt.Errorf("cannot read response body: %s", err)
+		return resp.StatusCode, resp.Header, fmt.Errorf("cannot read response body: %s", err)
 	}
 
 	headersGroup := []slog.Attr{}
@@ -192,49 +270,47 @@ func (c *Client) DoRequest(ctx context.Context, method, path string, data map[st
 	response.SetHTTPHeaders(resp.Header)
 
 	if response.IsError() {
-		return ErrorResp{Inner: response}
+		return resp.StatusCode, resp.Header, ErrorResp{Inner: response}
 	}
 
-	return nil
+	return resp.StatusCode, resp.Header, nil
 }
 
 // DoFileRequest is the main function to send requests to Zulip's API with a file. For file and emoji uploads.
+//
+// The file is streamed to the server rather than buffered in memory. If the
+// size of the file can be determined (see WithUploadSize), the request is
+// sent with a Content-Length header, otherwise chunked encoding is used.
 func (c *Client) DoFileRequest(ctx context.Context, method, path string, fileName string, file io.Reader, response APIResponse, opts ...DoRequestOption) error {
 	options := clientSendRequestOptions{
-		timeout: RESTClientDefaultTimeout,
+		timeout:    RESTClientFileUploadTimeout,
+		uploadSize: -1,
 	}
 	for _, opt := range opts {
 		opt(&options)
 	}
 
-	var requestBody bytes.Buffer
-
-	writer := multipart.NewWriter(&requestBody)
-	h := make(textproto.MIMEHeader)
-	h.Set("Content-Disposition",
-		fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
-			"filename",
-			filepath.Base(fileName)))
+	fileSize := options.uploadSize
+	if fileSize < 0 {
+		fileSize = readerSize(file)
+	}
+	if options.maxUploadSize > 0 && fileSize > options.maxUploadSize {
+		return fmt.Errorf("%w: %d bytes exceeds the limit of %d bytes", ErrFileTooLarge, fileSize, options.maxUploadSize)
+	}
 
 	mimeType := mime.TypeByExtension(filepath.Ext(fileName))
 	if mimeType == "" {
 		mimeType = "application/octet-stream"
 	}
 
-	h.Set("Content-Type", mimeType)
-
-	part, err := writer.CreatePart(h)
+	body, err := newMultipartFileBody(fileName, mimeType, file, fileSize, options)
 	if err != nil {
-		return fmt.Errorf("cannot create writer from file: %v", err)
+		return err
 	}
 
-	_, err = io.Copy(part, file)
-	if err != nil {
-		return fmt.Errorf("copying file content: %v", err)
-	}
-
-	if err := writer.Close(); err != nil {
-		return fmt.Errorf("closing writer: %v", err)
+	bucket := rateLimitBucketKey(method, path)
+	if err := c.rateLimiter.wait(ctx, bucket); err != nil {
+		return fmt.Errorf("waiting for rate limit: %w", err)
 	}
 
 	reqCtx, reqCancel := context.WithTimeout(ctx, options.timeout)
@@ -250,15 +326,26 @@ func (c *Client) DoFileRequest(ctx context.Context, method, path string, fileNam
 		slog.String("url", fullURLPath),
 		slog.String("filename", fileName),
 		slog.String("mimetype", mimeType),
-		slog.Int("content_length", requestBody.Len()))
+		slog.Int64("content_length", body.contentLength))
+
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		_ = pipeWriter.CloseWithError(body.writeTo(pipeWriter))
+	}()
+	// Closing the reader unblocks the writer goroutine if the request fails before the body is fully sent.
+	defer func() { _ = pipeReader.Close() }()
 
-	req, err := http.NewRequestWithContext(reqCtx, method, fullURLPath, &requestBody)
+	req, err := http.NewRequestWithContext(reqCtx, method, fullURLPath, pipeReader)
 	if err != nil {
 		return fmt.Errorf("creating send request: %w", err)
 	}
 
+	if body.contentLength >= 0 {
+		req.ContentLength = body.contentLength
+	}
+
 	req.Header.Set("User-Agent", c.userAgent)
-	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Content-Type", body.contentType)
 	req.Header.Add("Accept", "application/json")
 	req.SetBasicAuth(c.userEmail, c.userAPIKey)
 
@@ -286,5 +373,15 @@ func (c *Client) DoFileRequest(ctx context.Context, method, path string, fileNam
 	response.SetHTTPCode(resp.StatusCode)
 	response.SetHTTPHeaders(resp.Header)
 
+	var retryAfter time.Duration
+	if resp.StatusCode == http.StatusTooManyRequests {
+		retryAfter = parseRetryAfter(response, resp.Header)
+	}
+	c.rateLimiter.update(bucket, resp.StatusCode, resp.Header, retryAfter)
+
+	if response.IsError() {
+		return ErrorResp{Inner: response}
+	}
+
 	return nil
 }
@@ -171,3 +171,125 @@ func TestRestClientDoRequestFile(t *testing.T) {
 	assert.Equal(t, expectedHeaders.Get("Authorization"), requestRecorder.headers.Get("Authorization"))
 	assert.Equal(t, expectedHeaders.Get("Accept-Encoding"), requestRecorder.headers.Get("Accept-Encoding"))
 }
+
+func TestRestClientDoRequestFileStreaming(t *testing.T) {
+	fileContent := bytes.Repeat([]byte("0123456789"), 100_000)
+
+	cases := []struct {
+		name          string
+		reader        io.Reader
+		opts          []zulip.DoRequestOption
+		knownLength   bool
+		expectedTotal int64
+	}{
+		{
+			name:          "Known size",
+			reader:        bytes.NewReader(fileContent),
+			knownLength:   true,
+			expectedTotal: int64(len(fileContent)),
+		},
+		{
+			name:          "Unknown size",
+			reader:        io.MultiReader(bytes.NewReader(fileContent)),
+			knownLength:   false,
+			expectedTotal: -1,
+		},
+		{
+			name:          "Size set explicitly",
+			reader:        io.MultiReader(bytes.NewReader(fileContent)),
+			opts:          []zulip.DoRequestOption{zulip.WithUploadSize(int64(len(fileContent)))},
+			knownLength:   true,
+			expectedTotal: int64(len(fileContent)),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var (
+				receivedLength  int64
+				receivedContent []byte
+			)
+
+			mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				receivedLength = r.ContentLength
+
+				file, _, err := r.FormFile("filename")
+				if assert.NoError(t, err) {
+					receivedContent, _ = io.ReadAll(file)
+				}
+
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(`{"result": "success"}`))
+			}))
+			defer mockServer.Close()
+
+			client, err := zulip.NewClient(zulip.Credentials(mockServer.URL, "email@test", "apikey"))
+			require.NoError(t, err)
+
+			var lastSent, lastTotal int64
+
+			opts := append(c.opts, zulip.WithUploadProgress(func(sent, total int64) {
+				lastSent = sent
+				lastTotal = total
+			}))
+
+			var resp zulip.APIResponseBase
+
+			err = client.DoFileRequest(context.TODO(), http.MethodPost, "/api/v1/user_uploads", "file.txt", c.reader, &resp, opts...)
+			require.NoError(t, err)
+
+			assert.Equal(t, fileContent, receivedContent)
+			assert.Equal(t, c.knownLength, receivedLength > 0)
+			assert.Equal(t, int64(len(fileContent)), lastSent)
+			assert.Equal(t, c.expectedTotal, lastTotal)
+		})
+	}
+}
+
+func TestRestClientDoRequestFileTooLarge(t *testing.T) {
+	var calls int
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"result": "success"}`))
+	}))
+	defer mockServer.Close()
+
+	client, err := zulip.NewClient(zulip.Credentials(mockServer.URL, "email@test", "apikey"))
+	require.NoError(t, err)
+
+	var resp zulip.APIResponseBase
+
+	// Known size is rejected before sending anything.
+	err = client.DoFileRequest(context.TODO(), http.MethodPost, "/api/v1/user_uploads", "file.txt",
+		bytes.NewReader(make([]byte, 2048)), &resp, zulip.WithMaxUploadSize(1024))
+	require.ErrorIs(t, err, zulip.ErrFileTooLarge)
+	assert.Equal(t, 0, calls)
+
+	// Unknown size is aborted while streaming.
+	err = client.DoFileRequest(context.TODO(), http.MethodPost, "/api/v1/user_uploads", "file.txt",
+		io.MultiReader(bytes.NewReader(make([]byte, 2048))), &resp, zulip.WithMaxUploadSize(1024))
+	require.ErrorIs(t, err, zulip.ErrFileTooLarge)
+}
+
+func TestRestClientDoRequestFileError(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code": "BAD_REQUEST", "msg": "File is larger than this server's configured maximum upload size (25 MiB).", "result": "error"}`))
+	}))
+	defer mockServer.Close()
+
+	client, err := zulip.NewClient(zulip.Credentials(mockServer.URL, "email@test", "apikey"))
+	require.NoError(t, err)
+
+	var resp zulip.APIResponseBase
+
+	err = client.DoFileRequest(context.TODO(), http.MethodPost, "/api/v1/user_uploads", "file.txt",
+		bytes.NewReader([]byte("file content")), &resp)
+	require.Error(t, err)
+	assert.True(t, zulip.IsCode(err, "BAD_REQUEST"))
+	assert.Equal(t, http.StatusBadRequest, resp.HTTPCode())
+}
@@ -0,0 +1,97 @@
+package scheduledmessages
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/messages/recipient"
+)
+
+type CreateScheduledMessageResponse struct {
+	zulip.APIResponseBase
+	createScheduledMessageData
+}
+
+type createScheduledMessageData struct {
+	ScheduledMessageID int `json:"scheduled_message_id"`
+}
+
+func (c *CreateScheduledMessageResponse) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &c.APIResponseBase); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(b, &c.createScheduledMessageData); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type createScheduledMessageOptions struct {
+	Type                       string  `param:"type"`
+	To                         any     `param:"to"`
+	Content                    string  `param:"content"`
+	Topic                      *string `param:"topic"`
+	ScheduledDeliveryTimestamp int64   `param:"scheduled_delivery_timestamp"`
+	ReadBySender               *bool   `param:"read_by_sender"`
+}
+
+type CreateScheduledMessageOption func(*createScheduledMessageOptions) error
+
+// ToTopic sets the topic of a scheduled channel message. Servers that support
+// empty topics send messages without a topic to the "general chat" topic.
+func ToTopic(name string) CreateScheduledMessageOption {
+	return func(o *createScheduledMessageOptions) error {
+		if strings.TrimSpace(name) == "" {
+			return errors.New("topic 'name' is empty")
+		}
+
+		o.Topic = &name
+
+		return nil
+	}
+}
+
+// ReadBySender sets whether the message is marked as read by the sender when it's sent.
+func ReadBySender(asRead bool) CreateScheduledMessageOption {
+	return func(o *createScheduledMessageOptions) error {
+		o.ReadBySender = &asRead
+
+		return nil
+	}
+}
+
+// CreateScheduledMessage schedules a message to be sent at the given time.
+// Channels must be given by ID.
+func (svc *Service) CreateScheduledMessage(ctx context.Context, to recipient.Recipient, content string, deliverAt time.Time, options ...CreateScheduledMessageOption) (*CreateScheduledMessageResponse, error) {
+	const (
+		method = http.MethodPost
+		path   = "/api/v1/scheduled_messages"
+	)
+
+	recipientType, err := svc.recipientType(to)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := createScheduledMessageOptions{
+		Type:                       recipientType,
+		To:                         to.To(),
+		Content:                    content,
+		ScheduledDeliveryTimestamp: deliverAt.Unix(),
+	}
+	for _, opt := range options {
+		if err := opt(&opts); err != nil {
+			return nil, fmt.Errorf("applying option: %w", err)
+		}
+	}
+
+	return zulip.Do[CreateScheduledMessageResponse](ctx, svc.client, method, path, &opts)
+}
@@ -0,0 +1,86 @@
+package scheduledmessages_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/messages/recipient"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/scheduledmessages"
+)
+
+func TestCreateScheduledMessage(t *testing.T) {
+	const response = `{
+    "msg": "",
+    "result": "success",
+    "scheduled_message_id": 42
+}`
+	deliverAt := time.Unix(1681662420, 0)
+
+	t.Run("channel", func(t *testing.T) {
+		client := createMockClient(response)
+		svc := scheduledmessages.NewService(client)
+
+		resp, err := svc.CreateScheduledMessage(
+			context.Background(), recipient.ToChannel(14), "Hi", deliverAt,
+			scheduledmessages.ToTopic("Introduction"),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, 42, resp.ScheduledMessageID)
+
+		assert.Equal(t, http.MethodPost, client.(*mockClient).method)
+		assert.Equal(t, "/api/v1/scheduled_messages", client.(*mockClient).path)
+		assert.Equal(t, map[string]any{
+			"type":                         "channel",
+			"to":                           recipient.ToChannel(14).To(),
+			"content":                      "Hi",
+			"topic":                        "Introduction",
+			"scheduled_delivery_timestamp": int64(1681662420),
+		}, client.(*mockClient).paramsSent)
+	})
+
+	t.Run("direct on old server", func(t *testing.T) {
+		client := &mockClient{
+			response: response,
+			features: &zulip.ServerFeatures{ZulipFeatureLevel: zulip.FeatureLevelScheduledMessages},
+		}
+		svc := scheduledmessages.NewService(client)
+
+		_, err := svc.CreateScheduledMessage(
+			context.Background(), recipient.ToUsers([]int{8, 9}), "Lunch?", deliverAt,
+			scheduledmessages.ReadBySender(true),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"type":                         "direct",
+			"to":                           "[8,9]",
+			"content":                      "Lunch?",
+			"scheduled_delivery_timestamp": int64(1681662420),
+			"read_by_sender":               true,
+		}, client.paramsSent)
+	})
+
+	t.Run("channel on old server", func(t *testing.T) {
+		client := &mockClient{
+			response: response,
+			features: &zulip.ServerFeatures{ZulipFeatureLevel: zulip.FeatureLevelScheduledMessages},
+		}
+		svc := scheduledmessages.NewService(client)
+
+		_, err := svc.CreateScheduledMessage(context.Background(), recipient.ToChannel(14), "Hi", deliverAt)
+		require.NoError(t, err)
+		assert.Equal(t, "stream", client.paramsSent["type"])
+	})
+
+	t.Run("empty topic", func(t *testing.T) {
+		svc := scheduledmessages.NewService(createMockClient(response))
+
+		_, err := svc.CreateScheduledMessage(context.Background(), recipient.ToChannel(14), "Hi", deliverAt, scheduledmessages.ToTopic(" "))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,25 @@
+package scheduledmessages
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+)
+
+type DeleteScheduledMessageResponse struct {
+	zulip.APIResponseBase
+}
+
+// DeleteScheduledMessage cancels a scheduled message.
+func (svc *Service) DeleteScheduledMessage(ctx context.Context, id int) (*DeleteScheduledMessageResponse, error) {
+	const (
+		method = http.MethodDelete
+		path   = "/api/v1/scheduled_messages"
+	)
+
+	deletePath := fmt.Sprintf("%s/%d", path, id)
+
+	return zulip.Do[DeleteScheduledMessageResponse](ctx, svc.client, method, deletePath, nil)
+}
@@ -0,0 +1,28 @@
+package scheduledmessages_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip/scheduledmessages"
+)
+
+func TestDeleteScheduledMessage(t *testing.T) {
+	client := createMockClient(`{
+    "msg": "",
+    "result": "success"
+}`)
+
+	svc := scheduledmessages.NewService(client)
+
+	resp, err := svc.DeleteScheduledMessage(context.Background(), 42)
+	require.NoError(t, err)
+	assert.True(t, resp.IsSuccess())
+
+	assert.Equal(t, http.MethodDelete, client.(*mockClient).method)
+	assert.Equal(t, "/api/v1/scheduled_messages/42", client.(*mockClient).path)
+}
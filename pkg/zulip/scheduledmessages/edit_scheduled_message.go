@@ -0,0 +1,91 @@
+package scheduledmessages
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/messages/recipient"
+)
+
+type EditScheduledMessageResponse struct {
+	zulip.APIResponseBase
+}
+
+type editScheduledMessageOptions struct {
+	Type                       *string `param:"type"`
+	To                         any     `param:"to"`
+	Content                    *string `param:"content"`
+	Topic                      *string `param:"topic"`
+	ScheduledDeliveryTimestamp *int64  `param:"scheduled_delivery_timestamp"`
+
+	recipient recipient.Recipient
+}
+
+type EditScheduledMessageOption func(*editScheduledMessageOptions) error
+
+// EditRecipient moves the scheduled message to another conversation.
+func EditRecipient(to recipient.Recipient) EditScheduledMessageOption {
+	return func(o *editScheduledMessageOptions) error {
+		o.recipient = to
+
+		return nil
+	}
+}
+
+func EditContent(content string) EditScheduledMessageOption {
+	return func(o *editScheduledMessageOptions) error {
+		o.Content = &content
+
+		return nil
+	}
+}
+
+func EditTopic(topic string) EditScheduledMessageOption {
+	return func(o *editScheduledMessageOptions) error {
+		o.Topic = &topic
+
+		return nil
+	}
+}
+
+// EditDeliveryTime reschedules the message. This also retries sending a
+// message that failed to send.
+func EditDeliveryTime(deliverAt time.Time) EditScheduledMessageOption {
+	return func(o *editScheduledMessageOptions) error {
+		timestamp := deliverAt.Unix()
+		o.ScheduledDeliveryTimestamp = &timestamp
+
+		return nil
+	}
+}
+
+func (svc *Service) EditScheduledMessage(ctx context.Context, id int, options ...EditScheduledMessageOption) (*EditScheduledMessageResponse, error) {
+	const (
+		method = http.MethodPatch
+		path   = "/api/v1/scheduled_messages"
+	)
+
+	patchPath := fmt.Sprintf("%s/%d", path, id)
+
+	opts := editScheduledMessageOptions{}
+	for _, opt := range options {
+		if err := opt(&opts); err != nil {
+			return nil, fmt.Errorf("applying option: %w", err)
+		}
+	}
+
+	if opts.recipient != nil {
+		recipientType, err := svc.recipientType(opts.recipient)
+		if err != nil {
+			return nil, err
+		}
+
+		opts.Type = &recipientType
+		opts.To = opts.recipient.To()
+	}
+
+	return zulip.Do[EditScheduledMessageResponse](ctx, svc.client, method, patchPath, &opts)
+}
@@ -0,0 +1,41 @@
+package scheduledmessages_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip/messages/recipient"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/scheduledmessages"
+)
+
+func TestEditScheduledMessage(t *testing.T) {
+	client := createMockClient(`{
+    "msg": "",
+    "result": "success"
+}`)
+
+	svc := scheduledmessages.NewService(client)
+
+	resp, err := svc.EditScheduledMessage(
+		context.Background(), 42,
+		scheduledmessages.EditRecipient(recipient.ToUser(8)),
+		scheduledmessages.EditContent("Lunch at noon?"),
+		scheduledmessages.EditDeliveryTime(time.Unix(1681662480, 0)),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "success", resp.Result())
+
+	assert.Equal(t, http.MethodPatch, client.(*mockClient).method)
+	assert.Equal(t, "/api/v1/scheduled_messages/42", client.(*mockClient).path)
+	assert.Equal(t, map[string]any{
+		"type":                         "direct",
+		"to":                           "[8]",
+		"content":                      "Lunch at noon?",
+		"scheduled_delivery_timestamp": int64(1681662480),
+	}, client.(*mockClient).paramsSent)
+}
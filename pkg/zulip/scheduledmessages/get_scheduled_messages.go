@@ -0,0 +1,41 @@
+package scheduledmessages
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+)
+
+type GetScheduledMessagesResponse struct {
+	zulip.APIResponseBase
+	getScheduledMessagesData
+}
+
+type getScheduledMessagesData struct {
+	ScheduledMessages []ScheduledMessage `json:"scheduled_messages"`
+}
+
+func (g *GetScheduledMessagesResponse) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &g.APIResponseBase); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(b, &g.getScheduledMessagesData); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetScheduledMessages returns the messages the user has scheduled that
+// haven't been sent yet, including ones that failed to send.
+func (svc *Service) GetScheduledMessages(ctx context.Context) (*GetScheduledMessagesResponse, error) {
+	const (
+		method = http.MethodGet
+		path   = "/api/v1/scheduled_messages"
+	)
+
+	return zulip.Do[GetScheduledMessagesResponse](ctx, svc.client, method, path, nil)
+}
@@ -0,0 +1,76 @@
+package scheduledmessages_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip/scheduledmessages"
+)
+
+func TestGetScheduledMessages(t *testing.T) {
+	client := createMockClient(`{
+    "msg": "",
+    "result": "success",
+    "scheduled_messages": [
+        {
+            "content": "Hi",
+            "failed": false,
+            "rendered_content": "<p>Hi</p>",
+            "scheduled_delivery_timestamp": 1681662420,
+            "scheduled_message_id": 27,
+            "to": 14,
+            "topic": "Introduction",
+            "type": "stream"
+        },
+        {
+            "content": "Lunch?",
+            "failed": true,
+            "rendered_content": "<p>Lunch?</p>",
+            "scheduled_delivery_timestamp": 1681662480,
+            "scheduled_message_id": 28,
+            "to": [
+                8,
+                9
+            ],
+            "type": "private"
+        }
+    ]
+}`)
+
+	svc := scheduledmessages.NewService(client)
+
+	resp, err := svc.GetScheduledMessages(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "success", resp.Result())
+
+	assert.Equal(t, http.MethodGet, client.(*mockClient).method)
+	assert.Equal(t, "/api/v1/scheduled_messages", client.(*mockClient).path)
+
+	require.Len(t, resp.ScheduledMessages, 2)
+	assert.Equal(t, 27, resp.ScheduledMessages[0].ScheduledMessageID)
+	assert.Equal(t, scheduledmessages.TypeChannel, resp.ScheduledMessages[0].Type)
+	assert.Equal(t, scheduledmessages.To{StreamID: 14}, resp.ScheduledMessages[0].To)
+	assert.Equal(t, "Introduction", resp.ScheduledMessages[0].Topic)
+	assert.Equal(t, int64(1681662420), resp.ScheduledMessages[0].ScheduledDeliveryTimestamp)
+	assert.False(t, resp.ScheduledMessages[0].Failed)
+
+	assert.Equal(t, scheduledmessages.TypeDirect, resp.ScheduledMessages[1].Type)
+	assert.Equal(t, scheduledmessages.To{UserIDs: []int{8, 9}}, resp.ScheduledMessages[1].To)
+	assert.True(t, resp.ScheduledMessages[1].Failed)
+}
+
+func TestScheduledMessageToRoundTrip(t *testing.T) {
+	for _, to := range []scheduledmessages.To{{StreamID: 14}, {UserIDs: []int{8, 9}}} {
+		data, err := json.Marshal(to)
+		require.NoError(t, err)
+
+		var decoded scheduledmessages.To
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		assert.Equal(t, to, decoded)
+	}
+}
@@ -0,0 +1,95 @@
+// Package scheduledmessages provides functionality for managing messages that
+// are sent by the server at a later time.
+//
+// Implemented features:
+//   - Get scheduled messages
+//   - Create a scheduled message
+//   - Edit a scheduled message
+//   - Delete a scheduled message
+//
+// Scheduled messages require Zulip 7.0 (feature level 179).
+//
+// See https://zulip.com/api/ for the complete API documentation.
+package scheduledmessages
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+	"go.mau.fi/mautrix-zulip/pkg/zulip/messages/recipient"
+)
+
+type Service struct {
+	client zulip.RESTClient
+}
+
+func NewService(c zulip.RESTClient) *Service {
+	return &Service{client: c}
+}
+
+// Type is the type of conversation a scheduled message is sent to, as
+// returned by the server.
+type Type string
+
+const (
+	TypeChannel Type = "stream"
+	TypeDirect  Type = "private"
+)
+
+// ScheduledMessage is a message that hasn't been sent yet.
+type ScheduledMessage struct {
+	ScheduledMessageID int    `json:"scheduled_message_id"`
+	Type               Type   `json:"type"`
+	To                 To     `json:"to"`
+	Topic              string `json:"topic"`
+	Content            string `json:"content"`
+	RenderedContent    string `json:"rendered_content"`
+	// ScheduledDeliveryTimestamp is the Unix timestamp of when the message will be sent.
+	ScheduledDeliveryTimestamp int64 `json:"scheduled_delivery_timestamp"`
+	// Failed is set if the server failed to send the message at the scheduled time.
+	Failed bool `json:"failed"`
+}
+
+// To is the recipient of a scheduled message, which is a channel ID for
+// channel messages and a list of user IDs for direct messages.
+type To struct {
+	StreamID int
+	UserIDs  []int
+}
+
+func (t *To) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &t.StreamID); err == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal(b, &t.UserIDs); err == nil {
+		return nil
+	}
+
+	return errors.New("failed to unmarshal scheduled message recipient")
+}
+
+func (t To) MarshalJSON() ([]byte, error) {
+	if t.UserIDs != nil {
+		return json.Marshal(t.UserIDs)
+	}
+
+	return json.Marshal(t.StreamID)
+}
+
+// recipientType returns the "type" parameter for sending to a recipient.
+func (svc *Service) recipientType(to recipient.Recipient) (string, error) {
+	switch to.(type) {
+	case recipient.Direct:
+		return "direct", nil
+	case recipient.Channel:
+		if !zulip.SupportsFeature(svc.client, zulip.FeatureLevelChannelMessageType) {
+			return "stream", nil
+		}
+		return "channel", nil
+	default:
+		return "", fmt.Errorf("unsupported recipient type: %T", to)
+	}
+}
@@ -0,0 +1,57 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+)
+
+type CreateUserGroupResponse struct {
+	zulip.APIResponseBase
+	createUserGroupResponseData
+}
+
+type createUserGroupResponseData struct {
+	GroupID int `json:"group_id"`
+}
+
+func (c *CreateUserGroupResponse) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &c.APIResponseBase); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(b, &c.createUserGroupResponseData); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type createUserGroupOptions struct {
+	Name        string `param:"name"`
+	Description string `param:"description"`
+	Members     []int  `param:"members"`
+}
+
+// CreateUserGroup Create a new user group. The response only includes the
+// group ID on Zulip 10.0+ (feature level 317).
+func (svc *Service) CreateUserGroup(ctx context.Context, name, description string, members []int) (*CreateUserGroupResponse, error) {
+	const (
+		path   = "/api/v1/user_groups/create"
+		method = http.MethodPost
+	)
+
+	if members == nil {
+		members = []int{}
+	}
+
+	opts := createUserGroupOptions{
+		Name:        name,
+		Description: description,
+		Members:     members,
+	}
+
+	return zulip.Do[CreateUserGroupResponse](ctx, svc.client, method, path, &opts)
+}
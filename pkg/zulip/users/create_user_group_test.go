@@ -0,0 +1,35 @@
+package users_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip/users"
+)
+
+func TestCreateUserGroup(t *testing.T) {
+	client := createMockClient(`{
+    "group_id": 11,
+    "msg": "",
+    "result": "success"
+}`)
+
+	userSvc := users.NewService(client)
+
+	resp, err := userSvc.CreateUserGroup(context.Background(), "marketing", "The marketing team", []int{1, 2})
+	require.NoError(t, err)
+	assert.Equal(t, 11, resp.GroupID)
+
+	// validate the parameters sent are correct
+	assert.Equal(t, http.MethodPost, client.(*mockClient).method)
+	assert.Equal(t, "/api/v1/user_groups/create", client.(*mockClient).path)
+	assert.Equal(t, map[string]any{
+		"name":        "marketing",
+		"description": "The marketing team",
+		"members":     "[1,2]",
+	}, client.(*mockClient).paramsSent)
+}
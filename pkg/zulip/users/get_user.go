@@ -31,29 +31,21 @@ func (g *GetUserResponse) UnmarshalJSON(b []byte) error {
 }
 
 type getUserOptions struct {
-	clientGravatar struct {
-		fieldName string
-		value     *bool
-	}
-	includeCustomProfileFields struct {
-		fieldName string
-		value     *bool
-	}
+	ClientGravatar             *bool `param:"client_gravatar"`
+	IncludeCustomProfileFields *bool `param:"include_custom_profile_fields"`
 }
 
 type GetUserOption func(*getUserOptions)
 
 func ClientGravatar(value bool) GetUserOption {
 	return func(args *getUserOptions) {
-		args.clientGravatar.fieldName = "client_gravatar"
-		args.clientGravatar.value = &value
+		args.ClientGravatar = &value
 	}
 }
 
 func IncludeCustomProfileFields(value bool) GetUserOption {
 	return func(args *getUserOptions) {
-		args.includeCustomProfileFields.fieldName = "include_custom_profile_fields"
-		args.includeCustomProfileFields.value = &value
+		args.IncludeCustomProfileFields = &value
 	}
 }
 
@@ -82,25 +74,10 @@ func (svc *Service) getUser(ctx context.Context, path string, options ...GetUser
 		method = http.MethodGet
 	)
 
-	msg := map[string]any{}
-
 	opts := getUserOptions{}
 	for _, opt := range options {
 		opt(&opts)
 	}
 
-	if opts.clientGravatar.value != nil {
-		msg[opts.clientGravatar.fieldName] = *opts.clientGravatar.value
-	}
-
-	if opts.includeCustomProfileFields.value != nil {
-		msg[opts.includeCustomProfileFields.fieldName] = *opts.includeCustomProfileFields.value
-	}
-
-	resp := GetUserResponse{}
-	if err := svc.client.DoRequest(ctx, method, path, msg, &resp); err != nil {
-		return nil, err
-	}
-
-	return &resp, nil
+	return zulip.Do[GetUserResponse](ctx, svc.client, method, path, &opts)
 }
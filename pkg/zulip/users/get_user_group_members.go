@@ -0,0 +1,62 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+)
+
+type GetUserGroupMembersResponse struct {
+	zulip.APIResponseBase
+	getUserGroupMembersResponseData
+}
+
+type getUserGroupMembersResponseData struct {
+	Members []int `json:"members"`
+}
+
+func (g *GetUserGroupMembersResponse) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &g.APIResponseBase); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(b, &g.getUserGroupMembersResponseData); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type getUserGroupMembersOptions struct {
+	DirectMemberOnly *bool `param:"direct_member_only"`
+}
+
+type GetUserGroupMembersOption func(*getUserGroupMembersOptions)
+
+// DirectMemberOnly Whether to only return direct members of the group, excluding members of subgroups.
+func DirectMemberOnly(value bool) GetUserGroupMembersOption {
+	return func(args *getUserGroupMembersOptions) {
+		args.DirectMemberOnly = &value
+	}
+}
+
+// GetUserGroupMembers Get the IDs of the members of a user group, including
+// members of subgroups unless DirectMemberOnly is set.
+func (svc *Service) GetUserGroupMembers(ctx context.Context, groupID int, options ...GetUserGroupMembersOption) (*GetUserGroupMembersResponse, error) {
+	const (
+		path   = "/api/v1/user_groups/%d/members"
+		method = http.MethodGet
+	)
+
+	pathPatch := fmt.Sprintf(path, groupID)
+
+	opts := getUserGroupMembersOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	return zulip.Do[GetUserGroupMembersResponse](ctx, svc.client, method, pathPatch, &opts)
+}
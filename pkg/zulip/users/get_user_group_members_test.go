@@ -0,0 +1,33 @@
+package users_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip/users"
+)
+
+func TestGetUserGroupMembers(t *testing.T) {
+	client := createMockClient(`{
+    "members": [10, 12],
+    "msg": "",
+    "result": "success"
+}`)
+
+	userSvc := users.NewService(client)
+
+	resp, err := userSvc.GetUserGroupMembers(context.Background(), 3)
+	require.NoError(t, err)
+	assert.Equal(t, []int{10, 12}, resp.Members)
+
+	// validate the parameters sent are correct
+	assert.Equal(t, "/api/v1/user_groups/3/members", client.(*mockClient).path)
+	assert.Empty(t, client.(*mockClient).paramsSent)
+
+	_, err = userSvc.GetUserGroupMembers(context.Background(), 3, users.DirectMemberOnly(true))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"direct_member_only": true}, client.(*mockClient).paramsSent)
+}
@@ -0,0 +1,70 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+)
+
+type GetUserGroupsResponse struct {
+	zulip.APIResponseBase
+	getUserGroupsResponseData
+}
+
+type UserGroup struct {
+	ID                int    `json:"id"`
+	Name              string `json:"name"`
+	Description       string `json:"description"`
+	Members           []int  `json:"members"`
+	DirectSubgroupIDs []int  `json:"direct_subgroup_ids"`
+	IsSystemGroup     bool   `json:"is_system_group"`
+	Deactivated       bool   `json:"deactivated"`
+	CreatorID         int    `json:"creator_id"`
+	DateCreated       int    `json:"date_created"`
+}
+
+type getUserGroupsResponseData struct {
+	UserGroups []UserGroup `json:"user_groups"`
+}
+
+func (g *GetUserGroupsResponse) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &g.APIResponseBase); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(b, &g.getUserGroupsResponseData); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type getUserGroupsOptions struct {
+	IncludeDeactivatedGroups *bool `param:"include_deactivated_groups"`
+}
+
+type GetUserGroupsOption func(*getUserGroupsOptions)
+
+func IncludeDeactivatedGroups(value bool) GetUserGroupsOption {
+	return func(args *getUserGroupsOptions) {
+		args.IncludeDeactivatedGroups = &value
+	}
+}
+
+// GetUserGroups Fetches all of the user groups in the organization.
+// Members only include direct members, see DirectSubgroupIDs for the rest.
+func (svc *Service) GetUserGroups(ctx context.Context, options ...GetUserGroupsOption) (*GetUserGroupsResponse, error) {
+	const (
+		path   = "/api/v1/user_groups"
+		method = http.MethodGet
+	)
+
+	opts := getUserGroupsOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	return zulip.Do[GetUserGroupsResponse](ctx, svc.client, method, path, &opts)
+}
@@ -0,0 +1,55 @@
+package users_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip/users"
+)
+
+func TestGetUserGroups(t *testing.T) {
+	client := createMockClient(`{
+    "msg": "",
+    "result": "success",
+    "user_groups": [
+        {
+            "description": "Backend team",
+            "id": 1,
+            "members": [1, 2],
+            "direct_subgroup_ids": [3],
+            "name": "backend",
+            "is_system_group": false,
+            "deactivated": false
+        },
+        {
+            "description": "Everyone",
+            "id": 2,
+            "members": [1, 2, 3],
+            "direct_subgroup_ids": [],
+            "name": "role:members",
+            "is_system_group": true,
+            "deactivated": false
+        }
+    ]
+}`)
+
+	userSvc := users.NewService(client)
+
+	resp, err := userSvc.GetUserGroups(context.Background(), users.IncludeDeactivatedGroups(true))
+	require.NoError(t, err)
+
+	require.Len(t, resp.UserGroups, 2)
+	assert.Equal(t, "backend", resp.UserGroups[0].Name)
+	assert.Equal(t, []int{1, 2}, resp.UserGroups[0].Members)
+	assert.Equal(t, []int{3}, resp.UserGroups[0].DirectSubgroupIDs)
+	assert.True(t, resp.UserGroups[1].IsSystemGroup)
+
+	// validate the parameters sent are correct
+	assert.Equal(t, http.MethodGet, client.(*mockClient).method)
+	assert.Equal(t, "/api/v1/user_groups", client.(*mockClient).path)
+	assert.Equal(t, map[string]any{"include_deactivated_groups": true}, client.(*mockClient).paramsSent)
+}
@@ -31,6 +31,7 @@ type getUserMeResponseData struct {
 	IsGuest        bool                        `json:"is_guest"`
 	IsOwner        bool                        `json:"is_owner"`
 	MaxMessageID   int                         `json:"max_message_id"`
+	Timezone       string                      `json:"timezone"`
 	ProfileData    map[string]struct {
 		Value         string `json:"value"`
 		RenderedValue string `json:"rendered_value,omitempty"`
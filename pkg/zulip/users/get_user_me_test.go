@@ -73,6 +73,7 @@ func TestGetUserMe(t *testing.T) {
 	assert.False(t, resp.IsGuest)
 	assert.False(t, resp.IsOwner)
 	assert.Equal(t, 30, resp.MaxMessageID)
+	assert.Empty(t, resp.Timezone)
 	assert.Equal(t, "https://secure.gravatar.com/avatar/af4f06322c177ef4e1e9b2c424986b54?d=identicon&version=1", resp.AvatarURL)
 	assert.Equal(t, 1, resp.AvatarVersion)
 	assert.Equal(t, "2019-10-20T07:50:53.728864+00:00", resp.DateJoined)
@@ -52,29 +52,21 @@ func (g *GetUsersResponse) UnmarshalJSON(b []byte) error {
 }
 
 type getUsersOptions struct {
-	clientGravatar struct {
-		fieldName string
-		value     *bool
-	}
-	includeCustomProfileFields struct {
-		fieldName string
-		value     *bool
-	}
+	ClientGravatar             *bool `param:"client_gravatar"`
+	IncludeCustomProfileFields *bool `param:"include_custom_profile_fields"`
 }
 
 type GetUsersOption func(*getUsersOptions)
 
 func ClientGravatars(value bool) GetUsersOption {
 	return func(args *getUsersOptions) {
-		args.clientGravatar.fieldName = "client_gravatar"
-		args.clientGravatar.value = &value
+		args.ClientGravatar = &value
 	}
 }
 
 func IncludeCustomProfilesFields(value bool) GetUsersOption {
 	return func(args *getUsersOptions) {
-		args.includeCustomProfileFields.fieldName = "include_custom_profile_fields"
-		args.includeCustomProfileFields.value = &value
+		args.IncludeCustomProfileFields = &value
 	}
 }
 
@@ -84,25 +76,10 @@ func (svc *Service) GetUsers(ctx context.Context, options ...GetUsersOption) (*G
 		method = http.MethodGet
 	)
 
-	msg := map[string]any{}
-
 	opts := getUsersOptions{}
 	for _, opt := range options {
 		opt(&opts)
 	}
 
-	if opts.clientGravatar.value != nil {
-		msg[opts.clientGravatar.fieldName] = *opts.clientGravatar.value
-	}
-
-	if opts.includeCustomProfileFields.value != nil {
-		msg[opts.includeCustomProfileFields.fieldName] = *opts.includeCustomProfileFields.value
-	}
-
-	resp := GetUsersResponse{}
-	if err := svc.client.DoRequest(ctx, method, path, msg, &resp); err != nil {
-		return nil, err
-	}
-
-	return &resp, nil
+	return zulip.Do[GetUsersResponse](ctx, svc.client, method, path, &opts)
 }
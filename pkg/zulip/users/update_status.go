@@ -21,51 +21,35 @@ func (g *UpdateStatusResponse) UnmarshalJSON(b []byte) error {
 }
 
 type updateStatusOptions struct {
-	statusText struct {
-		fieldName string
-		value     *string
-	}
-	emojiName struct {
-		fieldName string
-		value     *string
-	}
-	emojiCode struct {
-		fieldName string
-		value     *string
-	}
-	reactionType struct {
-		fieldName string
-		value     *zulip.ReactionType
-	}
+	StatusText   *string             `param:"status_text"`
+	EmojiName    *string             `param:"emoji_name"`
+	EmojiCode    *string             `param:"emoji_code"`
+	ReactionType *zulip.ReactionType `param:"reaction_type"`
 }
 
 type UpdateStatusOption func(*updateStatusOptions)
 
 func StatusText(value string) UpdateStatusOption {
 	return func(args *updateStatusOptions) {
-		args.statusText.fieldName = "status_text"
-		args.statusText.value = &value
+		args.StatusText = &value
 	}
 }
 
 func StatusEmojiName(value string) UpdateStatusOption {
 	return func(args *updateStatusOptions) {
-		args.emojiName.fieldName = "emoji_name"
-		args.emojiName.value = &value
+		args.EmojiName = &value
 	}
 }
 
 func StatusEmojiCode(value string) UpdateStatusOption {
 	return func(args *updateStatusOptions) {
-		args.emojiCode.fieldName = "emoji_code"
-		args.emojiCode.value = &value
+		args.EmojiCode = &value
 	}
 }
 
 func StatusReactionType(value zulip.ReactionType) UpdateStatusOption {
 	return func(args *updateStatusOptions) {
-		args.reactionType.fieldName = "reaction_type"
-		args.reactionType.value = &value
+		args.ReactionType = &value
 	}
 }
 
@@ -75,33 +59,10 @@ func (svc *Service) UpdateStatus(ctx context.Context, opts ...UpdateStatusOption
 		method = http.MethodPost
 	)
 
-	msg := map[string]any{}
-
 	options := updateStatusOptions{}
 	for _, opt := range opts {
 		opt(&options)
 	}
 
-	if options.statusText.value != nil {
-		msg[options.statusText.fieldName] = *options.statusText.value
-	}
-
-	if options.emojiName.value != nil {
-		msg[options.emojiName.fieldName] = *options.emojiName.value
-	}
-
-	if options.emojiCode.value != nil {
-		msg[options.emojiCode.fieldName] = *options.emojiCode.value
-	}
-
-	if options.reactionType.value != nil {
-		msg[options.reactionType.fieldName] = *options.reactionType.value
-	}
-
-	resp := UpdateStatusResponse{}
-	if err := svc.client.DoRequest(ctx, method, path, msg, &resp); err != nil {
-		return nil, err
-	}
-
-	return &resp, nil
+	return zulip.Do[UpdateStatusResponse](ctx, svc.client, method, path, &options)
 }
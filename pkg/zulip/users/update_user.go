@@ -29,37 +29,23 @@ func (u *UpdateUserResponse) UnmarshalJSON(b []byte) error {
 }
 
 type updateUserOptions struct {
-	fullName struct {
-		fieldName string
-		value     *string
-	}
-	role struct {
-		fieldName string
-		value     *zulip.OrganizationRoleLevel
-	}
-	profileData struct {
-		fieldName string
-		value     *ProfileData
-	}
-	newEmail struct {
-		fieldName string
-		value     *string
-	}
+	FullName    *string                      `param:"full_name"`
+	Role        *zulip.OrganizationRoleLevel `param:"role"`
+	ProfileData *ProfileData                 `param:"profile_data"`
+	NewEmail    *string                      `param:"new_email"`
 }
 
 type UpdateUserOption func(*updateUserOptions)
 
 func FullName(value string) UpdateUserOption {
 	return func(args *updateUserOptions) {
-		args.fullName.fieldName = "full_name"
-		args.fullName.value = &value
+		args.FullName = &value
 	}
 }
 
 func Role(value zulip.OrganizationRoleLevel) UpdateUserOption {
 	return func(args *updateUserOptions) {
-		args.role.fieldName = "role"
-		args.role.value = &value
+		args.Role = &value
 	}
 }
 
@@ -72,15 +58,13 @@ type ProfileDataItem struct {
 
 func SetProfileData(value ProfileData) UpdateUserOption {
 	return func(args *updateUserOptions) {
-		args.profileData.fieldName = "profile_data"
-		args.profileData.value = &value
+		args.ProfileData = &value
 	}
 }
 
 func NewEmail(value string) UpdateUserOption {
 	return func(args *updateUserOptions) {
-		args.newEmail.fieldName = "new_email"
-		args.newEmail.value = &value
+		args.NewEmail = &value
 	}
 }
 
@@ -109,38 +93,10 @@ func (svc *Service) updateUser(ctx context.Context, patchPath string, options ..
 		method = http.MethodPatch
 	)
 
-	msg := map[string]any{}
-
 	opts := updateUserOptions{}
 	for _, opt := range options {
 		opt(&opts)
 	}
 
-	if opts.fullName.value != nil {
-		msg[opts.fullName.fieldName] = *opts.fullName.value
-	}
-
-	if opts.role.value != nil {
-		msg[opts.role.fieldName] = *opts.role.value
-	}
-
-	if opts.profileData.value != nil {
-		jsonData, err := json.Marshal(opts.profileData.value)
-		if err != nil {
-			return nil, err
-		}
-
-		msg[opts.profileData.fieldName] = string(jsonData)
-	}
-
-	if opts.newEmail.value != nil {
-		msg[opts.newEmail.fieldName] = *opts.newEmail.value
-	}
-
-	resp := UpdateUserResponse{}
-	if err := svc.client.DoRequest(ctx, method, patchPath, msg, &resp); err != nil {
-		return nil, err
-	}
-
-	return &resp, nil
+	return zulip.Do[UpdateUserResponse](ctx, svc.client, method, patchPath, &opts)
 }
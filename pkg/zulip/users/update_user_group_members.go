@@ -0,0 +1,54 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip"
+)
+
+type UpdateUserGroupMembersResponse struct {
+	zulip.APIResponseBase
+}
+
+func (u *UpdateUserGroupMembersResponse) UnmarshalJSON(b []byte) error {
+	return json.Unmarshal(b, &u.APIResponseBase)
+}
+
+type updateUserGroupMembersOptions struct {
+	Add    []int `param:"add,omitempty"`
+	Delete []int `param:"delete,omitempty"`
+}
+
+type UpdateUserGroupMembersOption func(*updateUserGroupMembersOptions)
+
+func AddGroupMembers(userIDs ...int) UpdateUserGroupMembersOption {
+	return func(args *updateUserGroupMembersOptions) {
+		args.Add = append(args.Add, userIDs...)
+	}
+}
+
+func RemoveGroupMembers(userIDs ...int) UpdateUserGroupMembersOption {
+	return func(args *updateUserGroupMembersOptions) {
+		args.Delete = append(args.Delete, userIDs...)
+	}
+}
+
+// UpdateUserGroupMembers Add or remove direct members of a user group.
+func (svc *Service) UpdateUserGroupMembers(ctx context.Context, groupID int, options ...UpdateUserGroupMembersOption) (*UpdateUserGroupMembersResponse, error) {
+	const (
+		path   = "/api/v1/user_groups/%d/members"
+		method = http.MethodPost
+	)
+
+	pathPatch := fmt.Sprintf(path, groupID)
+
+	opts := updateUserGroupMembersOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	return zulip.Do[UpdateUserGroupMembersResponse](ctx, svc.client, method, pathPatch, &opts)
+}
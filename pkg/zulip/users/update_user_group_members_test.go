@@ -0,0 +1,36 @@
+package users_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.mau.fi/mautrix-zulip/pkg/zulip/users"
+)
+
+func TestUpdateUserGroupMembers(t *testing.T) {
+	client := createMockClient(`{
+    "msg": "",
+    "result": "success"
+}`)
+
+	userSvc := users.NewService(client)
+
+	resp, err := userSvc.UpdateUserGroupMembers(context.Background(), 5,
+		users.AddGroupMembers(1, 2),
+		users.RemoveGroupMembers(3),
+	)
+	require.NoError(t, err)
+	assert.True(t, resp.IsSuccess())
+
+	// validate the parameters sent are correct
+	assert.Equal(t, http.MethodPost, client.(*mockClient).method)
+	assert.Equal(t, "/api/v1/user_groups/5/members", client.(*mockClient).path)
+	assert.Equal(t, map[string]any{
+		"add":    "[1,2]",
+		"delete": "[3]",
+	}, client.(*mockClient).paramsSent)
+}
@@ -11,6 +11,7 @@
 //   - Get user presence (individual or all users)
 //   - Update user presence
 //   - Update user settings
+//   - Get, create and update user groups
 //
 // See https://zulip.com/api/ for the complete API documentation.
 package users